@@ -14,3 +14,11 @@ type paramUnion = param.APIUnion
 type paramObj = param.APIObject
 
 type Error = apierror.Error
+
+// BEGIN CUSTOM CODE - not generated by Stainless.
+
+// CircuitOpenError is returned when a circuit breaker set with
+// option.NewCircuitBreaker fails a request without sending it.
+type CircuitOpenError = apierror.CircuitOpenError
+
+// END CUSTOM CODE - not generated by Stainless.
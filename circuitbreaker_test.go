@@ -0,0 +1,172 @@
+// Custom tests. Not generated by Stainless.
+package stagehand_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var mu sync.Mutex
+	healthy := false
+	sent := 0
+	transitions := []string{}
+	breaker := option.NewCircuitBreaker(option.CircuitBreakerConfig{
+		MinRequests: 2,
+		OpenTimeout: 50 * time.Millisecond,
+		OnStateChange: func(key option.CircuitKey, from, to option.CircuitState) {
+			transitions = append(transitions, key.Model+": "+from.String()+" -> "+to.String())
+		},
+	})
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithRetryPolicy(option.ExponentialRetryPolicy(time.Millisecond, time.Millisecond, 0)),
+		breaker,
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					sent++
+					if !healthy {
+						return jsonResponse(http.StatusServiceUnavailable, `{}`), nil
+					}
+					return jsonResponse(http.StatusOK, `{"success":true,"data":{"available":true,"sessionId":"session"}}`), nil
+				},
+			},
+		}),
+	)
+	start := func(model string) error {
+		_, err := client.Sessions.Start(context.Background(), stagehand.SessionStartParams{ModelName: model})
+		return err
+	}
+
+	// The first attempt and its retry fail, which opens the circuit, so the
+	// second retry fails fast.
+	err := start("openai/gpt-5.4-mini")
+	var circuitErr *stagehand.CircuitOpenError
+	if !errors.As(err, &circuitErr) || circuitErr.Model != "openai/gpt-5.4-mini" {
+		t.Fatalf("expected a CircuitOpenError, got %v", err)
+	}
+	if sent != 2 {
+		t.Fatalf("expected 2 requests before the circuit opened, got %d", sent)
+	}
+	if err := start("openai/gpt-5.4-mini"); !errors.As(err, &circuitErr) || sent != 2 {
+		t.Fatalf("expected the open circuit to fail fast, got %v after %d requests", err, sent)
+	}
+
+	// Other models are tracked separately.
+	mu.Lock()
+	healthy = true
+	mu.Unlock()
+	if err := start("anthropic/claude-sonnet-4-5"); err != nil {
+		t.Fatalf("expected another model to be unaffected, got %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if err := start("openai/gpt-5.4-mini"); err != nil {
+		t.Fatalf("expected the probe to succeed, got %v", err)
+	}
+	key := option.CircuitKey{BaseURL: "http://stagehand.test", Model: "openai/gpt-5.4-mini"}
+	if state := breaker.State(key); state != option.CircuitClosed {
+		t.Fatalf("expected the circuit to close, got %v", state)
+	}
+
+	want := []string{
+		"openai/gpt-5.4-mini: closed -> open",
+		"openai/gpt-5.4-mini: open -> half-open",
+		"openai/gpt-5.4-mini: half-open -> closed",
+	}
+	if !reflect.DeepEqual(transitions, want) {
+		t.Fatalf("expected transitions %v, got %v", want, transitions)
+	}
+}
+
+func TestCircuitBreaker_CancelledProbe(t *testing.T) {
+	var mu sync.Mutex
+	block := false
+	sent := 0
+	breaker := option.NewCircuitBreaker(option.CircuitBreakerConfig{MinRequests: 1, OpenTimeout: 10 * time.Millisecond})
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(0),
+		breaker,
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					mu.Lock()
+					sent++
+					blocked := block
+					mu.Unlock()
+					if blocked {
+						<-req.Context().Done()
+						return nil, req.Context().Err()
+					}
+					return jsonResponse(http.StatusServiceUnavailable, `{}`), nil
+				},
+			},
+		}),
+	)
+	start := func(ctx context.Context) error {
+		_, err := client.Sessions.Start(ctx, stagehand.SessionStartParams{ModelName: "m"})
+		return err
+	}
+
+	if err := start(context.Background()); err == nil {
+		t.Fatal("expected the request to fail")
+	}
+	key := option.CircuitKey{BaseURL: "http://stagehand.test", Model: "m"}
+	time.Sleep(20 * time.Millisecond)
+	if state := breaker.State(key); state != option.CircuitHalfOpen {
+		t.Fatalf("expected a half-open circuit, got %v", state)
+	}
+
+	// A cancelled probe neither closes nor reopens the circuit, and another
+	// request may probe in its place.
+	mu.Lock()
+	block = true
+	mu.Unlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	probeErr := make(chan error, 1)
+	go func() { probeErr <- start(ctx) }()
+	for sending := 0; sending != 2; time.Sleep(time.Millisecond) {
+		mu.Lock()
+		sending = sent
+		mu.Unlock()
+	}
+	// While the probe is in flight, other requests fail fast without a
+	// retry time, since the probe's end is unknown.
+	var circuitErr *stagehand.CircuitOpenError
+	if err := start(context.Background()); !errors.As(err, &circuitErr) || !circuitErr.RetryAt.IsZero() {
+		t.Fatalf("expected a CircuitOpenError without a retry time, got %v", err)
+	}
+	cancel()
+	if err := <-probeErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the probe to be cancelled, got %v", err)
+	}
+	if state := breaker.State(key); state != option.CircuitHalfOpen {
+		t.Fatalf("expected the circuit to stay half-open, got %v", state)
+	}
+	mu.Lock()
+	block = false
+	mu.Unlock()
+	if err := start(context.Background()); err == nil || errors.As(err, &circuitErr) {
+		t.Fatalf("expected another probe to be sent, got %v", err)
+	}
+	if sent != 3 {
+		t.Fatalf("expected 3 requests, got %d", sent)
+	}
+	if state := breaker.State(key); state != option.CircuitOpen {
+		t.Fatalf("expected the failed probe to reopen the circuit, got %v", state)
+	}
+}
@@ -18,6 +18,11 @@ import (
 type Client struct {
 	Options  []option.RequestOption
 	Sessions SessionService
+	// BEGIN CUSTOM CODE - not generated by Stainless.
+	Contexts  ContextService
+	Downloads DownloadService
+	Uploads   UploadService
+	// END CUSTOM CODE - not generated by Stainless.
 }
 
 // DefaultClientOptions read from the environment (BROWSERBASE_API_KEY,
@@ -25,6 +30,19 @@ type Client struct {
 // to initialize new clients.
 func DefaultClientOptions() []option.RequestOption {
 	defaults := []option.RequestOption{option.WithEnvironmentProduction()}
+	// BEGIN CUSTOM CODE - not generated by Stainless.
+	// Settings from the configuration file come before the environment so that
+	// environment variables override them. A profile that cannot be loaded
+	// fails every request rather than falling back to the defaults;
+	// NewClientFromConfig reports it when the client is built.
+	profile, err := defaultProfileOptions()
+	if err != nil {
+		profile = []option.RequestOption{requestconfig.RequestOptionFunc(func(*requestconfig.RequestConfig) error {
+			return err
+		})}
+	}
+	defaults = append(defaults, profile...)
+	// END CUSTOM CODE - not generated by Stainless.
 	if o, ok := os.LookupEnv("STAGEHAND_BASE_URL"); ok {
 		defaults = append(defaults, option.WithBaseURL(o))
 	}
@@ -48,14 +66,25 @@ func DefaultClientOptions() []option.RequestOption {
 func NewClient(opts ...option.RequestOption) (r Client) {
 	opts = append(DefaultClientOptions(), opts...)
 	// BEGIN CUSTOM CODE - not generated by Stainless.
+	transport, err := transportFromOptions(opts)
+	opts = withTransport(opts, transport, err)
+	opts = append(opts, newSessionTracker())
+	opts = append(opts, idempotencyKeys{})
 	if serverModeFromOptions(opts) == "local" {
-		opts = append(opts, newLocalServerOption())
+		opts = append(opts, newLocalServerOption(localServerScopeFromOptions(opts), localDriverVersionFromOptions(opts), transport))
+	} else {
+		opts = append(opts, newRegionRouter(opts, transport))
 	}
 	// END CUSTOM CODE - not generated by Stainless.
 
 	r = Client{Options: opts}
 
 	r.Sessions = NewSessionService(opts...)
+	// BEGIN CUSTOM CODE - not generated by Stainless.
+	r.Contexts = NewContextService(opts...)
+	r.Downloads = NewDownloadService(opts...)
+	r.Uploads = NewUploadService(opts...)
+	// END CUSTOM CODE - not generated by Stainless.
 
 	return
 }
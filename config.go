@@ -0,0 +1,259 @@
+// Custom code. Not generated by Stainless.
+package stagehand
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+// Config is the content of a Stagehand configuration file holding named
+// profiles, written in JSON, YAML or TOML:
+//
+//	{
+//	  "defaultProfile": "staging",
+//	  "profiles": {
+//	    "staging": {"baseURL": "https://staging.example.com/", "model": "openai/gpt-5.4-mini"},
+//	    "local": {"server": "local", "localDriverVersion": "3.0.1"}
+//	  }
+//	}
+type Config struct {
+	// DefaultProfile is the profile used when STAGEHAND_PROFILE is not set.
+	// Defaults to "default".
+	DefaultProfile string             `json:"defaultProfile,omitempty"`
+	Profiles       map[string]Profile `json:"profiles,omitempty"`
+}
+
+// Profile holds client settings. Empty fields are left at their defaults.
+type Profile struct {
+	BaseURL string `json:"baseURL,omitempty"`
+	// Server is "remote" or "local".
+	Server string `json:"server,omitempty"`
+	// LocalServerScope is "process" or "host".
+	LocalServerScope   string `json:"localServerScope,omitempty"`
+	LocalDriverVersion string `json:"localDriverVersion,omitempty"`
+	// Region is the Browserbase region of started sessions.
+	Region string `json:"region,omitempty"`
+	// Model is the model of sessions started without one.
+	Model      string `json:"model,omitempty"`
+	MaxRetries *int   `json:"maxRetries,omitempty"`
+	// RequestTimeout is a duration such as "30s".
+	RequestTimeout       string `json:"requestTimeout,omitempty"`
+	BrowserbaseProjectID string `json:"browserbaseProjectID,omitempty"`
+	// Proxy is the URL of the proxy requests go through.
+	Proxy string `json:"proxy,omitempty"`
+	// CABundle is a PEM file of additionally trusted certificate authorities.
+	CABundle string `json:"caBundle,omitempty"`
+	// BrowserbaseSession holds defaults for the Browserbase session
+	// parameters of started sessions.
+	BrowserbaseSession map[string]any `json:"browserbaseSession,omitempty"`
+}
+
+// configFileNames are the names the configuration file is looked up by, in
+// order.
+var configFileNames = []string{"config.json", "config.yaml", "config.yml", "config.toml"}
+
+// ConfigPath returns the path of the configuration file: STAGEHAND_CONFIG if
+// set, otherwise the first of config.json, config.yaml, config.yml and
+// config.toml that exists in the stagehand directory of the user
+// configuration directory, such as ~/.config on Linux. If none exists, the
+// path of config.json is returned.
+func ConfigPath() (string, error) {
+	if path := os.Getenv("STAGEHAND_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "stagehand")
+	for _, name := range configFileNames {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return filepath.Join(dir, name), nil
+		}
+	}
+	return filepath.Join(dir, configFileNames[0]), nil
+}
+
+// LoadConfig reads the configuration file at path. Files named *.yaml or
+// *.yml are read as YAML, files named *.toml as TOML, and others as JSON.
+// YAML anchors, tags and multi-line strings, and TOML arrays of tables, dates
+// and multi-line strings are not supported.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		doc, err = parseYAML(string(data))
+	case ".toml":
+		doc, err = parseTOML(string(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stagehand: invalid config file %s: %w", path, err)
+	}
+	if doc != nil {
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("stagehand: invalid config file %s: %w", path, err)
+		}
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("stagehand: invalid config file %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Profile returns the named profile, or if name is empty the one selected by
+// STAGEHAND_PROFILE or DefaultProfile.
+func (c *Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = os.Getenv("STAGEHAND_PROFILE")
+	}
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		name = "default"
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("stagehand: profile %q not found", name)
+	}
+	return p, nil
+}
+
+// Options returns the request options that apply the profile. Invalid
+// settings, such as an unknown server or an unreadable CA bundle, are
+// reported here rather than when the options are applied.
+func (p Profile) Options() ([]option.RequestOption, error) {
+	var opts []option.RequestOption
+	if p.BaseURL != "" {
+		opts = append(opts, option.WithBaseURL(p.BaseURL))
+	}
+	if p.Server != "" {
+		opts = append(opts, option.WithServer(p.Server))
+	}
+	if p.LocalServerScope != "" {
+		opts = append(opts, option.WithLocalServerScope(p.LocalServerScope))
+	}
+	if p.LocalDriverVersion != "" {
+		opts = append(opts, option.WithLocalDriverVersion(p.LocalDriverVersion))
+	}
+	if p.Model != "" {
+		opts = append(opts, option.WithDefaultModel(p.Model))
+	}
+	if p.MaxRetries != nil {
+		if *p.MaxRetries < 0 {
+			return nil, fmt.Errorf("stagehand: maxRetries cannot be negative")
+		}
+		opts = append(opts, option.WithMaxRetries(*p.MaxRetries))
+	}
+	if p.RequestTimeout != "" {
+		d, err := time.ParseDuration(p.RequestTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("stagehand: invalid requestTimeout: %w", err)
+		}
+		opts = append(opts, option.WithRequestTimeout(d))
+	}
+	if p.BrowserbaseProjectID != "" {
+		opts = append(opts, option.WithBrowserbaseProjectID(p.BrowserbaseProjectID))
+	}
+	if p.Proxy != "" {
+		opts = append(opts, option.WithProxy(p.Proxy))
+	}
+	if p.CABundle != "" {
+		opts = append(opts, option.WithCABundle(p.CABundle))
+	}
+	session := p.BrowserbaseSession
+	if p.Region != "" {
+		session = map[string]any{"region": p.Region}
+		for k, v := range p.BrowserbaseSession {
+			session[k] = v
+		}
+	}
+	if len(session) > 0 {
+		opts = append(opts, option.WithBrowserbaseSessionDefaults(session))
+	}
+	if err := option.Validate(opts...); err != nil {
+		return nil, fmt.Errorf("stagehand: invalid profile: %w", err)
+	}
+	return opts, nil
+}
+
+// LoadProfileOptions returns the options of the named profile from the
+// configuration file at [ConfigPath]. An empty name selects the profile as
+// described for [Config.Profile].
+func LoadProfileOptions(name string) ([]option.RequestOption, error) {
+	path, err := ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := cfg.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+	return p.Options()
+}
+
+// defaultProfileOptions returns the options of the profile selected by the
+// environment. A missing configuration file is not an error unless
+// STAGEHAND_PROFILE asks for a profile, nor is a missing "default" profile
+// when no other profile was asked for.
+func defaultProfileOptions() ([]option.RequestOption, error) {
+	path, err := ConfigPath()
+	if err != nil {
+		return nil, nil
+	}
+	cfg, err := LoadConfig(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if name := os.Getenv("STAGEHAND_PROFILE"); name != "" {
+			return nil, fmt.Errorf("stagehand: profile %q not found: no config file at %s", name, path)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := cfg.Profiles["default"]; !ok && os.Getenv("STAGEHAND_PROFILE") == "" && cfg.DefaultProfile == "" {
+		return nil, nil
+	}
+	p, err := cfg.Profile("")
+	if err != nil {
+		return nil, err
+	}
+	return p.Options()
+}
+
+// NewClientFromConfig is like [NewClient], but returns an error if the
+// configuration file cannot be read or parsed, or if the profile selected by
+// STAGEHAND_PROFILE or the file does not exist or is invalid. [NewClient]
+// makes every request fail with that error instead, rather than falling back
+// to the default settings. Options that were
+// invalid when built, such as a malformed proxy URL, are reported here too,
+// rather than by every request.
+func NewClientFromConfig(opts ...option.RequestOption) (Client, error) {
+	if _, err := defaultProfileOptions(); err != nil {
+		return Client{}, err
+	}
+	all := append(DefaultClientOptions(), opts...)
+	if err := option.Validate(all...); err != nil {
+		return Client{}, err
+	}
+	if _, err := transportFromOptions(all); err != nil {
+		return Client{}, err
+	}
+	return NewClient(opts...), nil
+}
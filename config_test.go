@@ -0,0 +1,246 @@
+// Custom tests. Not generated by Stainless.
+package stagehand_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+	"github.com/tidwall/gjson"
+)
+
+// TestMain keeps the configuration file and profile of whoever runs the tests
+// away from the clients they create.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "stagehand-config")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("STAGEHAND_CONFIG", filepath.Join(dir, "config.json"))
+	os.Unsetenv("STAGEHAND_PROFILE")
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestConfigProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(`{
+		"defaultProfile": "prod",
+		"profiles": {
+			"prod": {"baseURL": "http://prod.test/"},
+			"staging": {
+				"baseURL": "http://staging.test/",
+				"model": "openai/gpt-5.4-mini",
+				"region": "us-west-2",
+				"browserbaseSession": {"keepAlive": true, "browserSettings": {"blockAds": true}}
+			}
+		}
+	}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("STAGEHAND_CONFIG", path)
+	t.Setenv("STAGEHAND_PROFILE", "staging")
+	t.Setenv("STAGEHAND_BASE_URL", "")
+	os.Unsetenv("STAGEHAND_BASE_URL")
+
+	var host string
+	var body []byte
+	newClient := func() stagehand.Client {
+		return stagehand.NewClient(
+			option.WithModelAPIKey("My Model API Key"),
+			option.WithHTTPClient(&http.Client{
+				Transport: &closureTransport{
+					fn: func(req *http.Request) (*http.Response, error) {
+						host = req.URL.Host
+						body, _ = io.ReadAll(req.Body)
+						return jsonResponse(http.StatusOK, `{"success":true,"data":{"available":true,"sessionId":"session"}}`), nil
+					},
+				},
+			}),
+		)
+	}
+	ctx := context.Background()
+
+	client := newClient()
+	_, err = client.Sessions.Start(ctx, stagehand.SessionStartParams{
+		BrowserbaseSessionCreateParams: stagehand.SessionStartParamsBrowserbaseSessionCreateParams{
+			KeepAlive: stagehand.Bool(false),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if host != "staging.test" {
+		t.Fatalf("expected the staging base URL, got %q", host)
+	}
+	for path, want := range map[string]string{
+		"modelName":                                               "openai/gpt-5.4-mini",
+		"browserbaseSessionCreateParams.region":                   "us-west-2",
+		"browserbaseSessionCreateParams.keepAlive":                "false",
+		"browserbaseSessionCreateParams.browserSettings.blockAds": "true",
+	} {
+		if got := gjson.GetBytes(body, path).String(); got != want {
+			t.Errorf("expected %s to be %q, got %q in %s", path, want, got, body)
+		}
+	}
+
+	t.Setenv("STAGEHAND_BASE_URL", "http://env.test/")
+	client = newClient()
+	_, _ = client.Sessions.Start(ctx, stagehand.SessionStartParams{ModelName: "anthropic/claude-haiku-4-5"})
+	if host != "env.test" {
+		t.Fatalf("expected the environment to override the profile, got %q", host)
+	}
+	if got := gjson.GetBytes(body, "modelName").String(); got != "anthropic/claude-haiku-4-5" {
+		t.Fatalf("expected an explicit model to be kept, got %q", got)
+	}
+
+	// A profile that cannot be loaded fails requests rather than falling back
+	// to the defaults, and NewClientFromConfig reports it up front.
+	t.Setenv("STAGEHAND_BASE_URL", "")
+	os.Unsetenv("STAGEHAND_BASE_URL")
+	t.Setenv("STAGEHAND_PROFILE", "stagng")
+	host = ""
+	client = newClient()
+	if _, err := client.Sessions.Start(ctx, stagehand.SessionStartParams{ModelName: "m"}); err == nil || !strings.Contains(err.Error(), `profile "stagng" not found`) {
+		t.Fatalf("expected an unknown profile to fail the request, got %v", err)
+	}
+	if host != "" {
+		t.Fatalf("expected no request to be sent, got one to %q", host)
+	}
+	if _, err := stagehand.NewClientFromConfig(); err == nil || !strings.Contains(err.Error(), `profile "stagng" not found`) {
+		t.Fatalf("expected an unknown profile to be reported, got %v", err)
+	}
+
+	t.Setenv("STAGEHAND_PROFILE", "")
+	if _, err := stagehand.NewClientFromConfig(); err != nil {
+		t.Fatalf("NewClientFromConfig: %v", err)
+	}
+	for name, content := range map[string]string{
+		"malformed file":   `profiles: {}`,
+		"invalid server":   `{"profiles": {"default": {"server": "cloud"}}}`,
+		"invalid proxy":    `{"profiles": {"default": {"proxy": "ftp://proxy.test"}}}`,
+		"missing CA file":  `{"profiles": {"default": {"caBundle": "/missing/ca.pem"}}}`,
+		"negative retries": `{"profiles": {"default": {"maxRetries": -1}}}`,
+	} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stagehand.NewClientFromConfig(); err == nil || !strings.HasPrefix(err.Error(), "stagehand: ") {
+			t.Fatalf("%s: expected an error, got %v", name, err)
+		}
+		client := newClient()
+		if _, err := client.Sessions.Start(ctx, stagehand.SessionStartParams{ModelName: "m"}); err == nil {
+			t.Fatalf("%s: expected the request to fail", name)
+		}
+	}
+
+	t.Setenv("STAGEHAND_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
+	if _, err := stagehand.NewClientFromConfig(); err != nil {
+		t.Fatalf("expected a missing file to be ignored, got %v", err)
+	}
+	t.Setenv("STAGEHAND_PROFILE", "staging")
+	if _, err := stagehand.NewClientFromConfig(); err == nil || !strings.Contains(err.Error(), "no config file") {
+		t.Fatalf("expected a profile without a file to be reported, got %v", err)
+	}
+}
+
+func TestLoadConfig_Formats(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	load := func(path string) *stagehand.Config {
+		t.Helper()
+		cfg, err := stagehand.LoadConfig(path)
+		if err != nil {
+			t.Fatalf("LoadConfig(%s): %v", filepath.Base(path), err)
+		}
+		return cfg
+	}
+
+	want := load(write("config.json", `{
+		"defaultProfile": "staging",
+		"profiles": {
+			"staging": {
+				"baseURL": "https://staging.example.com/",
+				"model": "openai/gpt-5.4-mini",
+				"maxRetries": 4,
+				"requestTimeout": "2m",
+				"browserbaseSession": {"keepAlive": true, "browserSettings": {"blockAds": true, "locales": ["en-US", "de-DE"]}, "note": "a, b # c"}
+			},
+			"local dev": {"server": "local", "localDriverVersion": "3.0.1"}
+		}
+	}`))
+
+	yaml := load(write("config.yaml", `---
+# Profiles for the team.
+defaultProfile: staging
+profiles:
+  staging:
+    baseURL: "https://staging.example.com/"
+    model: openai/gpt-5.4-mini   # the default model
+    maxRetries: 4
+    requestTimeout: 2m
+    browserbaseSession:
+      keepAlive: true
+      browserSettings:
+        blockAds: true
+        locales:
+        - en-US
+        - 'de-DE'
+      note: "a, b # c"
+  "local dev": {server: local, localDriverVersion: "3.0.1"}
+`))
+
+	toml := load(write("config.toml", `# Profiles for the team.
+defaultProfile = "staging"
+
+[profiles.staging]
+baseURL = "https://staging.example.com/"
+model = "openai/gpt-5.4-mini" # the default model
+maxRetries = 4
+requestTimeout = "2m"
+browserbaseSession = { keepAlive = true, note = "a, b # c" }
+browserbaseSession.browserSettings.blockAds = true
+browserbaseSession.browserSettings.locales = [
+  "en-US",
+  'de-DE',
+]
+
+[profiles."local dev"]
+server = "local"
+localDriverVersion = "3.0.1"
+`))
+
+	for name, got := range map[string]*stagehand.Config{"YAML": yaml, "TOML": toml} {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s config %+v differs from JSON config %+v", name, got, want)
+		}
+	}
+
+	for name, content := range map[string]string{
+		"bad.yaml": "profiles:\n  a: 1\n   b: 2\n",
+		"tab.yaml": "profiles:\n\tdefault: {}\n",
+		"ref.yaml": "profiles: &anchor {}\n",
+		"bad.toml": "[profiles.default]\nserver = local\n",
+		"dup.toml": "defaultProfile = \"a\"\ndefaultProfile = \"b\"\n",
+		"aot.toml": "[[profiles]]\n",
+	} {
+		if _, err := stagehand.LoadConfig(write(name, content)); err == nil || !strings.Contains(err.Error(), "invalid config file") {
+			t.Errorf("%s: expected an error, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,578 @@
+// Custom code. Not generated by Stainless.
+package stagehand
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The configuration file may also be written in YAML or TOML. Both are parsed
+// into the generic values encoding/json produces, then decoded like a JSON
+// file. Only the subsets needed for configuration files are supported: YAML
+// block and flow collections and scalars, without anchors, tags or multi-line
+// strings, and TOML tables, key/value pairs, arrays and inline tables, without
+// arrays of tables, dates or multi-line strings.
+
+// yamlLine is a line of a YAML document without its indentation and comment.
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// parseYAML parses a YAML document whose top level is a mapping.
+func parseYAML(data string) (map[string]any, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		text := strings.TrimRight(stripComment(raw, "#"), " \t")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || (len(lines) == 0 && trimmed == "---") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs cannot indent YAML", i+1)
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+	p := &yamlParser{lines: lines}
+	v, err := p.block(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, errors.New("the document is not a mapping")
+	}
+	return m, nil
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// block parses the mapping or sequence whose lines are indented by indent.
+func (p *yamlParser) block(indent int) (any, error) {
+	if isYAMLSequenceItem(p.lines[p.pos].text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) sequence(indent int) (any, error) {
+	items := []any{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text) {
+		line := p.lines[p.pos]
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" {
+			p.pos++
+			v, err := p.nested(indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+			continue
+		}
+		if _, _, ok := cutYAMLKey(rest); ok {
+			// "- key: value" starts a mapping indented like its first key.
+			p.lines[p.pos] = yamlLine{num: line.num, indent: indent + len(line.text) - len(rest), text: rest}
+			v, err := p.mapping(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+			continue
+		}
+		v, err := parseYAMLValue(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.num, err)
+		}
+		items = append(items, v)
+		p.pos++
+	}
+	return items, nil
+}
+
+func (p *yamlParser) mapping(indent int) (any, error) {
+	m := map[string]any{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		line := p.lines[p.pos]
+		key, rest, ok := cutYAMLKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a key", line.num)
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.num, key)
+		}
+		p.pos++
+		if rest != "" {
+			v, err := parseYAMLValue(rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line.num, err)
+			}
+			m[key] = v
+			continue
+		}
+		v, err := p.nested(indent)
+		if err != nil {
+			return nil, err
+		}
+		// A sequence may be indented like the key it belongs to.
+		if v == nil && p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text) {
+			if v, err = p.sequence(indent); err != nil {
+				return nil, err
+			}
+		}
+		m[key] = v
+	}
+	if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	return m, nil
+}
+
+// nested parses the block below a key or sequence item that has no inline
+// value, which is null if the next line is not indented further.
+func (p *yamlParser) nested(indent int) (any, error) {
+	if p.pos >= len(p.lines) || p.lines[p.pos].indent <= indent {
+		return nil, nil
+	}
+	return p.block(p.lines[p.pos].indent)
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// cutYAMLKey splits "key: value" into its key and value.
+func cutYAMLKey(text string) (key, rest string, ok bool) {
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return "", "", false
+	}
+	i := 0
+	if text != "" && (text[0] == '"' || text[0] == '\'') {
+		end := closingQuote(text)
+		if end < 0 {
+			return "", "", false
+		}
+		i = end + 1
+	}
+	for ; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			key = strings.TrimSpace(text[:i])
+			if unquoted, err := unquoteScalar(key); err == nil {
+				key = unquoted
+			} else {
+				return "", "", false
+			}
+			return key, strings.TrimSpace(text[i+1:]), key != ""
+		}
+	}
+	return "", "", false
+}
+
+// parseYAMLValue parses an inline YAML value: a flow collection or a scalar.
+func parseYAMLValue(text string) (any, error) {
+	switch text[0] {
+	case '|', '>':
+		return nil, errors.New("multi-line strings are not supported")
+	case '&', '*', '!':
+		return nil, errors.New("anchors, aliases and tags are not supported")
+	}
+	s := &flowScanner{text: text, sep: ':'}
+	if !strings.ContainsRune(`[{"'`, rune(text[0])) {
+		// Plain scalars outside flow collections may contain commas.
+		return s.plain(text)
+	}
+	v, err := s.value()
+	if err != nil {
+		return nil, err
+	}
+	if s.skipSpace(); s.pos < len(s.text) {
+		return nil, fmt.Errorf("unexpected %q", s.text[s.pos:])
+	}
+	return v, nil
+}
+
+// parseTOML parses a TOML document.
+func parseTOML(data string) (map[string]any, error) {
+	root := map[string]any{}
+	table := root
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		num := i + 1
+		line := strings.TrimSpace(stripComment(lines[i], "#"))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[[") {
+			return nil, fmt.Errorf("line %d: arrays of tables are not supported", num)
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated table header", num)
+			}
+			keys, err := splitTOMLKey(line[1 : len(line)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+			if table, err = tomlTable(root, keys); err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+			continue
+		}
+
+		key, value, ok := cutTOMLKey(line)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", num)
+		}
+		// Arrays and inline tables may continue on the following lines.
+		for !balanced(value) && i+1 < len(lines) {
+			i++
+			value += " " + strings.TrimSpace(stripComment(lines[i], "#"))
+		}
+		keys, err := splitTOMLKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		v, err := parseTOMLValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		if err := setTOMLKey(table, keys, v); err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+	}
+	return root, nil
+}
+
+// tomlTable returns the table at keys, creating the missing ones.
+func tomlTable(root map[string]any, keys []string) (map[string]any, error) {
+	table := root
+	for _, key := range keys {
+		switch v := table[key].(type) {
+		case nil:
+			next := map[string]any{}
+			table[key] = next
+			table = next
+		case map[string]any:
+			table = v
+		default:
+			return nil, fmt.Errorf("key %q is not a table", key)
+		}
+	}
+	return table, nil
+}
+
+func setTOMLKey(table map[string]any, keys []string, v any) error {
+	table, err := tomlTable(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	key := keys[len(keys)-1]
+	if _, dup := table[key]; dup {
+		return fmt.Errorf("duplicate key %q", key)
+	}
+	table[key] = v
+	return nil
+}
+
+// cutTOMLKey splits "key = value" at the first "=" outside quotes.
+func cutTOMLKey(line string) (key, value string, ok bool) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"', '\'':
+			end := closingQuote(line[i:])
+			if end < 0 {
+				return "", "", false
+			}
+			i += end
+		case '=':
+			key, value = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+			return key, value, key != "" && value != ""
+		}
+	}
+	return "", "", false
+}
+
+// splitTOMLKey splits a dotted key, such as profiles."my profile".model.
+func splitTOMLKey(key string) ([]string, error) {
+	var keys []string
+	for key = strings.TrimSpace(key); ; {
+		var part string
+		if key != "" && (key[0] == '"' || key[0] == '\'') {
+			end := closingQuote(key)
+			if end < 0 {
+				return nil, errors.New("unterminated quoted key")
+			}
+			unquoted, err := unquoteScalar(key[:end+1])
+			if err != nil {
+				return nil, err
+			}
+			part, key = unquoted, strings.TrimSpace(key[end+1:])
+		} else {
+			i := strings.IndexByte(key, '.')
+			if i < 0 {
+				i = len(key)
+			}
+			part, key = strings.TrimSpace(key[:i]), strings.TrimSpace(key[i:])
+			if part == "" || strings.ContainsAny(part, " \t\"'") {
+				return nil, fmt.Errorf("invalid key %q", part)
+			}
+		}
+		keys = append(keys, part)
+		if key == "" {
+			return keys, nil
+		}
+		if key[0] != '.' {
+			return nil, fmt.Errorf("invalid key %q", key)
+		}
+		key = strings.TrimSpace(key[1:])
+	}
+}
+
+func parseTOMLValue(text string) (any, error) {
+	if strings.HasPrefix(text, `"""`) || strings.HasPrefix(text, "'''") {
+		return nil, errors.New("multi-line strings are not supported")
+	}
+	s := &flowScanner{text: text, sep: '=', toml: true}
+	v, err := s.value()
+	if err != nil {
+		return nil, err
+	}
+	if s.skipSpace(); s.pos < len(s.text) {
+		return nil, fmt.Errorf("unexpected %q", s.text[s.pos:])
+	}
+	return v, nil
+}
+
+// flowScanner parses inline values: YAML flow collections and scalars, or
+// TOML values, whose inline tables separate keys and values with "=" instead
+// of ":".
+type flowScanner struct {
+	text string
+	pos  int
+	sep  byte
+	toml bool
+}
+
+func (s *flowScanner) skipSpace() {
+	for s.pos < len(s.text) && (s.text[s.pos] == ' ' || s.text[s.pos] == '\t') {
+		s.pos++
+	}
+}
+
+func (s *flowScanner) value() (any, error) {
+	s.skipSpace()
+	if s.pos >= len(s.text) {
+		return nil, errors.New("missing value")
+	}
+	switch s.text[s.pos] {
+	case '[':
+		return s.array()
+	case '{':
+		return s.table()
+	case '"', '\'':
+		end := closingQuote(s.text[s.pos:])
+		if end < 0 {
+			return nil, errors.New("unterminated string")
+		}
+		quoted := s.text[s.pos : s.pos+end+1]
+		s.pos += end + 1
+		return unquoteScalar(quoted)
+	}
+	start := s.pos
+	for s.pos < len(s.text) && !strings.ContainsRune(",]}", rune(s.text[s.pos])) {
+		s.pos++
+	}
+	return s.plain(strings.TrimSpace(s.text[start:s.pos]))
+}
+
+// plain resolves an unquoted scalar.
+func (s *flowScanner) plain(text string) (any, error) {
+	switch text {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	number := text
+	if s.toml {
+		number = strings.ReplaceAll(number, "_", "")
+	}
+	if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(number, 64); err == nil && strings.ContainsAny(number, "0123456789") {
+		return f, nil
+	}
+	if s.toml {
+		return nil, fmt.Errorf("unsupported value %q", text)
+	}
+	switch text {
+	case "", "~", "null":
+		return nil, nil
+	}
+	return text, nil
+}
+
+func (s *flowScanner) array() (any, error) {
+	s.pos++
+	items := []any{}
+	for {
+		s.skipSpace()
+		if s.pos < len(s.text) && s.text[s.pos] == ']' {
+			s.pos++
+			return items, nil
+		}
+		v, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+		if err := s.next(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *flowScanner) table() (any, error) {
+	s.pos++
+	m := map[string]any{}
+	for {
+		s.skipSpace()
+		if s.pos < len(s.text) && s.text[s.pos] == '}' {
+			s.pos++
+			return m, nil
+		}
+		start := s.pos
+		for s.pos < len(s.text) && s.text[s.pos] != s.sep {
+			if c := s.text[s.pos]; c == '"' || c == '\'' {
+				end := closingQuote(s.text[s.pos:])
+				if end < 0 {
+					return nil, errors.New("unterminated key")
+				}
+				s.pos += end
+			}
+			s.pos++
+		}
+		if s.pos >= len(s.text) {
+			return nil, errors.New("expected a key and value")
+		}
+		key, err := unquoteScalar(strings.TrimSpace(s.text[start:s.pos]))
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("duplicate key %q", key)
+		}
+		s.pos++
+		v, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+		if err := s.next('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// next consumes the comma after a collection item, or stays before the
+// closing bracket.
+func (s *flowScanner) next(closing byte) error {
+	s.skipSpace()
+	if s.pos >= len(s.text) {
+		return fmt.Errorf("missing %q", closing)
+	}
+	switch s.text[s.pos] {
+	case ',':
+		s.pos++
+		return nil
+	case closing:
+		return nil
+	}
+	return fmt.Errorf("unexpected %q", s.text[s.pos:])
+}
+
+// closingQuote returns the index of the quote closing the string that text
+// starts with, or -1. Double-quoted strings escape with backslashes, and
+// single-quoted ones, in YAML, by doubling the quote.
+func closingQuote(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case text[i] == quote:
+			if quote == '\'' && i+1 < len(text) && text[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// unquoteScalar removes the quotes around a string, if any.
+func unquoteScalar(text string) (string, error) {
+	if len(text) < 2 {
+		return text, nil
+	}
+	switch text[0] {
+	case '"':
+		s, err := strconv.Unquote(text)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", text)
+		}
+		return s, nil
+	case '\'':
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+	return text, nil
+}
+
+// stripComment removes a comment starting with marker outside quotes.
+func stripComment(line, marker string) string {
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '"' || c == '\'':
+			if end := closingQuote(line[i:]); end > 0 {
+				i += end
+			}
+		case strings.HasPrefix(line[i:], marker) && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// balanced reports whether the brackets in a value outside strings are
+// closed.
+func balanced(text string) bool {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '"', '\'':
+			end := closingQuote(text[i:])
+			if end < 0 {
+				return true
+			}
+			i += end
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
@@ -0,0 +1,198 @@
+// Custom code. Not generated by Stainless.
+package stagehand
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+	"github.com/browserbase/stagehand-go/v3/option"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// defaultBrowserbaseAPIURL is the base URL of the Browserbase API.
+const defaultBrowserbaseAPIURL = "https://api.browserbase.com/"
+
+// ContextService manages Browserbase contexts, which persist browser state
+// such as cookies and logins across sessions. Sessions use a context through
+// the Context field of their Browserbase browser settings.
+//
+// Calls go to the Browserbase API directly, authenticated with the client's
+// Browserbase API key, in local and remote mode alike.
+type ContextService struct {
+	Options []option.RequestOption
+	baseURL string
+}
+
+// NewContextService generates a new service that applies the given options to
+// each request. The Browserbase API URL is taken from
+// [option.WithBrowserbaseAPIURL], if given.
+func NewContextService(opts ...option.RequestOption) (r ContextService) {
+	r = ContextService{Options: opts, baseURL: browserbaseAPIURLFromOptions(opts)}
+	return
+}
+
+func browserbaseAPIURLFromOptions(opts []option.RequestOption) string {
+	baseURL := defaultBrowserbaseAPIURL
+	for _, opt := range opts {
+		if urlOpt, ok := opt.(option.BrowserbaseAPIURLOption); ok && urlOpt.BrowserbaseAPIURL() != "" {
+			baseURL = urlOpt.BrowserbaseAPIURL()
+		}
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	return baseURL
+}
+
+// Context is a Browserbase context.
+type Context struct {
+	ID        string     `json:"id"`
+	ProjectID string     `json:"projectId,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	// UploadURL, PublicKey, CipherAlgorithm and InitializationVectorSize are
+	// only returned when the context is created, for uploading an encrypted
+	// user data directory to it.
+	UploadURL                string `json:"uploadUrl,omitempty"`
+	PublicKey                string `json:"publicKey,omitempty"`
+	CipherAlgorithm          string `json:"cipherAlgorithm,omitempty"`
+	InitializationVectorSize int    `json:"initializationVectorSize,omitempty"`
+}
+
+type ContextNewParams struct {
+	// ProjectID is the project to create the context in. Defaults to the
+	// client's Browserbase project ID.
+	ProjectID string `json:"projectId,omitempty"`
+}
+
+// New creates a context.
+func (r *ContextService) New(ctx context.Context, params ContextNewParams, opts ...option.RequestOption) (res *Context, err error) {
+	opts = slices.Concat(r.Options, opts, []option.RequestOption{browserbaseRequest{}})
+	err = r.execute(ctx, http.MethodPost, "v1/contexts", params, &res, opts)
+	return res, err
+}
+
+// Get retrieves a context.
+func (r *ContextService) Get(ctx context.Context, id string, opts ...option.RequestOption) (res *Context, err error) {
+	opts = slices.Concat(r.Options, opts, []option.RequestOption{browserbaseRequest{}})
+	if id == "" {
+		err = errors.New("missing required id parameter")
+		return nil, err
+	}
+	err = r.execute(ctx, http.MethodGet, "v1/contexts/"+id, nil, &res, opts)
+	return res, err
+}
+
+// Delete deletes a context.
+func (r *ContextService) Delete(ctx context.Context, id string, opts ...option.RequestOption) error {
+	opts = slices.Concat(r.Options, opts, []option.RequestOption{browserbaseRequest{}})
+	if id == "" {
+		return errors.New("missing required id parameter")
+	}
+	return r.execute(ctx, http.MethodDelete, "v1/contexts/"+id, nil, nil, opts)
+}
+
+// execute sends a request to the Browserbase API. The URL is absolute, so
+// options that route requests to the Stagehand API leave it alone.
+func (r *ContextService) execute(ctx context.Context, method, path string, params any, res **Context, opts []option.RequestOption) error {
+	if res == nil {
+		return requestconfig.ExecuteNewRequest(ctx, method, r.baseURL+path, params, nil, opts...)
+	}
+	var body []byte
+	if err := requestconfig.ExecuteNewRequest(ctx, method, r.baseURL+path, params, &body, opts...); err != nil {
+		return err
+	}
+	*res = &Context{}
+	return json.Unmarshal(body, *res)
+}
+
+// browserbaseRequest is a request option that adapts a request to the
+// Browserbase API: it fills in the client's project ID where a JSON body
+// needs one, and does not send the model API key.
+type browserbaseRequest struct{}
+
+func (browserbaseRequest) Apply(cfg *requestconfig.RequestConfig) error {
+	cfg.PostApply = append(cfg.PostApply, func(cfg *requestconfig.RequestConfig) error {
+		cfg.Request.Header.Del("x-model-api-key")
+		buffer, ok := cfg.Body.(*bytes.Buffer)
+		if !ok || !strings.Contains(cfg.Request.Header.Get("Content-Type"), "json") ||
+			cfg.BrowserbaseProjectID == "" || gjson.GetBytes(buffer.Bytes(), "projectId").String() != "" {
+			return nil
+		}
+		body, err := sjson.SetBytes(buffer.Bytes(), "projectId", cfg.BrowserbaseProjectID)
+		if err != nil {
+			return err
+		}
+		cfg.Body = bytes.NewBuffer(body)
+		return nil
+	})
+	return nil
+}
+
+// ContextStore creates, retrieves and deletes contexts. [ContextService]
+// implements it against Browserbase, and [MemoryContextStore] in memory for
+// tests.
+type ContextStore interface {
+	New(ctx context.Context, params ContextNewParams, opts ...option.RequestOption) (*Context, error)
+	Get(ctx context.Context, id string, opts ...option.RequestOption) (*Context, error)
+	Delete(ctx context.Context, id string, opts ...option.RequestOption) error
+}
+
+// MemoryContextStore is a [ContextStore] that keeps contexts in memory, a
+// stand-in for the Browserbase API in tests. The zero value is ready to use.
+type MemoryContextStore struct {
+	mu       sync.Mutex
+	contexts map[string]Context
+	next     int
+}
+
+func (s *MemoryContextStore) New(_ context.Context, params ContextNewParams, _ ...option.RequestOption) (*Context, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.contexts == nil {
+		s.contexts = map[string]Context{}
+	}
+	s.next++
+	created := time.Now().UTC()
+	updated := created
+	c := Context{ID: fmt.Sprintf("local-context-%d", s.next), ProjectID: params.ProjectID, CreatedAt: &created, UpdatedAt: &updated}
+	s.contexts[c.ID] = c
+	return &c, nil
+}
+
+func (s *MemoryContextStore) Get(_ context.Context, id string, _ ...option.RequestOption) (*Context, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.contexts[id]
+	if !ok {
+		return nil, fmt.Errorf("stagehand: context %s not found", id)
+	}
+	return &c, nil
+}
+
+func (s *MemoryContextStore) Delete(_ context.Context, id string, _ ...option.RequestOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.contexts[id]; !ok {
+		return fmt.Errorf("stagehand: context %s not found", id)
+	}
+	delete(s.contexts, id)
+	return nil
+}
+
+// Len returns the number of contexts in the store.
+func (s *MemoryContextStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.contexts)
+}
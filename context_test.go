@@ -0,0 +1,109 @@
+// Custom tests. Not generated by Stainless.
+package stagehand_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+func TestContextService(t *testing.T) {
+	type call struct {
+		method, url, body, apiKey, modelKey string
+	}
+	var calls []call
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithBrowserbaseAPIURL("http://browserbase.test"),
+		option.WithBrowserbaseAPIKey("My Browserbase API Key"),
+		option.WithBrowserbaseProjectID("My Project"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(0),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					var body []byte
+					if req.Body != nil {
+						body, _ = io.ReadAll(req.Body)
+					}
+					calls = append(calls, call{req.Method, req.URL.String(), string(body), req.Header.Get("X-BB-API-Key"), req.Header.Get("x-model-api-key")})
+					switch req.Method {
+					case http.MethodPost:
+						return jsonResponse(http.StatusCreated, `{"id":"ctx-1","uploadUrl":"https://upload.test","publicKey":"key","cipherAlgorithm":"AES-256-CBC","initializationVectorSize":16}`), nil
+					case http.MethodGet:
+						return jsonResponse(http.StatusOK, `{"id":"ctx-1","projectId":"My Project","createdAt":"2025-01-02T15:04:05Z","updatedAt":"2025-01-02T15:04:05Z"}`), nil
+					case http.MethodDelete:
+						return jsonResponse(http.StatusNotFound, `{"error":"not found"}`), nil
+					}
+					return nil, errors.New("unexpected request")
+				},
+			},
+		}),
+	)
+	ctx := context.Background()
+
+	created, err := client.Contexts.New(ctx, stagehand.ContextNewParams{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if created.ID != "ctx-1" || created.InitializationVectorSize != 16 {
+		t.Fatalf("unexpected context %+v", created)
+	}
+	got, err := client.Contexts.Get(ctx, "ctx-1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.ProjectID != "My Project" || got.CreatedAt == nil || got.CreatedAt.Year() != 2025 {
+		t.Fatalf("unexpected context %+v", got)
+	}
+	var apiErr *stagehand.Error
+	if err := client.Contexts.Delete(ctx, "ctx-1"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+
+	want := []call{
+		{http.MethodPost, "http://browserbase.test/v1/contexts", `{"projectId":"My Project"}`, "My Browserbase API Key", ""},
+		{http.MethodGet, "http://browserbase.test/v1/contexts/ctx-1", "", "My Browserbase API Key", ""},
+		{http.MethodDelete, "http://browserbase.test/v1/contexts/ctx-1", "", "My Browserbase API Key", ""},
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("expected %d calls, got %+v", len(want), calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("call %d: expected %+v, got %+v", i, want[i], calls[i])
+		}
+	}
+}
+
+func TestContextService_LocalMode(t *testing.T) {
+	var hosts []string
+	client := stagehand.NewClient(
+		option.WithServer("local"),
+		option.WithBrowserbaseAPIURL("http://browserbase.test"),
+		option.WithBrowserbaseAPIKey("My Browserbase API Key"),
+		option.WithMaxRetries(0),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					hosts = append(hosts, req.URL.Host)
+					return jsonResponse(http.StatusOK, `{"id":"ctx-1"}`), nil
+				},
+			},
+		}),
+	)
+	defer client.Close()
+
+	// Contexts live in Browserbase, so no local driver is started for them.
+	if _, err := client.Contexts.Get(context.Background(), "ctx-1"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(hosts) != 1 || hosts[0] != "browserbase.test" {
+		t.Fatalf("expected a call to Browserbase, got %v", hosts)
+	}
+}
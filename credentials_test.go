@@ -0,0 +1,137 @@
+// Custom tests. Not generated by Stainless.
+package stagehand_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+func TestCredentialsProviderRotation(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "model-api-key")
+	writeKey := func(key string, mtime time.Time) {
+		if err := os.WriteFile(keyFile, []byte(key+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(keyFile, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeKey("first-key", time.Now().Add(-time.Hour))
+	t.Setenv("BROWSERBASE_PROJECT_ID", "env-project")
+
+	type sent struct{ modelKey, project string }
+	requests := []sent{}
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithCredentialsProvider(option.ChainCredentials(
+			option.FileCredentials(option.CredentialFiles{
+				ModelAPIKey:          keyFile,
+				BrowserbaseProjectID: filepath.Join(dir, "missing"),
+			}),
+			option.FileCredentials(option.CredentialFiles{ModelAPIKey: keyFile}),
+			option.EnvCredentials(),
+		)),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					requests = append(requests, sent{req.Header.Get("x-model-api-key"), req.Header.Get("x-bb-project-id")})
+					return jsonResponse(http.StatusOK, `{"success":true,"data":{"available":true,"sessionId":"session"}}`), nil
+				},
+			},
+		}),
+	)
+	start := func(opts ...option.RequestOption) {
+		if _, err := client.Sessions.Start(context.Background(), stagehand.SessionStartParams{ModelName: "openai/gpt-5.4-mini"}, opts...); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+	}
+
+	start()
+	writeKey("second-key", time.Now())
+	start()
+	start(option.WithModelAPIKey("request-key"))
+
+	want := []sent{
+		{"first-key", "env-project"},
+		{"second-key", "env-project"},
+		{"request-key", "env-project"},
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("expected requests %v, got %v", want, requests)
+	}
+}
+
+func TestCachedCredentialsInvalidatedOnUnauthorized(t *testing.T) {
+	resolved := 0
+	provider := option.CachedCredentials(option.CredentialsProviderFunc(func(context.Context) (option.Credentials, error) {
+		resolved++
+		return option.Credentials{ModelAPIKey: "key"}, nil
+	}), time.Hour)
+
+	status := http.StatusOK
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithMaxRetries(0),
+		option.WithCredentialsProvider(provider),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					return jsonResponse(status, `{"success":true,"data":{"available":true,"sessionId":"session"}}`), nil
+				},
+			},
+		}),
+	)
+	start := func() {
+		_, _ = client.Sessions.Start(context.Background(), stagehand.SessionStartParams{ModelName: "openai/gpt-5.4-mini"})
+	}
+
+	start()
+	start()
+	if resolved != 1 {
+		t.Fatalf("expected credentials to be cached, resolved %d times", resolved)
+	}
+	status = http.StatusUnauthorized
+	start()
+	status = http.StatusOK
+	start()
+	if resolved != 2 {
+		t.Fatalf("expected a 401 to refresh credentials, resolved %d times", resolved)
+	}
+}
+
+func TestCachedCredentialsBackOffAfterFailedRefresh(t *testing.T) {
+	resolved := 0
+	failing := false
+	provider := option.CachedCredentials(option.CredentialsProviderFunc(func(context.Context) (option.Credentials, error) {
+		resolved++
+		if failing {
+			return option.Credentials{}, errors.New("secret store unavailable")
+		}
+		return option.Credentials{ModelAPIKey: "key"}, nil
+	}), 100*time.Millisecond)
+
+	if _, err := provider.Credentials(context.Background()); err != nil {
+		t.Fatalf("Credentials: %v", err)
+	}
+	failing = true
+	time.Sleep(110 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		creds, err := provider.Credentials(context.Background())
+		if err != nil || creds.ModelAPIKey != "key" {
+			t.Fatalf("expected the previous credentials, got %+v, %v", creds, err)
+		}
+	}
+	if resolved != 2 {
+		t.Fatalf("expected a single failed refresh, resolved %d times", resolved)
+	}
+}
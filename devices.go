@@ -0,0 +1,169 @@
+// Custom code. Not generated by Stainless.
+package stagehand
+
+import (
+	"slices"
+	"strings"
+)
+
+// DeviceKind is the kind of a [Device].
+type DeviceKind string
+
+const (
+	DeviceKindPhone   DeviceKind = "phone"
+	DeviceKindTablet  DeviceKind = "tablet"
+	DeviceKindDesktop DeviceKind = "desktop"
+)
+
+// Device is a device profile to emulate: its browser's user agent and
+// viewport, its pixel density, touch support and locale, and what a
+// Browserbase fingerprint should imitate for it.
+type Device struct {
+	Name string
+	Kind DeviceKind
+	// UserAgent is only used by local browsers. Browserbase derives the user
+	// agent from the fingerprint.
+	UserAgent string
+	// Width and Height are the viewport size in CSS pixels.
+	Width             int
+	Height            int
+	DeviceScaleFactor float64
+	HasTouch          bool
+	Locale            string
+	Browser           FingerprintBrowser
+	OperatingSystem   FingerprintOperatingSystem
+}
+
+const (
+	iPhoneUserAgent  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"
+	iPadUserAgent    = "Mozilla/5.0 (iPad; CPU OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"
+	windowsUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
+	macUserAgent     = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
+	linuxUserAgent   = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
+
+	defaultDeviceLocale = "en-US"
+)
+
+func androidUserAgent(model string, mobile bool) string {
+	suffix := "Safari/537.36"
+	if mobile {
+		suffix = "Mobile " + suffix
+	}
+	return "Mozilla/5.0 (Linux; Android 14; " + model + ") AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 " + suffix
+}
+
+var devices = []Device{
+	{Name: "iPhone SE", Kind: DeviceKindPhone, UserAgent: iPhoneUserAgent, Width: 375, Height: 667, DeviceScaleFactor: 2, HasTouch: true, Locale: defaultDeviceLocale, Browser: FingerprintBrowserSafari, OperatingSystem: FingerprintOperatingSystemIOS},
+	{Name: "iPhone 15", Kind: DeviceKindPhone, UserAgent: iPhoneUserAgent, Width: 393, Height: 659, DeviceScaleFactor: 3, HasTouch: true, Locale: defaultDeviceLocale, Browser: FingerprintBrowserSafari, OperatingSystem: FingerprintOperatingSystemIOS},
+	{Name: "iPhone 15 Pro Max", Kind: DeviceKindPhone, UserAgent: iPhoneUserAgent, Width: 430, Height: 739, DeviceScaleFactor: 3, HasTouch: true, Locale: defaultDeviceLocale, Browser: FingerprintBrowserSafari, OperatingSystem: FingerprintOperatingSystemIOS},
+	{Name: "Pixel 7", Kind: DeviceKindPhone, UserAgent: androidUserAgent("Pixel 7", true), Width: 412, Height: 839, DeviceScaleFactor: 2.625, HasTouch: true, Locale: defaultDeviceLocale, Browser: FingerprintBrowserChrome, OperatingSystem: FingerprintOperatingSystemAndroid},
+	{Name: "Galaxy S24", Kind: DeviceKindPhone, UserAgent: androidUserAgent("SM-S921B", true), Width: 360, Height: 780, DeviceScaleFactor: 3, HasTouch: true, Locale: defaultDeviceLocale, Browser: FingerprintBrowserChrome, OperatingSystem: FingerprintOperatingSystemAndroid},
+	{Name: "iPad Mini", Kind: DeviceKindTablet, UserAgent: iPadUserAgent, Width: 768, Height: 1024, DeviceScaleFactor: 2, HasTouch: true, Locale: defaultDeviceLocale, Browser: FingerprintBrowserSafari, OperatingSystem: FingerprintOperatingSystemIOS},
+	{Name: "iPad Pro 11", Kind: DeviceKindTablet, UserAgent: iPadUserAgent, Width: 834, Height: 1194, DeviceScaleFactor: 2, HasTouch: true, Locale: defaultDeviceLocale, Browser: FingerprintBrowserSafari, OperatingSystem: FingerprintOperatingSystemIOS},
+	{Name: "Galaxy Tab S9", Kind: DeviceKindTablet, UserAgent: androidUserAgent("SM-X710", false), Width: 800, Height: 1280, DeviceScaleFactor: 2, HasTouch: true, Locale: defaultDeviceLocale, Browser: FingerprintBrowserChrome, OperatingSystem: FingerprintOperatingSystemAndroid},
+	{Name: "Laptop 1366x768", Kind: DeviceKindDesktop, UserAgent: windowsUserAgent, Width: 1366, Height: 768, DeviceScaleFactor: 1, Locale: defaultDeviceLocale, Browser: FingerprintBrowserChrome, OperatingSystem: FingerprintOperatingSystemWindows},
+	{Name: "MacBook Pro 14", Kind: DeviceKindDesktop, UserAgent: macUserAgent, Width: 1512, Height: 982, DeviceScaleFactor: 2, Locale: defaultDeviceLocale, Browser: FingerprintBrowserChrome, OperatingSystem: FingerprintOperatingSystemMacOS},
+	{Name: "Desktop 1080p", Kind: DeviceKindDesktop, UserAgent: windowsUserAgent, Width: 1920, Height: 1080, DeviceScaleFactor: 1, Locale: defaultDeviceLocale, Browser: FingerprintBrowserChrome, OperatingSystem: FingerprintOperatingSystemWindows},
+	{Name: "Desktop Linux 1080p", Kind: DeviceKindDesktop, UserAgent: linuxUserAgent, Width: 1920, Height: 1080, DeviceScaleFactor: 1, Locale: defaultDeviceLocale, Browser: FingerprintBrowserChrome, OperatingSystem: FingerprintOperatingSystemLinux},
+	{Name: "Desktop 1440p", Kind: DeviceKindDesktop, UserAgent: windowsUserAgent, Width: 2560, Height: 1440, DeviceScaleFactor: 1, Locale: defaultDeviceLocale, Browser: FingerprintBrowserChrome, OperatingSystem: FingerprintOperatingSystemWindows},
+}
+
+// Devices returns the device presets of the given kinds, or all of them if
+// no kind is given, in a stable order, for running the same test across a
+// device matrix.
+func Devices(kinds ...DeviceKind) []Device {
+	var matched []Device
+	for _, d := range devices {
+		if len(kinds) == 0 || slices.Contains(kinds, d.Kind) {
+			matched = append(matched, d)
+		}
+	}
+	return matched
+}
+
+// LookupDevice returns the device preset of the given name, such as
+// "iPhone 15" or "Desktop 1080p". Names are matched case-insensitively.
+func LookupDevice(name string) (Device, bool) {
+	for _, d := range devices {
+		if strings.EqualFold(d.Name, name) {
+			return d, true
+		}
+	}
+	return Device{}, false
+}
+
+// WithLocale returns a copy of the device with another locale, such as
+// "de-DE".
+func (d Device) WithLocale(locale string) Device {
+	d.Locale = locale
+	return d
+}
+
+// Mobile reports whether the device is a phone or a tablet.
+func (d Device) Mobile() bool {
+	return d.Kind == DeviceKindPhone || d.Kind == DeviceKindTablet
+}
+
+// Apply emulates the device in a session: through the launch options for
+// local browsers, and through the browser settings for Browserbase ones.
+func (d Device) Apply(params *SessionStartParams) {
+	if params.Browser.Type == string(BrowserTypeBrowserbase) {
+		d.ApplyBrowserSettings(&params.BrowserbaseSessionCreateParams.BrowserSettings)
+	} else {
+		d.ApplyLaunchOptions(&params.Browser.LaunchOptions)
+	}
+}
+
+// ApplyLaunchOptions sets the viewport, scale factor, touch support and
+// locale of a local browser, and replaces its user agent.
+func (d Device) ApplyLaunchOptions(o *SessionStartParamsBrowserLaunchOptions) {
+	o.Viewport.Width = float64(d.Width)
+	o.Viewport.Height = float64(d.Height)
+	if d.DeviceScaleFactor != 0 {
+		o.DeviceScaleFactor = Float(d.DeviceScaleFactor)
+	}
+	o.HasTouch = Bool(d.HasTouch)
+	if d.Locale != "" {
+		o.Locale = String(d.Locale)
+	}
+	if d.UserAgent != "" {
+		args := o.Args[:0:0]
+		for _, arg := range o.Args {
+			if !strings.HasPrefix(arg, "--user-agent=") {
+				args = append(args, arg)
+			}
+		}
+		o.Args = append(args, "--user-agent="+d.UserAgent)
+	}
+}
+
+// ApplyBrowserSettings sets the viewport of a Browserbase browser, and makes
+// its fingerprint imitate the device's browser, operating system, kind and
+// locale, with a screen at least as large as the viewport. Previous
+// fingerprint settings are replaced.
+func (d Device) ApplyBrowserSettings(s *SessionStartParamsBrowserbaseSessionCreateParamsBrowserSettings) {
+	s.Viewport.Width = Float(float64(d.Width))
+	s.Viewport.Height = Float(float64(d.Height))
+
+	f := &s.Fingerprint
+	f.Browsers = nil
+	if d.Browser != "" {
+		f.Browsers = []string{string(d.Browser)}
+	}
+	f.Devices = []string{string(FingerprintDeviceDesktop)}
+	if d.Mobile() {
+		f.Devices = []string{string(FingerprintDeviceMobile)}
+	}
+	f.OperatingSystems = nil
+	if d.OperatingSystem != "" {
+		f.OperatingSystems = []string{string(d.OperatingSystem)}
+	}
+	f.Locales = nil
+	if d.Locale != "" {
+		f.Locales = []string{d.Locale}
+	}
+	f.Screen = SessionStartParamsBrowserbaseSessionCreateParamsBrowserSettingsFingerprintScreen{
+		MinWidth:  Float(float64(d.Width)),
+		MinHeight: Float(float64(d.Height)),
+	}
+}
@@ -0,0 +1,93 @@
+// Custom tests. Not generated by Stainless.
+package stagehand_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/browserbase/stagehand-go/v3"
+)
+
+func TestDevices(t *testing.T) {
+	all := stagehand.Devices()
+	if len(all) == 0 {
+		t.Fatal("no device presets")
+	}
+	seen := map[string]bool{}
+	for _, d := range all {
+		if seen[d.Name] {
+			t.Fatalf("duplicate device %q", d.Name)
+		}
+		seen[d.Name] = true
+		if d.UserAgent == "" || d.Width == 0 || d.Height == 0 || d.DeviceScaleFactor == 0 || d.Locale == "" {
+			t.Fatalf("incomplete device %+v", d)
+		}
+		if d.Mobile() != d.HasTouch {
+			t.Fatalf("device %q: mobile %v but touch %v", d.Name, d.Mobile(), d.HasTouch)
+		}
+		// Every preset makes valid parameters for both kinds of browser.
+		for _, b := range []*stagehand.SessionStartBuilder{stagehand.NewLocalSessionStart("m"), stagehand.NewBrowserbaseSessionStart("m")} {
+			if _, err := b.Device(d).Build(); err != nil {
+				t.Fatalf("device %q: %v", d.Name, err)
+			}
+		}
+	}
+
+	tablets := stagehand.Devices(stagehand.DeviceKindTablet)
+	if len(tablets) == 0 || len(tablets) >= len(all) {
+		t.Fatalf("unexpected tablets %v", tablets)
+	}
+	for _, d := range tablets {
+		if d.Kind != stagehand.DeviceKindTablet {
+			t.Fatalf("unexpected device %q in tablets", d.Name)
+		}
+	}
+
+	if _, ok := stagehand.LookupDevice("Nokia 3310"); ok {
+		t.Fatal("found an unknown device")
+	}
+}
+
+func TestDevice_ApplyLaunchOptions(t *testing.T) {
+	d, ok := stagehand.LookupDevice("pixel 7")
+	if !ok {
+		t.Fatal("Pixel 7 not found")
+	}
+	params, err := stagehand.NewLocalSessionStart("m").
+		Args("--lang=de", "--user-agent=old").
+		Device(d.WithLocale("de-DE")).
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	lo := params.Browser.LaunchOptions
+	if lo.Viewport.Width != 412 || lo.Viewport.Height != 839 || lo.DeviceScaleFactor.Value != 2.625 || !lo.HasTouch.Value || lo.Locale.Value != "de-DE" {
+		t.Fatalf("unexpected launch options %+v", lo)
+	}
+	if len(lo.Args) != 2 || lo.Args[0] != "--lang=de" || lo.Args[1] != "--user-agent="+d.UserAgent || !strings.Contains(d.UserAgent, "Pixel 7") {
+		t.Fatalf("unexpected args %q", lo.Args)
+	}
+	if d.Locale != "en-US" {
+		t.Fatalf("WithLocale changed the preset: %q", d.Locale)
+	}
+}
+
+func TestDevice_ApplyBrowserSettings(t *testing.T) {
+	d, _ := stagehand.LookupDevice("iPhone 15")
+	params, err := stagehand.NewBrowserbaseSessionStart("m").
+		Fingerprint(nil, []stagehand.FingerprintDevice{stagehand.FingerprintDeviceDesktop}, nil).
+		Device(d).
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	data, _ := json.Marshal(params.BrowserbaseSessionCreateParams.BrowserSettings)
+	want := `{"fingerprint":{"browsers":["safari"],"devices":["mobile"],"locales":["en-US"],"operatingSystems":["ios"],"screen":{"minHeight":659,"minWidth":393}},"viewport":{"height":659,"width":393}}`
+	if string(data) != want {
+		t.Fatalf("expected %s, got %s", want, data)
+	}
+	if params.Browser.LaunchOptions.Viewport.Width != 0 {
+		t.Fatal("launch options set for a Browserbase browser")
+	}
+}
@@ -0,0 +1,80 @@
+// Custom code. Not generated by Stainless.
+package stagehand
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+// DownloadService retrieves the files downloaded by the browsers of
+// Browserbase sessions. The files of local browsers are on the local disk
+// instead.
+type DownloadService struct {
+	Options []option.RequestOption
+	baseURL string
+}
+
+// NewDownloadService generates a new service that applies the given options
+// to each request. The Browserbase API URL is taken from
+// [option.WithBrowserbaseAPIURL], if given.
+func NewDownloadService(opts ...option.RequestOption) (r DownloadService) {
+	r = DownloadService{Options: opts, baseURL: browserbaseAPIURLFromOptions(opts)}
+	return
+}
+
+// DownloadedFile is a file downloaded by the browser of a session.
+type DownloadedFile struct {
+	// Name is the file's name in the session's downloads. Browserbase adds
+	// the time of the download to the name the page suggested, as in
+	// report-1719265797164.csv.
+	Name string
+	Data []byte
+}
+
+// List returns the files downloaded so far by the browser of a Browserbase
+// session. Files become available shortly after their download completes.
+func (r *DownloadService) List(ctx context.Context, sessionID string, opts ...option.RequestOption) ([]DownloadedFile, error) {
+	opts = slices.Concat(r.Options, opts, []option.RequestOption{browserbaseRequest{}})
+	if sessionID == "" {
+		return nil, errors.New("missing required sessionID parameter")
+	}
+	var archive []byte
+	path := fmt.Sprintf("%sv1/sessions/%s/downloads", r.baseURL, sessionID)
+	if err := requestconfig.ExecuteNewRequest(ctx, http.MethodGet, path, nil, &archive, opts...); err != nil {
+		return nil, err
+	}
+	if len(archive) == 0 {
+		return nil, nil
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, fmt.Errorf("stagehand: invalid downloads archive of session %s: %w", sessionID, err)
+	}
+	var files []DownloadedFile
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, DownloadedFile{Name: f.Name, Data: data})
+	}
+	return files, nil
+}
@@ -18,10 +18,10 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/lib/cdp"
 	"github.com/browserbase/stagehand-go/v3/option"
 	"github.com/browserbase/stagehand-go/v3/packages/ssestream"
 	"github.com/chromedp/cdproto/target"
@@ -96,7 +96,16 @@ func main() {
 	}
 
 	// 4) Use chromedp to click a link in the same tab.
-	allocatorCtx2, cancelAllocator2 := chromedp.NewRemoteAllocator(context.Background(), wsURL, chromedp.NoModifyURL)
+	findCtx, cancelFind := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFind()
+	endpoint, err := cdp.ResolveEndpoint(findCtx, wsURL, &cdp.AttachOptions{
+		Page: cdp.PageOptions{URLContains: "example.com"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	allocatorCtx2, cancelAllocator2 := chromedp.NewRemoteAllocator(context.Background(), endpoint.URL, chromedp.NoModifyURL)
 	defer cancelAllocator2()
 
 	browserCtx2, cancelBrowser2 := chromedp.NewContext(allocatorCtx2,
@@ -104,12 +113,7 @@ func main() {
 	)
 	defer cancelBrowser2()
 
-	targetID, err := waitForTarget(browserCtx2, "example.com", 10*time.Second)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	tabCtx, cancelTab := chromedp.NewContext(browserCtx2, chromedp.WithTargetID(targetID))
+	tabCtx, cancelTab := chromedp.NewContext(browserCtx2, chromedp.WithTargetID(target.ID(endpoint.TargetID)))
 	defer cancelTab()
 
 	err = chromedp.Run(
@@ -351,25 +355,6 @@ func countSlice(result any) (int, error) {
 	return len(items), nil
 }
 
-func waitForTarget(ctx context.Context, urlSubstring string, timeout time.Duration) (target.ID, error) {
-	deadline := time.Now().Add(timeout)
-	for {
-		targets, err := chromedp.Targets(ctx)
-		if err != nil {
-			return "", err
-		}
-		for _, t := range targets {
-			if t.Type == "page" && strings.Contains(t.URL, urlSubstring) {
-				return t.TargetID, nil
-			}
-		}
-		if time.Now().After(deadline) {
-			return "", fmt.Errorf("no page target with URL containing %q", urlSubstring)
-		}
-		time.Sleep(200 * time.Millisecond)
-	}
-}
-
 // getBrowserWebSocketURL fetches the browser's websocket URL from Chrome's debug endpoint
 func getBrowserWebSocketURL(port string) (string, error) {
 	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%s/json/version", port))
@@ -16,11 +16,11 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net/url"
 	"os"
 	"time"
 
 	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/lib/cdp"
 	"github.com/browserbase/stagehand-go/v3/option"
 	"github.com/browserbase/stagehand-go/v3/packages/ssestream"
 	"github.com/chromedp/cdproto/target"
@@ -62,21 +62,23 @@ func main() {
 		log.Fatalf("Failed to navigate via Stagehand: %v", err)
 	}
 
-	cdpURL = ensurePort(cdpURL)
+	// Find the tab Stagehand is driving and connect ChromeDP to it
+	findCtx, cancelFind := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFind()
+	endpoint, err := cdp.ResolveEndpoint(findCtx, cdpURL, &cdp.AttachOptions{
+		Page: cdp.PageOptions{URLContains: "example.com"},
+	})
+	if err != nil {
+		log.Fatalf("Failed to find existing tab: %v", err)
+	}
 
-	// Connect ChromeDP to the main tab in the remote browser
-	allocatorCtx, cancelAllocator := chromedp.NewRemoteAllocator(context.Background(), cdpURL, chromedp.NoModifyURL)
+	allocatorCtx, cancelAllocator := chromedp.NewRemoteAllocator(context.Background(), endpoint.URL, chromedp.NoModifyURL)
 	defer cancelAllocator()
 
 	browserCtx, cancelBrowser := chromedp.NewContext(allocatorCtx, chromedp.WithErrorf(func(format string, args ...interface{}) {}))
 	defer cancelBrowser()
 
-	targetID, err := waitForTarget(browserCtx, "example.com", 10*time.Second)
-	if err != nil {
-		log.Fatalf("Failed to find existing tab: %v", err)
-	}
-
-	tabCtx, cancelTab := chromedp.NewContext(browserCtx, chromedp.WithTargetID(targetID))
+	tabCtx, cancelTab := chromedp.NewContext(browserCtx, chromedp.WithTargetID(target.ID(endpoint.TargetID)))
 	defer cancelTab()
 
 	if err := takeScreenshot(tabCtx, "screenshot_multiregion_start.png"); err != nil {
@@ -298,24 +300,6 @@ func requireEnv(names ...string) {
 	}
 }
 
-// ensurePort adds the default port to a WebSocket URL if missing.
-// chromedp requires an explicit port in the URL.
-func ensurePort(wsURL string) string {
-	u, err := url.Parse(wsURL)
-	if err != nil {
-		return wsURL
-	}
-	if u.Port() == "" {
-		switch u.Scheme {
-		case "wss":
-			u.Host = u.Hostname() + ":443"
-		case "ws":
-			u.Host = u.Hostname() + ":80"
-		}
-	}
-	return u.String()
-}
-
 func takeScreenshot(ctx context.Context, path string) error {
 	var screenshotBuf []byte
 	if err := chromedp.Run(
@@ -327,22 +311,3 @@ func takeScreenshot(ctx context.Context, path string) error {
 	}
 	return os.WriteFile(path, screenshotBuf, 0644)
 }
-
-func waitForTarget(ctx context.Context, urlSubstring string, timeout time.Duration) (target.ID, error) {
-	deadline := time.Now().Add(timeout)
-	for {
-		targets, err := chromedp.Targets(ctx)
-		if err != nil {
-			return "", err
-		}
-		for _, t := range targets {
-			if t.Type == "page" && strings.Contains(t.URL, urlSubstring) {
-				return t.TargetID, nil
-			}
-		}
-		if time.Now().After(deadline) {
-			return "", fmt.Errorf("no page target with URL containing %q", urlSubstring)
-		}
-		time.Sleep(200 * time.Millisecond)
-	}
-}
@@ -17,12 +17,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"net/url"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/lib/cdp"
 	"github.com/browserbase/stagehand-go/v3/packages/ssestream"
 	"github.com/chromedp/cdproto/target"
 	"github.com/chromedp/chromedp"
@@ -54,10 +53,6 @@ func main() {
 	fmt.Printf("Session started: %s\n", sessionID)
 	fmt.Printf("CDP URL: %s\n", cdpURL)
 
-	// Patch CDP URL to add port if missing (chromedp requires explicit port)
-	cdpURL = ensurePort(cdpURL)
-	fmt.Printf("CDP URL (patched): %s\n", cdpURL)
-
 	// 2) Navigate with Stagehand so we can attach chromedp to the existing tab.
 	_, err = client.Sessions.Navigate(
 		context.TODO(),
@@ -70,9 +65,19 @@ func main() {
 		log.Fatal(err)
 	}
 
-	// 3) Connect chromedp to the same browser over CDP.
+	// 3) Find the tab Stagehand is driving and connect chromedp to it. The
+	// endpoint URL has the explicit port chromedp requires.
+	findCtx, cancelFind := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFind()
+	endpoint, err := cdp.ResolveEndpoint(findCtx, cdpURL, &cdp.AttachOptions{
+		Page: cdp.PageOptions{URLContains: "example.com"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Use NoModifyURL to skip the /json/version fetch that Browserbase doesn't support.
-	allocatorCtx, cancelAllocator := chromedp.NewRemoteAllocator(context.Background(), cdpURL, chromedp.NoModifyURL)
+	allocatorCtx, cancelAllocator := chromedp.NewRemoteAllocator(context.Background(), endpoint.URL, chromedp.NoModifyURL)
 	defer cancelAllocator()
 
 	// Suppress CDP protocol unmarshal errors (version mismatch warnings)
@@ -81,12 +86,7 @@ func main() {
 	)
 	defer cancelBrowser()
 
-	targetID, err := waitForTarget(browserCtx, "example.com", 10*time.Second)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	tabCtx, cancelTab := chromedp.NewContext(browserCtx, chromedp.WithTargetID(targetID))
+	tabCtx, cancelTab := chromedp.NewContext(browserCtx, chromedp.WithTargetID(target.ID(endpoint.TargetID)))
 	defer cancelTab()
 
 	// 4) Use chromedp to click a link in the same tab.
@@ -361,40 +361,3 @@ func countSlice(result any) (int, error) {
 	}
 	return len(items), nil
 }
-
-// ensurePort adds the default port to a WebSocket URL if missing.
-// chromedp requires an explicit port in the URL.
-func ensurePort(wsURL string) string {
-	u, err := url.Parse(wsURL)
-	if err != nil {
-		return wsURL
-	}
-	if u.Port() == "" {
-		switch u.Scheme {
-		case "wss":
-			u.Host = u.Hostname() + ":443"
-		case "ws":
-			u.Host = u.Hostname() + ":80"
-		}
-	}
-	return u.String()
-}
-
-func waitForTarget(ctx context.Context, urlSubstring string, timeout time.Duration) (target.ID, error) {
-	deadline := time.Now().Add(timeout)
-	for {
-		targets, err := chromedp.Targets(ctx)
-		if err != nil {
-			return "", err
-		}
-		for _, t := range targets {
-			if t.Type == "page" && strings.Contains(t.URL, urlSubstring) {
-				return t.TargetID, nil
-			}
-		}
-		if time.Now().After(deadline) {
-			return "", fmt.Errorf("no page target with URL containing %q", urlSubstring)
-		}
-		time.Sleep(200 * time.Millisecond)
-	}
-}
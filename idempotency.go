@@ -0,0 +1,77 @@
+// Custom code. Not generated by Stainless.
+package stagehand
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+)
+
+// idempotencyKeyHeader is the header the API uses to recognise repeated
+// deliveries of the same call.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// idempotentOperations are the session operations that get an idempotency key.
+var idempotentOperations = []string{"start", "act", "navigate", "agentExecute", "end"}
+
+// idempotencyKeys is a request option that attaches a fresh Idempotency-Key
+// header to each mutating session call. The key is generated once per call, so
+// every retry of the call carries the same key and the server can discard
+// duplicates. A key set with option.WithIdempotencyKey is left untouched.
+type idempotencyKeys struct{}
+
+func (idempotencyKeys) Apply(cfg *requestconfig.RequestConfig) error {
+	if cfg == nil || cfg.Request == nil || cfg.Request.Method != http.MethodPost {
+		return nil
+	}
+	if !isIdempotentOperation(cfg.Request.URL.Path) {
+		return nil
+	}
+	cfg.PostApply = append(cfg.PostApply, func(cfg *requestconfig.RequestConfig) error {
+		if cfg.Request.Header.Get(idempotencyKeyHeader) != "" {
+			return nil
+		}
+		key, err := newIdempotencyKey()
+		if err != nil {
+			return err
+		}
+		cfg.Request.Header.Set(idempotencyKeyHeader, key)
+		return nil
+	})
+	return nil
+}
+
+func isIdempotentOperation(path string) bool {
+	path = strings.Trim(path, "/")
+	if path == "v1/sessions/start" {
+		return true
+	}
+	rest, ok := strings.CutPrefix(path, "v1/sessions/")
+	if !ok {
+		return false
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) != 2 {
+		return false
+	}
+	for _, op := range idempotentOperations {
+		if parts[1] == op {
+			return true
+		}
+	}
+	return false
+}
+
+// newIdempotencyKey returns a random version 4 UUID.
+func newIdempotencyKey() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate idempotency key: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
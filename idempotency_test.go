@@ -0,0 +1,114 @@
+// Custom tests. Not generated by Stainless.
+package stagehand_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/lib/stagehandtest"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+func TestIdempotencyKeyStableAcrossRetries(t *testing.T) {
+	var mu sync.Mutex
+	clicks := 0
+	keys := []string{}
+	server := httptest.NewServer(&stagehandtest.IdempotentHandler{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			clicks++
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"success":true,"data":{"result":{"success":true,"message":"clicked","actionDescription":"click","actions":[]}}}`)
+		}),
+	})
+	defer server.Close()
+
+	// The first response is lost on its way back, as if the connection timed
+	// out after the server had already acted.
+	dropped := false
+	client := stagehand.NewClient(
+		option.WithBaseURL(server.URL),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMiddleware(func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+			mu.Lock()
+			keys = append(keys, req.Header.Get("Idempotency-Key"))
+			mu.Unlock()
+			res, err := next(req)
+			if err == nil && !dropped {
+				dropped = true
+				_, _ = io.Copy(io.Discard, res.Body)
+				res.Body.Close()
+				return &http.Response{
+					StatusCode: http.StatusGatewayTimeout,
+					Header:     http.Header{"Retry-After-Ms": []string{"1"}},
+					Body:       io.NopCloser(strings.NewReader(`{}`)),
+				}, nil
+			}
+			return res, err
+		}),
+	)
+
+	params := stagehand.SessionActParams{
+		Input: stagehand.SessionActParamsInputUnion{OfString: stagehand.String("click buy")},
+	}
+	if _, err := client.Sessions.Act(context.Background(), "session", params); err != nil {
+		t.Fatalf("Act: %v", err)
+	}
+	if clicks != 1 {
+		t.Fatalf("expected the server to act once, acted %d times", clicks)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Fatalf("expected the same key on both attempts, got %q", keys)
+	}
+
+	if _, err := client.Sessions.Act(context.Background(), "session", params); err != nil {
+		t.Fatalf("Act: %v", err)
+	}
+	if clicks != 2 || keys[2] == keys[0] {
+		t.Fatalf("expected a new key for a new call, got %q", keys)
+	}
+
+	if _, err := client.Sessions.Act(context.Background(), "session", params, option.WithIdempotencyKey("order-42")); err != nil {
+		t.Fatalf("Act: %v", err)
+	}
+	if keys[3] != "order-42" {
+		t.Fatalf("expected the supplied key, got %q", keys[3])
+	}
+}
+
+func TestIdempotencyKeySuppliedKeyIsKept(t *testing.T) {
+	var keys []string
+	transport := &closureTransport{fn: func(req *http.Request) (*http.Response, error) {
+		keys = append(keys, req.Header.Get("Idempotency-Key"))
+		return jsonResponse(http.StatusOK, `{"success":true,"data":{"result":{"success":true,"message":"clicked","actionDescription":"click","actions":[]}}}`), nil
+	}}
+	params := stagehand.SessionActParams{
+		Input: stagehand.SessionActParamsInputUnion{OfString: stagehand.String("click buy")},
+	}
+
+	// A key given to the client is applied before the generated one, and a
+	// key given to the call after it. Neither may be replaced.
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithHTTPClient(&http.Client{Transport: transport}),
+		option.WithIdempotencyKey("client-key"),
+	)
+	if _, err := client.Sessions.Act(context.Background(), "session", params); err != nil {
+		t.Fatalf("Act: %v", err)
+	}
+	if _, err := client.Sessions.Act(context.Background(), "session", params, option.WithIdempotencyKey("call-key")); err != nil {
+		t.Fatalf("Act: %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "client-key" || keys[1] != "call-key" {
+		t.Fatalf("expected the supplied keys, got %q", keys)
+	}
+}
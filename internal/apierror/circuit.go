@@ -0,0 +1,31 @@
+// Custom code. Not generated by Stainless.
+package apierror
+
+import (
+	"fmt"
+	"time"
+)
+
+// CircuitOpenError is returned without sending the request when the circuit
+// breaker for its base URL and model is open. Requests failing with it are not
+// retried.
+type CircuitOpenError struct {
+	BaseURL string
+	// Model is the model named in the request, or empty if it named none.
+	Model string
+	// RetryAt is when the breaker lets a probe request through again. It is
+	// zero if the circuit is half-open and waiting for the probes in flight,
+	// whose end cannot be known in advance.
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	target := e.BaseURL
+	if e.Model != "" {
+		target += " (" + e.Model + ")"
+	}
+	if e.RetryAt.IsZero() {
+		return fmt.Sprintf("circuit half-open for %s, waiting for a probe request", target)
+	}
+	return fmt.Sprintf("circuit open for %s until %s", target, e.RetryAt.Format(time.RFC3339))
+}
@@ -182,6 +182,15 @@ func NewRequestConfig(ctx context.Context, method string, u string, body any, ds
 		return nil, err
 	}
 
+	// BEGIN CUSTOM CODE - not generated by Stainless.
+	// PostApply functions may register further ones, which run after them.
+	for i := 0; i < len(cfg.PostApply); i++ {
+		if err := cfg.PostApply[i](&cfg); err != nil {
+			return nil, err
+		}
+	}
+	// END CUSTOM CODE - not generated by Stainless.
+
 	// This must run after `cfg.Apply(...)` above in case the request timeout gets modified. We also only
 	// apply our own logic for it if it's still "0" from above. If it's not, then it was deleted or modified
 	// by the user and we should respect that.
@@ -229,6 +238,21 @@ type RequestConfig struct {
 	// given address
 	ResponseInto **http.Response
 	Body         io.Reader
+	// BEGIN CUSTOM CODE - not generated by Stainless.
+	// PostApply functions run once every option for the request has been
+	// applied, so they observe its final configuration.
+	PostApply []func(*RequestConfig) error
+	// RetryPolicy decides which attempts are retried and how long to wait.
+	// DefaultRetryPolicy is used when it is nil.
+	RetryPolicy RetryPolicy
+	// ModelProviderAPIKeys maps a model provider, such as "anthropic", to the
+	// API key sent for models of that provider.
+	ModelProviderAPIKeys map[string]string
+	// Around wraps the whole execution of the request, retries included,
+	// outermost first. A wrapper may change the request and call execute
+	// several times; each call runs the middlewares and retries anew.
+	Around []func(cfg *RequestConfig, execute func() error) error
+	// END CUSTOM CODE - not generated by Stainless.
 }
 
 // middleware is exactly the same type as the Middleware type found in the [option] package,
@@ -380,6 +404,11 @@ func retryDelay(res *http.Response, retryCount int) time.Duration {
 }
 
 func (cfg *RequestConfig) Execute() (err error) {
+	// BEGIN CUSTOM CODE - not generated by Stainless.
+	if len(cfg.Around) > 0 {
+		return cfg.executeAround()
+	}
+	// END CUSTOM CODE - not generated by Stainless.
 	if cfg.BaseURL == nil {
 		if cfg.DefaultBaseURL != nil {
 			cfg.BaseURL = cfg.DefaultBaseURL
@@ -450,9 +479,12 @@ func (cfg *RequestConfig) Execute() (err error) {
 		if ctx != nil && ctx.Err() != nil {
 			return ctx.Err()
 		}
-		if !shouldRetry(cfg.Request, res) || retryCount >= cfg.MaxRetries {
+		// BEGIN CUSTOM CODE - not generated by Stainless.
+		delay, retry := cfg.retryDecision(res, err, retryCount)
+		if !retry || retryCount >= cfg.MaxRetries {
 			break
 		}
+		// END CUSTOM CODE - not generated by Stainless.
 
 		// Prepare next request and wait for the retry delay
 		if cfg.Request.GetBody != nil {
@@ -475,7 +507,7 @@ func (cfg *RequestConfig) Execute() (err error) {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-time.After(retryDelay(res, retryCount)):
+		case <-time.After(delay):
 		}
 	}
 
@@ -597,6 +629,10 @@ func (cfg *RequestConfig) Clone(ctx context.Context) *RequestConfig {
 		BrowserbaseAPIKey:    cfg.BrowserbaseAPIKey,
 		BrowserbaseProjectID: cfg.BrowserbaseProjectID,
 		ModelAPIKey:          cfg.ModelAPIKey,
+		// BEGIN CUSTOM CODE - not generated by Stainless.
+		RetryPolicy:          cfg.RetryPolicy,
+		ModelProviderAPIKeys: cfg.ModelProviderAPIKeys,
+		// END CUSTOM CODE - not generated by Stainless.
 	}
 
 	return new
@@ -0,0 +1,225 @@
+// Custom code. Not generated by Stainless.
+package requestconfig
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3/internal/apierror"
+)
+
+// RetryPolicy decides whether a failed attempt is retried and how long to wait
+// before the next one. It is consulted after every attempt:
+// req is the request, res the response if one was received, err the transport
+// error if not, and attempt the zero-based number of the attempt that failed.
+//
+// The number of retries stays bounded by MaxRetries, and requests whose body
+// cannot be replayed are never retried.
+type RetryPolicy interface {
+	Retry(req *http.Request, res *http.Response, err error, attempt int) (time.Duration, bool)
+}
+
+// RetryPolicyFunc adapts a function to a [RetryPolicy].
+type RetryPolicyFunc func(req *http.Request, res *http.Response, err error, attempt int) (time.Duration, bool)
+
+func (f RetryPolicyFunc) Retry(req *http.Request, res *http.Response, err error, attempt int) (time.Duration, bool) {
+	return f(req, res, err, attempt)
+}
+
+// DefaultRetryPolicy is used when no policy is set. It retries connection
+// errors, 408, 409, 429 and 5xx responses, honours x-should-retry and
+// Retry-After up to a minute, and otherwise backs off from 0.5s doubling up
+// to 8s with jitter.
+var DefaultRetryPolicy RetryPolicy = RetryPolicyFunc(func(req *http.Request, res *http.Response, _ error, attempt int) (time.Duration, bool) {
+	if !shouldRetry(req, res) {
+		return 0, false
+	}
+	if d, ok := clampedRetryAfter(res, 0); ok {
+		return d, true
+	}
+	return retryDelay(res, attempt), true
+})
+
+// maxRetryAfter bounds the delay a Retry-After header can impose when the
+// policy has no maximum of its own.
+const maxRetryAfter = time.Minute
+
+// clampedRetryAfter returns the delay the server asked for, clamped to
+// maxDelay, or to maxRetryAfter if maxDelay is not positive.
+func clampedRetryAfter(res *http.Response, maxDelay time.Duration) (time.Duration, bool) {
+	d, ok := parseRetryAfterHeader(res)
+	if !ok {
+		return 0, false
+	}
+	if maxDelay <= 0 {
+		maxDelay = maxRetryAfter
+	}
+	return min(max(0, d), maxDelay), true
+}
+
+// retryDecision asks the configured policy whether to retry the attempt.
+func (cfg *RequestConfig) retryDecision(res *http.Response, err error, attempt int) (time.Duration, bool) {
+	// The breaker is failing fast on purpose; retrying would only wait out
+	// the backoff to fail again.
+	var circuitErr *apierror.CircuitOpenError
+	if errors.As(err, &circuitErr) {
+		return 0, false
+	}
+	// A middleware failed after the server handled the request successfully,
+	// so a retry would repeat work that is already done.
+	if err != nil && res != nil && res.StatusCode < http.StatusMultipleChoices {
+		return 0, false
+	}
+
+	policy := cfg.RetryPolicy
+	if policy == nil {
+		policy = DefaultRetryPolicy
+	}
+	return policy.Retry(cfg.Request, res, err, attempt)
+}
+
+// executeAround runs the request through the wrappers in cfg.Around.
+func (cfg *RequestConfig) executeAround() error {
+	wrappers := cfg.Around
+	cfg.Around = nil
+	defer func() { cfg.Around = wrappers }()
+
+	execute := cfg.Execute
+	for i := len(wrappers) - 1; i >= 0; i-- {
+		wrap, next := wrappers[i], execute
+		execute = func() error { return wrap(cfg, next) }
+	}
+	return execute()
+}
+
+// ExponentialRetryPolicy retries the same responses as [DefaultRetryPolicy],
+// waiting Initial doubled on each attempt and capped at Max, with up to Jitter
+// (a fraction between 0 and 1) of the delay taken off at random. A Retry-After
+// header from the server takes precedence, capped at Max, or at a minute if
+// Max is not set.
+type ExponentialRetryPolicy struct {
+	Initial time.Duration
+	Max     time.Duration
+	Jitter  float64
+}
+
+func (p ExponentialRetryPolicy) Retry(req *http.Request, res *http.Response, _ error, attempt int) (time.Duration, bool) {
+	if !shouldRetry(req, res) {
+		return 0, false
+	}
+	if d, ok := clampedRetryAfter(res, p.Max); ok {
+		return d, true
+	}
+
+	initial := p.Initial
+	if initial <= 0 {
+		initial = 500 * time.Millisecond
+	}
+	delay := time.Duration(float64(initial) * math.Pow(2, float64(attempt)))
+	if p.Max > 0 && (delay > p.Max || delay <= 0) {
+		delay = p.Max
+	}
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 && delay > 0 {
+		delay -= time.Duration(rand.Float64() * jitter * float64(delay))
+	}
+	return delay, true
+}
+
+// DecorrelatedJitterRetryPolicy retries the same responses as
+// [DefaultRetryPolicy], waiting a random duration between Base and a bound
+// that triples on each attempt, capped at Max. Unlike plain exponential
+// backoff, delays of clients that failed together spread out instead of
+// staying in step. A Retry-After header from the server takes precedence,
+// capped at Max, or at a minute if Max is not set.
+type DecorrelatedJitterRetryPolicy struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+func (p DecorrelatedJitterRetryPolicy) Retry(req *http.Request, res *http.Response, _ error, attempt int) (time.Duration, bool) {
+	if !shouldRetry(req, res) {
+		return 0, false
+	}
+	if d, ok := clampedRetryAfter(res, p.Max); ok {
+		return d, true
+	}
+
+	base := p.Base
+	if base <= 0 {
+		base = 500 * time.Millisecond
+	}
+	maxDelay := p.Max
+	if maxDelay <= 0 {
+		maxDelay = 8 * time.Second
+	}
+
+	upper := time.Duration(float64(base) * math.Pow(3, float64(attempt+1)))
+	if upper > maxDelay || upper <= 0 {
+		upper = maxDelay
+	}
+	delay := base
+	if upper > base {
+		delay += time.Duration(rand.Int63n(int64(upper - base)))
+	}
+	return min(delay, maxDelay), true
+}
+
+// nonIdempotentOperations are the session operations that drive the browser
+// through a model, so repeating one after it may have run is unsafe.
+var nonIdempotentOperations = []string{"/act", "/agentExecute"}
+
+// IsNonIdempotentOperation reports whether req performs a session operation
+// that may change the page, such as act or agentExecute.
+func IsNonIdempotentOperation(req *http.Request) bool {
+	if req == nil || req.Method != http.MethodPost || req.URL == nil {
+		return false
+	}
+	path := strings.TrimRight(req.URL.Path, "/")
+	if !strings.Contains(path, "/sessions/") {
+		return false
+	}
+	for _, op := range nonIdempotentOperations {
+		if strings.HasSuffix(path, op) {
+			return true
+		}
+	}
+	return false
+}
+
+// NoRetryNonIdempotentPolicy wraps Policy, or [DefaultRetryPolicy] if nil, and
+// never retries operations matched by [IsNonIdempotentOperation] unless the
+// connection could not be established, in which case the request was never
+// sent.
+type NoRetryNonIdempotentPolicy struct {
+	Policy RetryPolicy
+}
+
+func (p NoRetryNonIdempotentPolicy) Retry(req *http.Request, res *http.Response, err error, attempt int) (time.Duration, bool) {
+	if IsNonIdempotentOperation(req) && !isDialError(err) {
+		return 0, false
+	}
+	policy := p.Policy
+	if policy == nil {
+		policy = DefaultRetryPolicy
+	}
+	return policy.Retry(req, res, err, attempt)
+}
+
+// isDialError reports whether err happened while connecting, before anything
+// was written to the server.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// RetryAfter returns the delay the server asked for in the Retry-After-Ms or
+// Retry-After header of res, clamped like the delay of a retry: to maxDelay,
+// or to a minute if maxDelay is not positive.
+func RetryAfter(res *http.Response, maxDelay time.Duration) (time.Duration, bool) {
+	return clampedRetryAfter(res, maxDelay)
+}
@@ -0,0 +1,190 @@
+// Custom code. Not generated by Stainless.
+package cdp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// Clip is a region of the page in CSS pixels.
+type Clip struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	// Scale is the page scale factor. Defaults to 1.
+	Scale float64 `json:"scale"`
+}
+
+// ScreenshotOptions configure a screenshot. The zero value captures the
+// viewport as PNG.
+type ScreenshotOptions struct {
+	// FullPage captures the whole scrollable page instead of the viewport.
+	// It is ignored if Clip is set.
+	FullPage bool
+	// Format is "png" (default) or "jpeg".
+	Format string
+	// Quality is the JPEG quality from 0 to 100. Defaults to 80 for JPEG.
+	Quality int
+	// Clip, if set, captures only this region.
+	Clip *Clip
+}
+
+// ContentType returns the MIME type of screenshots taken with o.
+func (o ScreenshotOptions) ContentType() string {
+	if o.Format == "jpeg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+// Screenshot captures the session's page.
+func (s *Session) Screenshot(ctx context.Context, opts ScreenshotOptions) ([]byte, error) {
+	params := map[string]any{"format": "png"}
+	switch opts.Format {
+	case "", "png":
+	case "jpeg":
+		quality := opts.Quality
+		if quality <= 0 {
+			quality = 80
+		}
+		params["format"] = "jpeg"
+		params["quality"] = quality
+	default:
+		return nil, fmt.Errorf("cdp: unsupported screenshot format %q", opts.Format)
+	}
+
+	clip := opts.Clip
+	if clip == nil && opts.FullPage {
+		var metrics struct {
+			CSSContentSize struct {
+				Width  float64 `json:"width"`
+				Height float64 `json:"height"`
+			} `json:"cssContentSize"`
+		}
+		if err := s.Send(ctx, "Page.getLayoutMetrics", nil, &metrics); err != nil {
+			return nil, err
+		}
+		clip = &Clip{Width: metrics.CSSContentSize.Width, Height: metrics.CSSContentSize.Height}
+		params["captureBeyondViewport"] = true
+	}
+	if clip != nil {
+		c := *clip
+		if c.Scale == 0 {
+			c.Scale = 1
+		}
+		params["clip"] = c
+	}
+
+	var res struct {
+		Data string `json:"data"`
+	}
+	if err := s.Send(ctx, "Page.captureScreenshot", params, &res); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(res.Data)
+}
+
+// Snapshot is the state of a page at one point in time.
+type Snapshot struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	// HTML is the serialized DOM, including changes made by scripts.
+	HTML string `json:"html"`
+	// AccessibilityTree holds the nodes of the page's full accessibility
+	// tree.
+	AccessibilityTree []AXNode `json:"accessibilityTree"`
+}
+
+// AXNode is a node of the accessibility tree.
+type AXNode struct {
+	NodeID   string   `json:"nodeId"`
+	ParentID string   `json:"parentId,omitempty"`
+	ChildIDs []string `json:"childIds,omitempty"`
+	Ignored  bool     `json:"ignored,omitempty"`
+	Role     string   `json:"role,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Value    string   `json:"value,omitempty"`
+	// BackendDOMNodeID identifies the DOM node, if any.
+	BackendDOMNodeID int `json:"backendDOMNodeId,omitempty"`
+}
+
+// axValue is the protocol's AXValue; only its value is kept.
+type axValue struct {
+	Value json.RawMessage `json:"value"`
+}
+
+func (v *axValue) String() string {
+	if v == nil || len(v.Value) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(v.Value, &s); err == nil {
+		return s
+	}
+	return string(v.Value)
+}
+
+// Snapshot captures the DOM and accessibility tree of the session's page.
+func (s *Session) Snapshot(ctx context.Context) (*Snapshot, error) {
+	var eval struct {
+		Result struct {
+			Value struct {
+				URL   string `json:"url"`
+				Title string `json:"title"`
+				HTML  string `json:"html"`
+			} `json:"value"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text string `json:"text"`
+		} `json:"exceptionDetails"`
+	}
+	err := s.Send(ctx, "Runtime.evaluate", map[string]any{
+		"expression":    `({url: location.href, title: document.title, html: document.documentElement ? document.documentElement.outerHTML : ""})`,
+		"returnByValue": true,
+	}, &eval)
+	if err != nil {
+		return nil, err
+	}
+	if eval.ExceptionDetails != nil {
+		return nil, fmt.Errorf("cdp: failed to serialize the page: %s", eval.ExceptionDetails.Text)
+	}
+
+	var ax struct {
+		Nodes []struct {
+			NodeID           string   `json:"nodeId"`
+			ParentID         string   `json:"parentId"`
+			ChildIDs         []string `json:"childIds"`
+			Ignored          bool     `json:"ignored"`
+			Role             *axValue `json:"role"`
+			Name             *axValue `json:"name"`
+			Value            *axValue `json:"value"`
+			BackendDOMNodeID int      `json:"backendDOMNodeId"`
+		} `json:"nodes"`
+	}
+	if err := s.Send(ctx, "Accessibility.getFullAXTree", nil, &ax); err != nil {
+		return nil, err
+	}
+
+	snap := &Snapshot{
+		URL:               eval.Result.Value.URL,
+		Title:             eval.Result.Value.Title,
+		HTML:              eval.Result.Value.HTML,
+		AccessibilityTree: make([]AXNode, 0, len(ax.Nodes)),
+	}
+	for _, n := range ax.Nodes {
+		snap.AccessibilityTree = append(snap.AccessibilityTree, AXNode{
+			NodeID:           n.NodeID,
+			ParentID:         n.ParentID,
+			ChildIDs:         n.ChildIDs,
+			Ignored:          n.Ignored,
+			Role:             n.Role.String(),
+			Name:             n.Name.String(),
+			Value:            n.Value.String(),
+			BackendDOMNodeID: n.BackendDOMNodeID,
+		})
+	}
+	return snap, nil
+}
@@ -0,0 +1,436 @@
+// Custom code. Not generated by Stainless.
+package cdp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+	"github.com/browserbase/stagehand-go/v3/option"
+	"github.com/tidwall/gjson"
+)
+
+// captureTimeout bounds each automatic capture.
+const captureTimeout = 10 * time.Second
+
+// defaultCaptureIdleTimeout is the default [CaptureConfig.IdleTimeout].
+const defaultCaptureIdleTimeout = time.Hour
+
+// Artifact is a capture taken around a session operation.
+type Artifact struct {
+	SessionID string
+	// Operation is the session operation, such as "act" or "extract".
+	Operation string
+	// Stage is "before" or "after" the operation, or "error" if it failed.
+	Stage string
+	// Kind is "screenshot" or "snapshot".
+	Kind        string
+	ContentType string
+	Data        []byte
+	Time        time.Time
+}
+
+// ArtifactSink stores artifacts.
+type ArtifactSink interface {
+	Store(ctx context.Context, a Artifact) error
+}
+
+// ArtifactSinkFunc adapts a function to an [ArtifactSink].
+type ArtifactSinkFunc func(ctx context.Context, a Artifact) error
+
+func (f ArtifactSinkFunc) Store(ctx context.Context, a Artifact) error {
+	return f(ctx, a)
+}
+
+// DirSink returns a sink that writes each artifact to a file in a directory
+// per session under dir, named after its time, operation and stage, such as
+// dir/<session>/20250102T150405.000-act-before.png.
+func DirSink(dir string) ArtifactSink {
+	return ArtifactSinkFunc(func(_ context.Context, a Artifact) error {
+		sessionDir := filepath.Join(dir, filepath.Base(a.SessionID))
+		if err := os.MkdirAll(sessionDir, 0o755); err != nil {
+			return err
+		}
+		ext := ".png"
+		switch a.ContentType {
+		case "image/jpeg":
+			ext = ".jpg"
+		case "application/json":
+			ext = ".json"
+		}
+		name := fmt.Sprintf("%s-%s-%s%s", a.Time.UTC().Format("20060102T150405.000"), a.Operation, a.Stage, ext)
+		return os.WriteFile(filepath.Join(sessionDir, name), a.Data, 0o644)
+	})
+}
+
+// CaptureConfig configures a [Capturer].
+type CaptureConfig struct {
+	// Sink stores automatic captures. Without it nothing is captured
+	// automatically.
+	Sink ArtifactSink
+	// AroundAct captures a screenshot before and after every act call. A call
+	// that is retried is captured once, before its first attempt and after
+	// its last.
+	AroundAct bool
+	// OnError captures a screenshot when a session operation fails, once its
+	// retries are exhausted.
+	OnError bool
+	// SnapshotOnError also captures a [Snapshot] when an operation fails.
+	SnapshotOnError bool
+	// Screenshot configures automatic screenshots.
+	Screenshot ScreenshotOptions
+	// Page selects the page of each session. By default the page Stagehand
+	// is attached to is used.
+	Page PageOptions
+	Dial DialOptions
+	// IdleTimeout is how long a session is remembered without calls or
+	// captures. Once it passes, or the session's page is closed, the
+	// connection to the session's browser is closed and the session is
+	// forgotten. Zero means an hour.
+	IdleTimeout time.Duration
+	// ReportError, if set, is called when an automatic capture fails.
+	// Captures never fail the operation they belong to.
+	ReportError func(error)
+}
+
+// Capturer takes screenshots and snapshots of the pages of sessions, for
+// local and Browserbase sessions alike, through each session's CDP URL.
+//
+// Pass a Capturer to stagehand.NewClient so it learns the CDP URL of every
+// session the client starts, and, if configured, captures screenshots around
+// act calls and failed operations. Sessions started elsewhere can be added
+// with [Capturer.Register].
+type Capturer struct {
+	cfg CaptureConfig
+
+	mu       sync.Mutex
+	sessions map[string]*capturedSession
+}
+
+type capturedSession struct {
+	mu     sync.Mutex
+	cdpURL string
+	page   *Page
+	// idle forgets the session once it has not been used for the idle
+	// timeout.
+	idle *time.Timer
+}
+
+// NewCapturer returns a Capturer with the given configuration.
+func NewCapturer(cfg CaptureConfig) *Capturer {
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = defaultCaptureIdleTimeout
+	}
+	return &Capturer{cfg: cfg, sessions: map[string]*capturedSession{}}
+}
+
+// Register records the CDP URL of a session not started through a client
+// using this Capturer.
+func (c *Capturer) Register(sessionID, cdpURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.sessions[sessionID]; ok {
+		s.mu.Lock()
+		if s.cdpURL != cdpURL && s.page != nil {
+			_ = s.page.Close()
+			s.page = nil
+		}
+		s.cdpURL = cdpURL
+		s.mu.Unlock()
+		s.idle.Reset(c.cfg.IdleTimeout)
+		return
+	}
+	s := &capturedSession{cdpURL: cdpURL}
+	s.idle = time.AfterFunc(c.cfg.IdleTimeout, func() { c.forget(sessionID, s) })
+	c.sessions[sessionID] = s
+}
+
+// touch restarts the idle timeout of a session.
+func (c *Capturer) touch(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.sessions[sessionID]; ok {
+		s.idle.Reset(c.cfg.IdleTimeout)
+	}
+}
+
+// forget drops s, if it is still the session registered as sessionID, and
+// closes its connection.
+func (c *Capturer) forget(sessionID string, s *capturedSession) {
+	c.mu.Lock()
+	if c.sessions[sessionID] == s {
+		delete(c.sessions, sessionID)
+	}
+	c.mu.Unlock()
+	s.close()
+}
+
+// Screenshot captures the page of a session.
+func (c *Capturer) Screenshot(ctx context.Context, sessionID string, opts ScreenshotOptions) ([]byte, error) {
+	var data []byte
+	err := c.withPage(ctx, sessionID, func(p *Page) (err error) {
+		data, err = p.Screenshot(ctx, opts)
+		return err
+	})
+	return data, err
+}
+
+// Snapshot captures the DOM and accessibility tree of the page of a session.
+func (c *Capturer) Snapshot(ctx context.Context, sessionID string) (*Snapshot, error) {
+	var snap *Snapshot
+	err := c.withPage(ctx, sessionID, func(p *Page) (err error) {
+		snap, err = p.Snapshot(ctx)
+		return err
+	})
+	return snap, err
+}
+
+// Close closes the connections to the sessions' browsers.
+func (c *Capturer) Close() error {
+	c.mu.Lock()
+	sessions := c.sessions
+	c.sessions = map[string]*capturedSession{}
+	c.mu.Unlock()
+	for _, s := range sessions {
+		s.close()
+	}
+	return nil
+}
+
+// withPage calls fn with the page of a session, connecting to it first if
+// needed.
+func (c *Capturer) withPage(ctx context.Context, sessionID string, fn func(*Page) error) error {
+	c.mu.Lock()
+	s, ok := c.sessions[sessionID]
+	if ok {
+		s.idle.Reset(c.cfg.IdleTimeout)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("cdp: no CDP URL known for session %s", sessionID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.page != nil && s.page.conn.Err() != nil {
+		s.page = nil
+	}
+	if s.page == nil {
+		page, err := AttachPage(ctx, s.cdpURL, &AttachOptions{Page: c.cfg.Page, Dial: c.cfg.Dial})
+		if err != nil {
+			return err
+		}
+		s.page = page
+		// The browser detaches the session when the page is closed, which
+		// for Stagehand sessions means the session is over.
+		page.conn.On("Target.detachedFromTarget", func(ev Event) {
+			var p struct {
+				SessionID string `json:"sessionId"`
+			}
+			if json.Unmarshal(ev.Params, &p) == nil && p.SessionID == page.ID {
+				// s.mu may be held by a command waiting for this handler
+				// to return.
+				go c.forget(sessionID, s)
+			}
+		})
+	}
+	return fn(s.page)
+}
+
+func (s *capturedSession) close() {
+	s.idle.Stop()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.page != nil {
+		_ = s.page.Close()
+		s.page = nil
+	}
+}
+
+func (c *Capturer) Apply(r *requestconfig.RequestConfig) error {
+	if r == nil || r.Request == nil || r.Request.Method != http.MethodPost {
+		return nil
+	}
+	path := strings.Trim(r.Request.URL.Path, "/")
+	if path == "v1/sessions/start" {
+		r.Middlewares = append(r.Middlewares, c.recordStart)
+		return nil
+	}
+	rest, ok := strings.CutPrefix(path, "v1/sessions/")
+	if !ok {
+		return nil
+	}
+	id, op, _ := strings.Cut(rest, "/")
+	c.touch(id)
+	switch {
+	case op == "end":
+		r.Middlewares = append(r.Middlewares, func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+			res, err := next(req)
+			if err == nil && res.StatusCode < http.StatusMultipleChoices {
+				c.mu.Lock()
+				s, ok := c.sessions[id]
+				c.mu.Unlock()
+				if ok {
+					c.forget(id, s)
+				}
+			}
+			return res, err
+		})
+	case c.cfg.Sink != nil && (c.cfg.OnError || (c.cfg.AroundAct && op == "act")):
+		// Captures wrap the whole call rather than each attempt, so retries
+		// do not take them again.
+		r.Around = append(r.Around, func(r *requestconfig.RequestConfig, execute func() error) error {
+			return c.captureAround(r, execute, id, op)
+		})
+	}
+	return nil
+}
+
+// recordStart remembers the CDP URL of a started session: the one reported
+// by the server, or else the one the session was asked to connect to.
+func (c *Capturer) recordStart(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+	var requested string
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			_ = body.Close()
+			requested = gjson.GetBytes(data, "browser.launchOptions.cdpUrl").String()
+		}
+	}
+
+	res, err := next(req)
+	if err != nil || res.Body == nil || res.StatusCode >= http.StatusMultipleChoices {
+		return res, err
+	}
+	data, readErr := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(data))
+	if readErr != nil {
+		return res, readErr
+	}
+	id := gjson.GetBytes(data, "data.sessionId").String()
+	cdpURL := gjson.GetBytes(data, "data.cdpUrl").String()
+	if cdpURL == "" {
+		cdpURL = requested
+	}
+	if id != "" && cdpURL != "" {
+		c.Register(id, cdpURL)
+	}
+	return res, nil
+}
+
+// captureAround takes the captures of an operation: before its first attempt
+// and after its last one, once the result of a streamed act has been read.
+func (c *Capturer) captureAround(r *requestconfig.RequestConfig, execute func() error, id, op string) error {
+	c.mu.Lock()
+	_, known := c.sessions[id]
+	c.mu.Unlock()
+	if !known {
+		return execute()
+	}
+	ctx := r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	around := c.cfg.AroundAct && op == "act"
+	if !around {
+		err := execute()
+		if err != nil && c.cfg.OnError {
+			c.capture(ctx, id, op, "error")
+		}
+		return err
+	}
+
+	c.capture(ctx, id, op, "before")
+	// A streamed act is only done once its events have been read. Successful
+	// responses are not retried, so only the one returned is wrapped.
+	streamed := false
+	middlewares := r.Middlewares
+	defer func() { r.Middlewares = middlewares }()
+	r.Middlewares = append(middlewares[:len(middlewares):len(middlewares)], func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		res, err := next(req)
+		if err == nil && res.StatusCode < http.StatusMultipleChoices && res.Body != nil &&
+			strings.Contains(res.Header.Get("Content-Type"), "text/event-stream") {
+			streamed = true
+			res.Body = &onCloseBody{ReadCloser: res.Body, fn: func() {
+				c.capture(ctx, id, op, "after")
+			}}
+		}
+		return res, err
+	})
+
+	err := execute()
+	switch {
+	case err != nil && c.cfg.OnError:
+		c.capture(ctx, id, op, "error")
+	case err != nil || streamed:
+	default:
+		c.capture(ctx, id, op, "after")
+	}
+	return err
+}
+
+// capture stores a screenshot of a session, and a snapshot for failures if
+// configured. Failures are reported rather than returned.
+func (c *Capturer) capture(ctx context.Context, id, op, stage string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), captureTimeout)
+	defer cancel()
+
+	var errs []error
+	now := time.Now()
+	if data, err := c.Screenshot(ctx, id, c.cfg.Screenshot); err != nil {
+		errs = append(errs, err)
+	} else {
+		errs = append(errs, c.cfg.Sink.Store(ctx, Artifact{
+			SessionID: id, Operation: op, Stage: stage, Kind: "screenshot",
+			ContentType: c.cfg.Screenshot.ContentType(), Data: data, Time: now,
+		}))
+	}
+	if stage == "error" && c.cfg.SnapshotOnError {
+		snap, err := c.Snapshot(ctx, id)
+		if err == nil {
+			var data []byte
+			if data, err = json.Marshal(snap); err == nil {
+				err = c.cfg.Sink.Store(ctx, Artifact{
+					SessionID: id, Operation: op, Stage: stage, Kind: "snapshot",
+					ContentType: "application/json", Data: data, Time: now,
+				})
+			}
+		}
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil && c.cfg.ReportError != nil {
+		c.cfg.ReportError(fmt.Errorf("cdp: failed to capture %s %s of session %s: %w", stage, op, id, err))
+	}
+}
+
+// onCloseBody calls fn once, when the body is closed or fully read.
+type onCloseBody struct {
+	io.ReadCloser
+	fn   func()
+	once sync.Once
+}
+
+func (b *onCloseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.fn)
+	}
+	return n, err
+}
+
+func (b *onCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.fn)
+	return err
+}
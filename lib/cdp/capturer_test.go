@@ -0,0 +1,253 @@
+// Custom tests. Not generated by Stainless.
+package cdp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+	}
+}
+
+// newScreenshotBrowser returns a browser with one page whose screenshots are
+// numbered, and records the parameters of each capture.
+func newScreenshotBrowser(t *testing.T) (*fakeBrowser, func() []map[string]any) {
+	b := newFakeBrowser(t)
+	b.withPages(TargetInfo{TargetID: "page-1", Type: "page", URL: "https://example.com/", Attached: true})
+	var mu sync.Mutex
+	var captures []map[string]any
+	b.handle("Page.captureScreenshot", func(_ string, params json.RawMessage) (any, *Error) {
+		var p map[string]any
+		_ = json.Unmarshal(params, &p)
+		mu.Lock()
+		captures = append(captures, p)
+		n := len(captures)
+		mu.Unlock()
+		return map[string]string{"data": base64.StdEncoding.EncodeToString([]byte("shot-" + string(rune('0'+n))))}, nil
+	})
+	b.handle("Page.getLayoutMetrics", func(string, json.RawMessage) (any, *Error) {
+		return map[string]any{"cssContentSize": map[string]float64{"width": 1280, "height": 4000}}, nil
+	})
+	b.handle("Runtime.evaluate", func(string, json.RawMessage) (any, *Error) {
+		return map[string]any{"result": map[string]any{"type": "object", "value": map[string]string{
+			"url": "https://example.com/", "title": "Example", "html": "<html><body>Example</body></html>",
+		}}}, nil
+	})
+	b.handle("Accessibility.getFullAXTree", func(string, json.RawMessage) (any, *Error) {
+		return map[string]any{"nodes": []map[string]any{
+			{"nodeId": "1", "ignored": false, "role": map[string]any{"type": "role", "value": "RootWebArea"}, "name": map[string]any{"type": "computedString", "value": "Example"}, "childIds": []string{"2"}},
+			{"nodeId": "2", "parentId": "1", "ignored": false, "role": map[string]any{"type": "role", "value": "link"}, "name": map[string]any{"type": "computedString", "value": "More information..."}},
+		}}, nil
+	})
+	return b, func() []map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]map[string]any(nil), captures...)
+	}
+}
+
+func TestCapturer_ScreenshotAndSnapshot(t *testing.T) {
+	b, captures := newScreenshotBrowser(t)
+	c := NewCapturer(CaptureConfig{})
+	defer c.Close()
+	c.Register("session", b.URL())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	data, err := c.Screenshot(ctx, "session", ScreenshotOptions{FullPage: true, Format: "jpeg", Quality: 60})
+	if err != nil {
+		t.Fatalf("Screenshot: %v", err)
+	}
+	if string(data) != "shot-1" {
+		t.Fatalf("unexpected screenshot %q", data)
+	}
+	p := captures()[0]
+	clip, _ := p["clip"].(map[string]any)
+	if p["format"] != "jpeg" || p["quality"] != float64(60) || p["captureBeyondViewport"] != true || clip["height"] != float64(4000) || clip["scale"] != float64(1) {
+		t.Fatalf("unexpected capture parameters %v", p)
+	}
+
+	snap, err := c.Snapshot(ctx, "session")
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if snap.Title != "Example" || !strings.Contains(snap.HTML, "<body>Example") {
+		t.Fatalf("unexpected snapshot %+v", snap)
+	}
+	if len(snap.AccessibilityTree) != 2 || snap.AccessibilityTree[1].Role != "link" || snap.AccessibilityTree[1].Name != "More information..." {
+		t.Fatalf("unexpected accessibility tree %+v", snap.AccessibilityTree)
+	}
+
+	if _, err := c.Screenshot(ctx, "unknown", ScreenshotOptions{}); err == nil {
+		t.Fatalf("expected an error for an unknown session")
+	}
+}
+
+func TestCapturer_ForgetsIdleAndClosedSessions(t *testing.T) {
+	b, _ := newScreenshotBrowser(t)
+	c := NewCapturer(CaptureConfig{IdleTimeout: 100 * time.Millisecond})
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	known := func(id string) bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		_, ok := c.sessions[id]
+		return ok
+	}
+	waitForgotten := func(id string) {
+		t.Helper()
+		for deadline := time.Now().Add(2 * time.Second); known(id); {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected session %s to be forgotten", id)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	c.Register("idle", b.URL())
+	if _, err := c.Screenshot(ctx, "idle", ScreenshotOptions{}); err != nil {
+		t.Fatalf("Screenshot: %v", err)
+	}
+	waitForgotten("idle")
+	if _, err := c.Screenshot(ctx, "idle", ScreenshotOptions{}); err == nil {
+		t.Fatalf("expected an error for a forgotten session")
+	}
+
+	c = NewCapturer(CaptureConfig{})
+	defer c.Close()
+	c.Register("closed", b.URL())
+	if _, err := c.Screenshot(ctx, "closed", ScreenshotOptions{}); err != nil {
+		t.Fatalf("Screenshot: %v", err)
+	}
+	b.emit("", "Target.detachedFromTarget", map[string]string{"sessionId": "session-other", "targetId": "other"})
+	time.Sleep(50 * time.Millisecond)
+	if !known("closed") {
+		t.Fatalf("expected the session to be kept when another target closes")
+	}
+	b.emit("", "Target.detachedFromTarget", map[string]string{"sessionId": "session-page-1", "targetId": "page-1"})
+	waitForgotten("closed")
+}
+
+func TestCapturer_AutoCapture(t *testing.T) {
+	b, _ := newScreenshotBrowser(t)
+
+	var mu sync.Mutex
+	var artifacts []Artifact
+	capturer := NewCapturer(CaptureConfig{
+		Sink: ArtifactSinkFunc(func(_ context.Context, a Artifact) error {
+			mu.Lock()
+			artifacts = append(artifacts, a)
+			mu.Unlock()
+			return nil
+		}),
+		AroundAct:       true,
+		OnError:         true,
+		SnapshotOnError: true,
+		ReportError:     func(err error) { t.Errorf("capture failed: %v", err) },
+	})
+
+	// Captures are taken once per call, however many attempts it takes.
+	attempts := map[string]int{}
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://api.stagehand.test/"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(2),
+		option.WithRetryPolicy(option.ExponentialRetryPolicy(time.Millisecond, time.Millisecond, 0)),
+		option.WithHTTPClient(&http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			attempts[path.Base(req.URL.Path)]++
+			switch {
+			case strings.HasSuffix(req.URL.Path, "/start"):
+				return jsonResponse(http.StatusOK, `{"success":true,"data":{"available":true,"sessionId":"session","cdpUrl":"`+b.URL()+`"}}`), nil
+			case strings.HasSuffix(req.URL.Path, "/act"):
+				if attempts["act"] == 1 {
+					return jsonResponse(http.StatusServiceUnavailable, `{"success":false,"message":"busy"}`), nil
+				}
+				return jsonResponse(http.StatusOK, `{"success":true,"data":{"result":{"success":true,"message":"","actionDescription":"","actions":[]}}}`), nil
+			case strings.HasSuffix(req.URL.Path, "/extract"):
+				return jsonResponse(http.StatusInternalServerError, `{"success":false,"message":"failed"}`), nil
+			default:
+				return jsonResponse(http.StatusOK, `{"success":true,"data":{}}`), nil
+			}
+		})}),
+		capturer,
+	)
+	defer client.Close()
+
+	ctx := context.Background()
+	if _, err := client.Sessions.Start(ctx, stagehand.SessionStartParams{ModelName: "openai/gpt-5.4-mini"}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if _, err := client.Sessions.Act(ctx, "session", stagehand.SessionActParams{
+		Input: stagehand.SessionActParamsInputUnion{OfString: stagehand.String("click")},
+	}); err != nil {
+		t.Fatalf("act: %v", err)
+	}
+	if _, err := client.Sessions.Extract(ctx, "session", stagehand.SessionExtractParams{}); err == nil {
+		t.Fatalf("expected extract to fail")
+	}
+
+	if attempts["act"] != 2 || attempts["extract"] != 3 {
+		t.Fatalf("unexpected attempts %v", attempts)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var got []string
+	for _, a := range artifacts {
+		got = append(got, a.Operation+"/"+a.Stage+"/"+a.Kind+"/"+a.ContentType)
+	}
+	want := []string{
+		"act/before/screenshot/image/png",
+		"act/after/screenshot/image/png",
+		"extract/error/screenshot/image/png",
+		"extract/error/snapshot/application/json",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("expected artifacts %v, got %v", want, got)
+	}
+	if string(artifacts[0].Data) != "shot-1" || string(artifacts[1].Data) != "shot-2" {
+		t.Fatalf("unexpected screenshots %q, %q", artifacts[0].Data, artifacts[1].Data)
+	}
+}
+
+func TestDirSink(t *testing.T) {
+	dir := t.TempDir()
+	at := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	err := DirSink(dir).Store(context.Background(), Artifact{
+		SessionID: "session", Operation: "act", Stage: "before", Kind: "screenshot",
+		ContentType: "image/jpeg", Data: []byte("jpeg"), Time: at,
+	})
+	if err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "session", "20250102T150405.000-act-before.jpg"))
+	if err != nil || string(data) != "jpeg" {
+		t.Fatalf("unexpected file: %q, %v", data, err)
+	}
+}
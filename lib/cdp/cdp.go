@@ -0,0 +1,322 @@
+// Custom code. Not generated by Stainless.
+
+// Package cdp attaches to the browser of a Stagehand session over the Chrome
+// DevTools Protocol, so deterministic CDP steps can be mixed with Stagehand's
+// AI steps on the same page. It has no dependencies beyond the standard
+// library; see [Endpoint] for using it with chromedp and other CDP libraries.
+package cdp
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// EnsurePort returns wsURL with the default port of its scheme made explicit,
+// as some CDP clients require. Browserbase returns URLs without a port.
+// Invalid URLs are returned unchanged.
+func EnsurePort(wsURL string) string {
+	u, err := url.Parse(wsURL)
+	if err != nil || u.Host == "" || u.Port() != "" {
+		return wsURL
+	}
+	switch u.Scheme {
+	case "wss", "https":
+		u.Host = u.Host + ":443"
+	case "ws", "http":
+		u.Host = u.Host + ":80"
+	}
+	return u.String()
+}
+
+// DialOptions configure the connection to a browser.
+type DialOptions struct {
+	// Header is sent with the WebSocket handshake.
+	Header http.Header
+	// TLSConfig configures wss connections.
+	TLSConfig *tls.Config
+}
+
+// ErrClosed is returned by calls on a closed connection.
+var ErrClosed = errors.New("cdp: connection closed")
+
+// Error is an error returned by the browser for a command.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Data != "" {
+		return fmt.Sprintf("cdp: %s (%d): %s", e.Message, e.Code, e.Data)
+	}
+	return fmt.Sprintf("cdp: %s (%d)", e.Message, e.Code)
+}
+
+// Event is a protocol event sent by the browser.
+type Event struct {
+	Method string
+	// SessionID identifies the target session the event belongs to, or is
+	// empty for browser-level events.
+	SessionID string
+	Params    json.RawMessage
+}
+
+type message struct {
+	ID        int64           `json:"id,omitempty"`
+	Method    string          `json:"method,omitempty"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	SessionID string          `json:"sessionId,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     *Error          `json:"error,omitempty"`
+}
+
+type handler struct {
+	method    string
+	sessionID string
+	fn        func(Event)
+}
+
+// Conn is a connection to a browser's DevTools endpoint. It is safe for
+// concurrent use.
+type Conn struct {
+	ws     *wsConn
+	nextID atomic.Int64
+
+	mu       sync.Mutex
+	pending  map[int64]chan message
+	handlers map[int]handler
+	nextH    int
+	err      error
+	done     chan struct{}
+
+	// Events are handed from the read loop to the dispatcher through an
+	// unbounded queue, so handlers may send commands without blocking the
+	// responses they wait for.
+	queueMu   sync.Mutex
+	queueCond *sync.Cond
+	queue     []Event
+	closed    bool
+}
+
+// Dial connects to the browser-level DevTools WebSocket at wsURL, such as the
+// cdpUrl of a started session. The URL's port is made explicit with
+// [EnsurePort].
+func Dial(ctx context.Context, wsURL string, opts *DialOptions) (*Conn, error) {
+	if opts == nil {
+		opts = &DialOptions{}
+	}
+	ws, err := dialWebSocket(ctx, EnsurePort(wsURL), *opts)
+	if err != nil {
+		return nil, fmt.Errorf("cdp: failed to connect to %s: %w", redactURL(wsURL), err)
+	}
+	c := &Conn{
+		ws:       ws,
+		pending:  map[int64]chan message{},
+		handlers: map[int]handler{},
+		done:     make(chan struct{}),
+	}
+	c.queueCond = sync.NewCond(&c.queueMu)
+	go c.readLoop()
+	go c.dispatchLoop()
+	return c, nil
+}
+
+// redactURL drops the query of a CDP URL, which holds the connection's
+// credentials on Browserbase.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<invalid URL>"
+	}
+	u.RawQuery = ""
+	return u.String()
+}
+
+// Send runs a browser-level command and decodes its result into result, which
+// may be nil. params may be nil or any value that encodes to a JSON object.
+func (c *Conn) Send(ctx context.Context, method string, params, result any) error {
+	return c.send(ctx, "", method, params, result)
+}
+
+// On calls fn for every event named method, or for every event if method is
+// empty, including events of attached sessions. Handlers are called one at a
+// time in the order events arrive, and may send commands. On returns a
+// function that removes the handler.
+func (c *Conn) On(method string, fn func(Event)) (remove func()) {
+	return c.on(handler{method: method, fn: fn})
+}
+
+// Done is closed when the connection is closed.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the reason the connection closed, or nil while it is open.
+func (c *Conn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	c.shutdown(ErrClosed)
+	return c.ws.Close()
+}
+
+func (c *Conn) on(h handler) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := c.nextH
+	c.nextH++
+	c.handlers[id] = h
+	return func() {
+		c.mu.Lock()
+		delete(c.handlers, id)
+		c.mu.Unlock()
+	}
+}
+
+func (c *Conn) send(ctx context.Context, sessionID, method string, params, result any) error {
+	msg := message{ID: c.nextID.Add(1), Method: method, SessionID: sessionID}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("cdp: invalid params for %s: %w", method, err)
+		}
+		msg.Params = raw
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	reply := make(chan message, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return err
+	}
+	c.pending[msg.ID] = reply
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, msg.ID)
+		c.mu.Unlock()
+	}()
+
+	if err := c.ws.WriteMessage(data); err != nil {
+		return err
+	}
+	select {
+	case res := <-reply:
+		if res.Error != nil {
+			return res.Error
+		}
+		if result != nil && len(res.Result) > 0 {
+			if err := json.Unmarshal(res.Result, result); err != nil {
+				return fmt.Errorf("cdp: invalid result for %s: %w", method, err)
+			}
+		}
+		return nil
+	case <-c.done:
+		return c.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Conn) readLoop() {
+	for {
+		data, err := c.ws.ReadMessage()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = ErrClosed
+			}
+			c.shutdown(err)
+			return
+		}
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		if msg.ID != 0 {
+			c.mu.Lock()
+			reply, ok := c.pending[msg.ID]
+			c.mu.Unlock()
+			if ok {
+				reply <- msg
+			}
+			continue
+		}
+		if msg.Method != "" {
+			c.queueMu.Lock()
+			c.queue = append(c.queue, Event{Method: msg.Method, SessionID: msg.SessionID, Params: msg.Params})
+			c.queueCond.Signal()
+			c.queueMu.Unlock()
+		}
+	}
+}
+
+func (c *Conn) dispatchLoop() {
+	for {
+		c.queueMu.Lock()
+		for len(c.queue) == 0 && !c.closed {
+			c.queueCond.Wait()
+		}
+		if len(c.queue) == 0 {
+			c.queueMu.Unlock()
+			return
+		}
+		ev := c.queue[0]
+		c.queue = c.queue[1:]
+		c.queueMu.Unlock()
+
+		c.mu.Lock()
+		ids := make([]int, 0, len(c.handlers))
+		for id := range c.handlers {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+		var fns []func(Event)
+		for _, id := range ids {
+			h := c.handlers[id]
+			if (h.method == "" || h.method == ev.Method) && (h.sessionID == "" || h.sessionID == ev.SessionID) {
+				fns = append(fns, h.fn)
+			}
+		}
+		c.mu.Unlock()
+		for _, fn := range fns {
+			fn(ev)
+		}
+	}
+}
+
+// shutdown records why the connection ended and wakes everything waiting on
+// it. Only the first call has an effect.
+func (c *Conn) shutdown(err error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.err = err
+	close(c.done)
+	c.mu.Unlock()
+
+	c.queueMu.Lock()
+	c.closed = true
+	c.queueCond.Broadcast()
+	c.queueMu.Unlock()
+}
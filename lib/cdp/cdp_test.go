@@ -0,0 +1,318 @@
+// Custom tests. Not generated by Stainless.
+package cdp
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBrowser is an in-process stand-in for a browser's DevTools WebSocket.
+// Commands are answered by the handler registered for their method.
+type fakeBrowser struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	handlers map[string]func(sessionID string, params json.RawMessage) (any, *Error)
+	conns    []*fakeBrowserConn
+	calls    []string
+}
+
+type fakeBrowserConn struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+}
+
+func newFakeBrowser(t *testing.T) *fakeBrowser {
+	t.Helper()
+	b := &fakeBrowser{t: t, handlers: map[string]func(string, json.RawMessage) (any, *Error){}}
+	b.srv = httptest.NewServer(http.HandlerFunc(b.serve))
+	t.Cleanup(b.srv.Close)
+	return b
+}
+
+// URL returns the browser's WebSocket URL.
+func (b *fakeBrowser) URL() string {
+	return "ws" + strings.TrimPrefix(b.srv.URL, "http") + "/devtools/browser/fake"
+}
+
+func (b *fakeBrowser) handle(method string, fn func(sessionID string, params json.RawMessage) (any, *Error)) {
+	b.mu.Lock()
+	b.handlers[method] = fn
+	b.mu.Unlock()
+}
+
+// emit sends an event to every connected client.
+func (b *fakeBrowser) emit(sessionID, method string, params any) {
+	raw, _ := json.Marshal(params)
+	b.mu.Lock()
+	conns := append([]*fakeBrowserConn(nil), b.conns...)
+	b.mu.Unlock()
+	for _, c := range conns {
+		c.write(message{Method: method, SessionID: sessionID, Params: raw})
+	}
+}
+
+func (b *fakeBrowser) serve(w http.ResponseWriter, r *http.Request) {
+	sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + websocketGUID))
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		b.t.Errorf("hijack: %v", err)
+		return
+	}
+	defer conn.Close()
+	_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	_ = rw.Flush()
+
+	fc := &fakeBrowserConn{conn: conn}
+	b.mu.Lock()
+	b.conns = append(b.conns, fc)
+	b.mu.Unlock()
+
+	reader := &wsConn{conn: conn, br: bufio.NewReader(rw)}
+	for {
+		_, opcode, payload, err := reader.readFrame()
+		if err != nil || opcode == opClose {
+			return
+		}
+		var msg message
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			b.t.Errorf("invalid message %s", payload)
+			return
+		}
+		b.mu.Lock()
+		b.calls = append(b.calls, msg.Method)
+		fn := b.handlers[msg.Method]
+		b.mu.Unlock()
+
+		reply := message{ID: msg.ID, SessionID: msg.SessionID}
+		if fn == nil {
+			reply.Error = &Error{Code: -32601, Message: "'" + msg.Method + "' wasn't found"}
+		} else if result, cdpErr := fn(msg.SessionID, msg.Params); cdpErr != nil {
+			reply.Error = cdpErr
+		} else {
+			reply.Result, _ = json.Marshal(result)
+		}
+		fc.write(reply)
+	}
+}
+
+// write sends msg in an unmasked frame, as servers do.
+func (c *fakeBrowserConn) write(msg message) {
+	data, _ := json.Marshal(msg)
+	header := []byte{0x80 | opText}
+	switch n := len(data); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, _ = c.conn.Write(append(header, data...))
+}
+
+// withPages makes the browser report pages and accept attaching to them.
+func (b *fakeBrowser) withPages(pages ...TargetInfo) {
+	b.handle("Target.getTargets", func(string, json.RawMessage) (any, *Error) {
+		return map[string]any{"targetInfos": pages}, nil
+	})
+	b.handle("Target.attachToTarget", func(_ string, params json.RawMessage) (any, *Error) {
+		var p struct {
+			TargetID string `json:"targetId"`
+			Flatten  bool   `json:"flatten"`
+		}
+		_ = json.Unmarshal(params, &p)
+		if !p.Flatten {
+			return nil, &Error{Code: -32000, Message: "expected flatten"}
+		}
+		return map[string]any{"sessionId": "session-" + p.TargetID}, nil
+	})
+}
+
+func TestEnsurePort(t *testing.T) {
+	for in, want := range map[string]string{
+		"wss://connect.browserbase.com?signingKey=abc": "wss://connect.browserbase.com:443?signingKey=abc",
+		"ws://127.0.0.1/devtools/browser/1":            "ws://127.0.0.1:80/devtools/browser/1",
+		"ws://127.0.0.1:9222/devtools/browser/1":       "ws://127.0.0.1:9222/devtools/browser/1",
+		"wss://[::1]/devtools":                         "wss://[::1]:443/devtools",
+		"not a url":                                    "not a url",
+	} {
+		if got := EnsurePort(in); got != want {
+			t.Errorf("EnsurePort(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestConn_SendAndEvents(t *testing.T) {
+	b := newFakeBrowser(t)
+	b.handle("Browser.getVersion", func(string, json.RawMessage) (any, *Error) {
+		return map[string]string{"product": "Chrome/130"}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := Dial(ctx, b.URL(), nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+
+	var version struct {
+		Product string `json:"product"`
+	}
+	if err := conn.Send(ctx, "Browser.getVersion", nil, &version); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if version.Product != "Chrome/130" {
+		t.Fatalf("unexpected result %+v", version)
+	}
+
+	var cdpErr *Error
+	if err := conn.Send(ctx, "Nope.missing", nil, nil); !errors.As(err, &cdpErr) || cdpErr.Code != -32601 {
+		t.Fatalf("expected a protocol error, got %v", err)
+	}
+
+	// Handlers may send commands while handling an event.
+	events := make(chan string, 1)
+	remove := conn.On("Target.targetCreated", func(ev Event) {
+		var v struct {
+			Product string `json:"product"`
+		}
+		if err := conn.Send(ctx, "Browser.getVersion", nil, &v); err != nil {
+			t.Errorf("Send from handler: %v", err)
+		}
+		events <- string(ev.Params) + " " + v.Product
+	})
+	b.emit("", "Target.targetCreated", map[string]string{"targetId": "t1"})
+	select {
+	case got := <-events:
+		if got != `{"targetId":"t1"} Chrome/130` {
+			t.Fatalf("unexpected event %s", got)
+		}
+	case <-ctx.Done():
+		t.Fatal("event not delivered")
+	}
+	remove()
+}
+
+func TestConn_CloseFailsPendingCalls(t *testing.T) {
+	b := newFakeBrowser(t)
+	block := make(chan struct{})
+	defer close(block)
+	b.handle("Runtime.evaluate", func(string, json.RawMessage) (any, *Error) {
+		<-block
+		return nil, nil
+	})
+
+	ctx := context.Background()
+	conn, err := Dial(ctx, b.URL(), nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- conn.Send(ctx, "Runtime.evaluate", nil, nil) }()
+	time.Sleep(50 * time.Millisecond)
+	_ = conn.Close()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, ErrClosed) {
+			t.Fatalf("expected ErrClosed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pending call not released")
+	}
+	if err := conn.Send(ctx, "Runtime.evaluate", nil, nil); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed after Close, got %v", err)
+	}
+}
+
+func TestFindPage(t *testing.T) {
+	targets := []TargetInfo{
+		{TargetID: "worker", Type: "service_worker", URL: "https://example.com/sw.js", Attached: true},
+		{TargetID: "a", Type: "page", URL: "https://example.com/"},
+		{TargetID: "b", Type: "page", URL: "https://example.org/", Attached: true},
+	}
+	if p, _ := FindPage(targets, PageOptions{}); p.TargetID != "b" {
+		t.Fatalf("expected the attached page, got %s", p.TargetID)
+	}
+	if p, _ := FindPage(targets, PageOptions{URLContains: "example.com"}); p.TargetID != "a" {
+		t.Fatalf("expected the page matching the URL, got %s", p.TargetID)
+	}
+	if _, err := FindPage(targets, PageOptions{URLContains: "missing"}); !errors.Is(err, ErrNoPage) {
+		t.Fatalf("expected ErrNoPage, got %v", err)
+	}
+}
+
+func TestAttachPage(t *testing.T) {
+	b := newFakeBrowser(t)
+	b.withPages(TargetInfo{TargetID: "page-1", Type: "page", URL: "https://example.com/", Attached: true})
+	b.handle("Runtime.evaluate", func(sessionID string, _ json.RawMessage) (any, *Error) {
+		return map[string]any{"result": map[string]any{"type": "string", "value": sessionID}}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	page, err := AttachPage(ctx, b.URL(), &AttachOptions{Page: PageOptions{URLContains: "example.com"}})
+	if err != nil {
+		t.Fatalf("AttachPage: %v", err)
+	}
+	defer page.Close()
+
+	var res struct {
+		Result struct {
+			Value string `json:"value"`
+		} `json:"result"`
+	}
+	if err := page.Send(ctx, "Runtime.evaluate", map[string]any{"expression": "1"}, &res); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if res.Result.Value != "session-page-1" {
+		t.Fatalf("expected the command to run in the page session, got %q", res.Result.Value)
+	}
+
+	loaded := make(chan struct{}, 2)
+	page.On("Page.loadEventFired", func(Event) { loaded <- struct{}{} })
+	b.emit("other-session", "Page.loadEventFired", nil)
+	b.emit("session-page-1", "Page.loadEventFired", nil)
+	<-loaded
+	select {
+	case <-loaded:
+		t.Fatal("received an event of another session")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if ep := page.Endpoint(); ep.TargetID != "page-1" || ep.URL != b.URL() {
+		t.Fatalf("unexpected endpoint %+v", ep)
+	}
+}
+
+func TestWaitForPage_Timeout(t *testing.T) {
+	b := newFakeBrowser(t)
+	b.withPages()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	_, err := ResolveEndpoint(ctx, b.URL(), &AttachOptions{Page: PageOptions{URLContains: "example.com", PollInterval: 20 * time.Millisecond}})
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "example.com") {
+		t.Fatalf("expected a timeout naming the URL, got %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+// Custom code. Not generated by Stainless.
+package cdp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3"
+)
+
+// remoteDownloadDir is where remote browsers save downloads. Browserbase
+// makes the files in it available through its downloads API.
+const remoteDownloadDir = "downloads"
+
+// Download is a file downloaded by the browser.
+type Download struct {
+	// GUID identifies the download. The browser saves the file under it.
+	GUID string
+	URL  string
+	// Filename is the name the page suggested for the file.
+	Filename string
+	// Path is where the file is, on the browser's machine.
+	Path string
+	Size int64
+	// MIMEType is derived from the filename's extension, or else from the
+	// content of local files.
+	MIMEType string
+	// State is "completed" or "canceled".
+	State string
+	// Remote is set if the browser runs on another machine, so the file has
+	// to be fetched with [ReadDownload].
+	Remote bool
+}
+
+// DownloadOptions configure [Page.WatchDownloads].
+type DownloadOptions struct {
+	// Dir is where the browser saves downloads, on the browser's machine.
+	// Defaults to a new temporary directory for browsers on this machine,
+	// and to the directory Browserbase serves downloads from for others.
+	Dir string
+}
+
+// Downloads tracks the downloads of a page's browser context.
+type Downloads struct {
+	dir    string
+	remote bool
+	remove []func()
+
+	mu       sync.Mutex
+	pending  map[string]*Download
+	finished []Download
+	changed  chan struct{}
+}
+
+// WatchDownloads makes the browser save the downloads of the page's browser
+// context and report them, until [Downloads.Close] is called. Start watching
+// before the action that triggers downloads. opts may be nil.
+func (p *Page) WatchDownloads(ctx context.Context, opts *DownloadOptions) (*Downloads, error) {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	d := &Downloads{dir: opts.Dir, remote: !isLocalURL(p.url), pending: map[string]*Download{}, changed: make(chan struct{})}
+	if d.dir == "" {
+		if d.remote {
+			d.dir = remoteDownloadDir
+		} else {
+			dir, err := os.MkdirTemp("", "stagehand-downloads-")
+			if err != nil {
+				return nil, err
+			}
+			d.dir = dir
+		}
+	}
+
+	d.remove = append(d.remove,
+		p.conn.On("Browser.downloadWillBegin", d.willBegin),
+		p.conn.On("Browser.downloadProgress", d.progress),
+	)
+	err := p.conn.Send(ctx, "Browser.setDownloadBehavior", p.contextParams(map[string]any{
+		"behavior":      "allowAndName",
+		"downloadPath":  d.dir,
+		"eventsEnabled": true,
+	}), nil)
+	if err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func isLocalURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Hostname(), "localhost") {
+		return true
+	}
+	ip := net.ParseIP(u.Hostname())
+	return ip != nil && ip.IsLoopback()
+}
+
+// Dir returns where the browser saves downloads.
+func (d *Downloads) Dir() string {
+	return d.dir
+}
+
+func (d *Downloads) willBegin(ev Event) {
+	var p struct {
+		GUID              string `json:"guid"`
+		URL               string `json:"url"`
+		SuggestedFilename string `json:"suggestedFilename"`
+	}
+	if err := json.Unmarshal(ev.Params, &p); err != nil {
+		return
+	}
+	join := filepath.Join
+	if d.remote {
+		join = path.Join
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending[p.GUID] = &Download{
+		GUID: p.GUID, URL: p.URL, Filename: p.SuggestedFilename,
+		Path: join(d.dir, p.GUID), Remote: d.remote,
+	}
+	d.notify()
+}
+
+func (d *Downloads) progress(ev Event) {
+	var p struct {
+		GUID          string  `json:"guid"`
+		TotalBytes    float64 `json:"totalBytes"`
+		ReceivedBytes float64 `json:"receivedBytes"`
+		State         string  `json:"state"`
+		FilePath      string  `json:"filePath"`
+	}
+	if err := json.Unmarshal(ev.Params, &p); err != nil || p.State == "inProgress" {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	download, ok := d.pending[p.GUID]
+	if !ok {
+		return
+	}
+	delete(d.pending, p.GUID)
+	download.State = p.State
+	download.Size = int64(p.ReceivedBytes)
+	if p.FilePath != "" {
+		download.Path = p.FilePath
+	}
+	download.MIMEType = downloadMIMEType(*download)
+	d.finished = append(d.finished, *download)
+	d.notify()
+}
+
+// notify wakes up waiters. The caller holds d.mu.
+func (d *Downloads) notify() {
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
+
+func downloadMIMEType(d Download) string {
+	if t := mime.TypeByExtension(filepath.Ext(d.Filename)); t != "" {
+		t, _, _ = strings.Cut(t, ";")
+		return t
+	}
+	if !d.Remote && d.State == "completed" {
+		if f, err := os.Open(d.Path); err == nil {
+			defer f.Close()
+			head := make([]byte, 512)
+			n, _ := f.Read(head)
+			t, _, _ := strings.Cut(http.DetectContentType(head[:n]), ";")
+			return t
+		}
+	}
+	return "application/octet-stream"
+}
+
+// Wait waits for the downloads begun since watching started or since the
+// previous Wait, and returns them once they have finished. Downloads may
+// begin shortly after the action that triggers them returns, so if none is
+// in progress Wait waits up to settle for one to begin. Bound the wait with
+// ctx.
+func (d *Downloads) Wait(ctx context.Context, settle time.Duration) ([]Download, error) {
+	timer := time.NewTimer(settle)
+	defer timer.Stop()
+	settled := false
+	for {
+		d.mu.Lock()
+		if len(d.pending) == 0 && (settled || len(d.finished) > 0) {
+			finished := d.finished
+			d.finished = nil
+			d.mu.Unlock()
+			return finished, nil
+		}
+		changed := d.changed
+		d.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			settled = true
+		case <-ctx.Done():
+			d.mu.Lock()
+			pending := len(d.pending)
+			d.mu.Unlock()
+			return nil, fmt.Errorf("cdp: %d downloads did not finish: %w", pending, ctx.Err())
+		}
+	}
+}
+
+// Close stops watching downloads. Files already downloaded are kept.
+func (d *Downloads) Close() error {
+	for _, remove := range d.remove {
+		remove()
+	}
+	d.remove = nil
+	return nil
+}
+
+// timestampSuffix matches the time Browserbase adds to the names of
+// downloaded files.
+var timestampSuffix = regexp.MustCompile(`-\d+$`)
+
+// ReadDownload returns the content of a completed download. Files of remote
+// browsers are fetched through the Browserbase downloads API of the session,
+// waiting for the file to become available; bound the wait with ctx. A remote
+// file is found by the download's GUID, or else by its filename, which fails
+// if the session downloaded several files of that name.
+func ReadDownload(ctx context.Context, service *stagehand.DownloadService, sessionID string, d Download) ([]byte, error) {
+	if d.State != "completed" {
+		return nil, fmt.Errorf("cdp: download %s is %s", d.Filename, d.State)
+	}
+	if !d.Remote {
+		return os.ReadFile(d.Path)
+	}
+	if service == nil {
+		return nil, errors.New("cdp: a download service is required for remote downloads")
+	}
+
+	ext := path.Ext(d.Filename)
+	stem := strings.TrimSuffix(d.Filename, ext)
+	for {
+		files, err := service.List(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		// Files are matched by GUID, or else by the suggested filename with
+		// a timestamp added. Several files of the same name cannot be told
+		// apart, so none of them is taken.
+		var matches []string
+		var data []byte
+		for _, f := range files {
+			name := path.Base(f.Name)
+			if name == d.GUID {
+				return f.Data, nil
+			}
+			if path.Ext(name) == ext && timestampSuffix.ReplaceAllString(strings.TrimSuffix(name, ext), "") == stem {
+				matches = append(matches, name)
+				data = f.Data
+			}
+		}
+		switch {
+		case len(matches) == 1:
+			return data, nil
+		case len(matches) > 1:
+			return nil, fmt.Errorf("cdp: download %s is ambiguous in session %s, which has %s", d.Filename, sessionID, strings.Join(matches, ", "))
+		}
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("cdp: download %s is not available in session %s: %w", d.Filename, sessionID, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Custom tests. Not generated by Stainless.
+package cdp
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+func TestPage_WatchDownloads(t *testing.T) {
+	b := newFakeBrowser(t)
+	b.withPages(TargetInfo{TargetID: "main", Type: "page", URL: "https://shop.test/orders", BrowserContextID: "ctx-1"})
+	var behavior map[string]any
+	b.handle("Browser.setDownloadBehavior", func(_ string, params json.RawMessage) (any, *Error) {
+		_ = json.Unmarshal(params, &behavior)
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	page, err := AttachPage(ctx, b.URL(), nil)
+	if err != nil {
+		t.Fatalf("AttachPage: %v", err)
+	}
+	defer page.Close()
+
+	downloads, err := page.WatchDownloads(ctx, &DownloadOptions{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("WatchDownloads: %v", err)
+	}
+	defer downloads.Close()
+	if behavior["behavior"] != "allowAndName" || behavior["downloadPath"] != downloads.Dir() || behavior["browserContextId"] != "ctx-1" || behavior["eventsEnabled"] != true {
+		t.Fatalf("unexpected download behavior %v", behavior)
+	}
+
+	// A download that begins shortly after the action is waited for.
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		b.emit("", "Browser.downloadWillBegin", map[string]any{"guid": "g-1", "url": "https://shop.test/export", "suggestedFilename": "orders.csv"})
+		b.emit("", "Browser.downloadProgress", map[string]any{"guid": "g-1", "state": "inProgress", "receivedBytes": 4, "totalBytes": 9})
+		_ = os.WriteFile(filepath.Join(downloads.Dir(), "g-1"), []byte("id,total\n"), 0o600)
+		b.emit("", "Browser.downloadProgress", map[string]any{"guid": "g-1", "state": "completed", "receivedBytes": 9, "totalBytes": 9})
+	}()
+	got, err := downloads.Wait(ctx, time.Second)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	want := Download{
+		GUID: "g-1", URL: "https://shop.test/export", Filename: "orders.csv",
+		Path: filepath.Join(downloads.Dir(), "g-1"), Size: 9, MIMEType: "text/csv", State: "completed",
+	}
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	data, err := ReadDownload(ctx, nil, "session", got[0])
+	if err != nil || string(data) != "id,total\n" {
+		t.Fatalf("unexpected content %q, %v", data, err)
+	}
+
+	// Without downloads, Wait returns once settled.
+	if got, err := downloads.Wait(ctx, 20*time.Millisecond); err != nil || len(got) != 0 {
+		t.Fatalf("expected no downloads, got %+v, %v", got, err)
+	}
+}
+
+func TestReadDownload_Remote(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for name, content := range map[string]string{
+		"orders-1719265797164.csv":  "orders",
+		"report-1719265797164.csv":  "old",
+		"report-1719265799999.csv":  "new",
+		"g-3":                       "by guid",
+		"invoice-1719265797164.pdf": "pdf",
+	} {
+		w, _ := zw.Create(name)
+		_, _ = w.Write([]byte(content))
+	}
+	_ = zw.Close()
+
+	var paths []string
+	client := stagehand.NewClient(
+		option.WithBrowserbaseAPIURL("http://browserbase.test"),
+		option.WithBrowserbaseAPIKey("My Browserbase API Key"),
+		option.WithMaxRetries(0),
+		option.WithHTTPClient(&http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			paths = append(paths, req.URL.Path)
+			res := jsonResponse(http.StatusOK, "")
+			res.Header.Set("Content-Type", "application/zip")
+			res.Body = http.NoBody
+			if len(paths) > 1 {
+				res.Body = io.NopCloser(bytes.NewReader(archive.Bytes()))
+			}
+			return res, nil
+		})}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	d := Download{GUID: "g-1", Filename: "orders.csv", State: "completed", Remote: true}
+	data, err := ReadDownload(ctx, &client.Downloads, "session", d)
+	if err != nil || string(data) != "orders" {
+		t.Fatalf("expected orders.csv, got %q, %v", data, err)
+	}
+	if len(paths) != 2 || paths[0] != "/v1/sessions/session/downloads" {
+		t.Fatalf("expected the downloads to be polled until available, got %v", paths)
+	}
+
+	if data, err := ReadDownload(ctx, &client.Downloads, "session", Download{GUID: "g-3", Filename: "report.csv", State: "completed", Remote: true}); err != nil || string(data) != "by guid" {
+		t.Fatalf("expected the file named after the GUID, got %q, %v", data, err)
+	}
+	if _, err := ReadDownload(ctx, &client.Downloads, "session", Download{GUID: "g-2", Filename: "report.csv", State: "completed", Remote: true}); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Fatalf("expected an error for several files of the same name, got %v", err)
+	}
+
+	d.State = "canceled"
+	if _, err := ReadDownload(ctx, &client.Downloads, "session", d); err == nil || !strings.Contains(err.Error(), "canceled") {
+		t.Fatalf("expected an error for a canceled download, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+// Custom code. Not generated by Stainless.
+package cdp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Frame is a frame of a page. Its ID is the value Stagehand calls take as
+// their FrameID parameter.
+type Frame struct {
+	ID string
+	// ParentID is the ID of the parent frame, or empty for the main frame.
+	ParentID string
+	URL      string
+	// Name is the frame's name attribute, if any.
+	Name string
+}
+
+type frameTree struct {
+	Frame struct {
+		ID       string `json:"id"`
+		ParentID string `json:"parentId"`
+		URL      string `json:"url"`
+		Name     string `json:"name"`
+	} `json:"frame"`
+	ChildFrames []frameTree `json:"childFrames"`
+}
+
+func (t frameTree) flatten(parentID string, frames []Frame) []Frame {
+	frames = append(frames, Frame{ID: t.Frame.ID, ParentID: parentID, URL: t.Frame.URL, Name: t.Frame.Name})
+	for _, child := range t.ChildFrames {
+		frames = child.flatten(t.Frame.ID, frames)
+	}
+	return frames
+}
+
+func getFrameTree(ctx context.Context, c *Conn, sessionID string) (frameTree, error) {
+	var res struct {
+		FrameTree frameTree `json:"frameTree"`
+	}
+	err := c.send(ctx, sessionID, "Page.getFrameTree", nil, &res)
+	return res.FrameTree, err
+}
+
+// Frames returns the frames of the session's page, the main frame first and
+// every frame before its children. Out-of-process iframes, which is how
+// browsers usually run cross-origin payment and login iframes, are included
+// by briefly attaching to their targets.
+func (s *Session) Frames(ctx context.Context) ([]Frame, error) {
+	tree, err := getFrameTree(ctx, s.conn, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	frames := tree.flatten("", nil)
+
+	targets, err := s.conn.Targets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	iframes := map[string]TargetInfo{}
+	for _, t := range targets {
+		if t.Type == "iframe" {
+			iframes[t.TargetID] = t
+		}
+	}
+	// An out-of-process iframe appears in its parent's tree with the ID of
+	// its own target, whose tree holds the frame's children.
+	for i := 0; i < len(frames) && len(iframes) > 0; i++ {
+		target, ok := iframes[frames[i].ID]
+		if !ok {
+			continue
+		}
+		delete(iframes, target.TargetID)
+		child, err := s.conn.Attach(ctx, target)
+		if err != nil {
+			// The iframe may have gone away since the targets were listed.
+			continue
+		}
+		sub, err := getFrameTree(ctx, s.conn, child.ID)
+		_ = child.Detach(ctx)
+		if err != nil {
+			continue
+		}
+		for _, grandchild := range sub.ChildFrames {
+			frames = grandchild.flatten(frames[i].ID, frames)
+		}
+	}
+	return frames, nil
+}
+
+// FrameSelector reports whether a frame is the one looked for.
+type FrameSelector func(Frame) bool
+
+// FrameMatching selects frames whose URL matches urlPattern. In the pattern,
+// * matches any run of characters, and the whole URL must match, e.g.
+// "https://js.stripe.com/*". A pattern without * matches any URL containing
+// it.
+func FrameMatching(urlPattern string) FrameSelector {
+	if !strings.Contains(urlPattern, "*") {
+		return func(f Frame) bool { return strings.Contains(f.URL, urlPattern) }
+	}
+	re := regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(urlPattern), `\*`, ".*") + "$")
+	return func(f Frame) bool { return re.MatchString(f.URL) }
+}
+
+// FrameNamed selects frames whose name attribute is name.
+func FrameNamed(name string) FrameSelector {
+	return func(f Frame) bool { return f.Name == name }
+}
+
+// ErrNoFrame is returned when no frame matches a selector.
+var ErrNoFrame = errors.New("cdp: no matching frame")
+
+// FindFrame returns the first of frames selected by sel.
+func FindFrame(frames []Frame, sel FrameSelector) (Frame, error) {
+	for _, f := range frames {
+		if sel(f) {
+			return f, nil
+		}
+	}
+	return Frame{}, ErrNoFrame
+}
+
+// Frame returns the first frame of the session's page selected by sel.
+func (s *Session) Frame(ctx context.Context, sel FrameSelector) (Frame, error) {
+	frames, err := s.Frames(ctx)
+	if err != nil {
+		return Frame{}, err
+	}
+	return FindFrame(frames, sel)
+}
+
+// WaitForFrame lists the frames of the session's page every interval until
+// one is selected by sel, or ctx is done. A zero interval defaults to 200
+// milliseconds.
+func (s *Session) WaitForFrame(ctx context.Context, sel FrameSelector, interval time.Duration) (Frame, error) {
+	if interval <= 0 {
+		interval = 200 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		frame, err := s.Frame(ctx, sel)
+		if err == nil {
+			return frame, nil
+		}
+		if !errors.Is(err, ErrNoFrame) && ctx.Err() == nil {
+			return Frame{}, err
+		}
+		select {
+		case <-ctx.Done():
+			return Frame{}, fmt.Errorf("%w: %w", ErrNoFrame, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
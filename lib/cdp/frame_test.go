@@ -0,0 +1,115 @@
+// Custom tests. Not generated by Stainless.
+package cdp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func frameNode(id, url, name string, children ...map[string]any) map[string]any {
+	node := map[string]any{"frame": map[string]any{"id": id, "url": url, "name": name}}
+	if len(children) > 0 {
+		node["childFrames"] = children
+	}
+	return node
+}
+
+func TestSession_Frames(t *testing.T) {
+	b := newFakeBrowser(t)
+	b.withPages(TargetInfo{TargetID: "main", Type: "page", URL: "https://shop.test/checkout"})
+	// The payment iframe is out of process, so its own child is only visible
+	// through its target.
+	b.handle("Target.getTargets", func(string, json.RawMessage) (any, *Error) {
+		return map[string]any{"targetInfos": []TargetInfo{
+			{TargetID: "main", Type: "page", URL: "https://shop.test/checkout"},
+			{TargetID: "pay", Type: "iframe", URL: "https://js.stripe.com/v3/elements"},
+		}}, nil
+	})
+	b.handle("Page.getFrameTree", func(sessionID string, _ json.RawMessage) (any, *Error) {
+		switch sessionID {
+		case "session-main":
+			return map[string]any{"frameTree": frameNode("main", "https://shop.test/checkout", "",
+				frameNode("login", "https://login.shop.test/frame", "login"),
+				frameNode("pay", "https://js.stripe.com/v3/elements", "__privateStripeFrame1"),
+			)}, nil
+		case "session-pay":
+			return map[string]any{"frameTree": frameNode("pay", "https://js.stripe.com/v3/elements", "",
+				frameNode("pay-inner", "https://hooks.stripe.com/3ds", "challenge"),
+			)}, nil
+		}
+		return nil, &Error{Code: -32000, Message: "unknown session " + sessionID}
+	})
+	var detached []string
+	b.handle("Target.detachFromTarget", func(_ string, params json.RawMessage) (any, *Error) {
+		var p struct {
+			SessionID string `json:"sessionId"`
+		}
+		_ = json.Unmarshal(params, &p)
+		detached = append(detached, p.SessionID)
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	page, err := AttachPage(ctx, b.URL(), nil)
+	if err != nil {
+		t.Fatalf("AttachPage: %v", err)
+	}
+	defer page.Close()
+
+	frames, err := page.Frames(ctx)
+	if err != nil {
+		t.Fatalf("Frames: %v", err)
+	}
+	want := []Frame{
+		{ID: "main", URL: "https://shop.test/checkout"},
+		{ID: "login", ParentID: "main", URL: "https://login.shop.test/frame", Name: "login"},
+		{ID: "pay", ParentID: "main", URL: "https://js.stripe.com/v3/elements", Name: "__privateStripeFrame1"},
+		{ID: "pay-inner", ParentID: "pay", URL: "https://hooks.stripe.com/3ds", Name: "challenge"},
+	}
+	if len(frames) != len(want) {
+		t.Fatalf("expected %d frames, got %+v", len(want), frames)
+	}
+	for i := range want {
+		if frames[i] != want[i] {
+			t.Fatalf("frame %d: expected %+v, got %+v", i, want[i], frames[i])
+		}
+	}
+	if len(detached) != 1 || detached[0] != "session-pay" {
+		t.Fatalf("expected the iframe session to be detached, got %v", detached)
+	}
+
+	frame, err := page.Frame(ctx, FrameMatching("https://js.stripe.com/*"))
+	if err != nil || frame.ID != "pay" {
+		t.Fatalf("expected the payment frame, got %+v, %v", frame, err)
+	}
+	if frame, _ := FindFrame(frames, FrameNamed("challenge")); frame.ID != "pay-inner" {
+		t.Fatalf("expected the frame named challenge, got %+v", frame)
+	}
+
+	ctx, cancel = context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err := page.WaitForFrame(ctx, FrameMatching("paypal.com"), 20*time.Millisecond); !errors.Is(err, ErrNoFrame) {
+		t.Fatalf("expected ErrNoFrame, got %v", err)
+	}
+}
+
+func TestFrameMatching(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, url string
+		want         bool
+	}{
+		{"https://js.stripe.com/*", "https://js.stripe.com/v3/elements", true},
+		{"https://js.stripe.com/*", "https://evil.test/?https://js.stripe.com/", false},
+		{"*.paypal.com/*", "https://www.paypal.com/checkout", true},
+		{"login", "https://login.shop.test/frame", true},
+		{"login", "https://shop.test/", false},
+	} {
+		if got := FrameMatching(tc.pattern)(Frame{URL: tc.url}); got != tc.want {
+			t.Errorf("FrameMatching(%q) on %q = %v, want %v", tc.pattern, tc.url, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Custom code. Not generated by Stainless.
+package cdp
+
+import (
+	"context"
+	"errors"
+
+	"github.com/browserbase/stagehand-go/v3"
+)
+
+// AttachOptions configure [AttachPage] and [AttachSession].
+type AttachOptions struct {
+	Page PageOptions
+	Dial DialOptions
+}
+
+// Page is a connection to a browser attached to one of its pages, usually the
+// page a Stagehand session is driving.
+type Page struct {
+	*Session
+	url string
+}
+
+// AttachPage connects to the browser at cdpURL, waits for the page selected by
+// opts, and attaches to it. opts may be nil. Bound the wait with ctx.
+func AttachPage(ctx context.Context, cdpURL string, opts *AttachOptions) (*Page, error) {
+	if opts == nil {
+		opts = &AttachOptions{}
+	}
+	conn, err := Dial(ctx, cdpURL, &opts.Dial)
+	if err != nil {
+		return nil, err
+	}
+	target, err := conn.WaitForPage(ctx, opts.Page)
+	if err == nil {
+		var session *Session
+		if session, err = conn.Attach(ctx, target); err == nil {
+			return &Page{Session: session, url: EnsurePort(cdpURL)}, nil
+		}
+	}
+	_ = conn.Close()
+	return nil, err
+}
+
+// AttachSession attaches to the page driven by a Stagehand session, using the
+// CDP URL of its start response. opts may be nil.
+func AttachSession(ctx context.Context, data stagehand.SessionStartResponseData, opts *AttachOptions) (*Page, error) {
+	if data.CdpURL == "" {
+		return nil, errors.New("cdp: the session did not report a CDP URL")
+	}
+	return AttachPage(ctx, data.CdpURL, opts)
+}
+
+// Endpoint returns where another CDP library can reach the page.
+func (p *Page) Endpoint() Endpoint {
+	return Endpoint{URL: p.url, TargetID: p.Target.TargetID}
+}
+
+// Close closes the connection. The page and the browser are left running.
+func (p *Page) Close() error {
+	return p.conn.Close()
+}
+
+// Endpoint locates a page for other CDP libraries. With chromedp:
+//
+//	allocCtx, cancel := chromedp.NewRemoteAllocator(ctx, ep.URL, chromedp.NoModifyURL)
+//	browserCtx, cancel := chromedp.NewContext(allocCtx)
+//	tabCtx, cancel := chromedp.NewContext(browserCtx, chromedp.WithTargetID(target.ID(ep.TargetID)))
+//
+// Libraries that take a browser WebSocket URL, such as go-rod or
+// playwright-go's ConnectOverCDP, can use URL and pick the page by TargetID.
+type Endpoint struct {
+	// URL is the browser-level WebSocket URL with an explicit port.
+	URL string
+	// TargetID identifies the page.
+	TargetID string
+}
+
+// ResolveEndpoint finds the page selected by opts in the browser at cdpURL and
+// returns its endpoint. The connection used to find it is closed again. opts
+// may be nil.
+func ResolveEndpoint(ctx context.Context, cdpURL string, opts *AttachOptions) (Endpoint, error) {
+	if opts == nil {
+		opts = &AttachOptions{}
+	}
+	conn, err := Dial(ctx, cdpURL, &opts.Dial)
+	if err != nil {
+		return Endpoint{}, err
+	}
+	defer conn.Close()
+	target, err := conn.WaitForPage(ctx, opts.Page)
+	if err != nil {
+		return Endpoint{}, err
+	}
+	return Endpoint{URL: EnsurePort(cdpURL), TargetID: target.TargetID}, nil
+}
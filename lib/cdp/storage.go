@@ -0,0 +1,412 @@
+// Custom code. Not generated by Stainless.
+package cdp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+	"github.com/browserbase/stagehand-go/v3/option"
+	"github.com/tidwall/gjson"
+)
+
+// StorageState is the cookies and web storage of a browser, in the format of
+// Playwright's storageState, so files can be shared with Playwright. Each
+// origin additionally records its sessionStorage, which Playwright ignores.
+type StorageState struct {
+	Cookies []Cookie      `json:"cookies"`
+	Origins []OriginState `json:"origins"`
+}
+
+// Cookie is a cookie of a [StorageState].
+type Cookie struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Domain string `json:"domain"`
+	Path   string `json:"path"`
+	// Expires is the expiry in seconds since the Unix epoch, or -1 for a
+	// session cookie.
+	Expires  float64 `json:"expires"`
+	HTTPOnly bool    `json:"httpOnly"`
+	Secure   bool    `json:"secure"`
+	// SameSite is "Strict", "Lax" or "None".
+	SameSite string `json:"sameSite"`
+}
+
+// OriginState is the web storage of one origin.
+type OriginState struct {
+	Origin         string        `json:"origin"`
+	LocalStorage   []StorageItem `json:"localStorage"`
+	SessionStorage []StorageItem `json:"sessionStorage,omitempty"`
+}
+
+// StorageItem is a key of localStorage or sessionStorage.
+type StorageItem struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// LoadStorageState reads a storage state file, such as one written by
+// [StorageState.Save] or by Playwright.
+func LoadStorageState(path string) (*StorageState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state StorageState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("cdp: invalid storage state file %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// Save writes the storage state to path. The file holds credentials, so it is
+// only readable by the current user.
+func (s *StorageState) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// StorageState exports the cookies of the session's browser context, and the
+// localStorage and sessionStorage of the origins of its page's frames.
+func (s *Session) StorageState(ctx context.Context) (*StorageState, error) {
+	var cookies struct {
+		Cookies []struct {
+			Name     string  `json:"name"`
+			Value    string  `json:"value"`
+			Domain   string  `json:"domain"`
+			Path     string  `json:"path"`
+			Expires  float64 `json:"expires"`
+			HTTPOnly bool    `json:"httpOnly"`
+			Secure   bool    `json:"secure"`
+			Session  bool    `json:"session"`
+			SameSite string  `json:"sameSite"`
+		} `json:"cookies"`
+	}
+	if err := s.conn.Send(ctx, "Storage.getCookies", s.contextParams(nil), &cookies); err != nil {
+		return nil, err
+	}
+	state := &StorageState{Cookies: []Cookie{}, Origins: []OriginState{}}
+	for _, c := range cookies.Cookies {
+		cookie := Cookie{
+			Name: c.Name, Value: c.Value, Domain: c.Domain, Path: c.Path,
+			Expires: c.Expires, HTTPOnly: c.HTTPOnly, Secure: c.Secure, SameSite: c.SameSite,
+		}
+		if c.Session {
+			cookie.Expires = -1
+		}
+		if cookie.SameSite == "" {
+			cookie.SameSite = "Lax"
+		}
+		state.Cookies = append(state.Cookies, cookie)
+	}
+
+	frames, err := s.Frames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Send(ctx, "DOMStorage.enable", nil, nil); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, f := range frames {
+		origin := webOrigin(f.URL)
+		if origin == "" || seen[origin] {
+			continue
+		}
+		seen[origin] = true
+		local, err := s.domStorageItems(ctx, origin, true)
+		if err != nil {
+			// The frame may have navigated away since it was listed.
+			continue
+		}
+		session, _ := s.domStorageItems(ctx, origin, false)
+		if len(local) > 0 || len(session) > 0 {
+			state.Origins = append(state.Origins, OriginState{Origin: origin, LocalStorage: local, SessionStorage: session})
+		}
+	}
+	return state, nil
+}
+
+// webOrigin returns the origin of an http or https URL, or "" for other URLs.
+func webOrigin(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func (s *Session) domStorageItems(ctx context.Context, origin string, local bool) ([]StorageItem, error) {
+	var res struct {
+		Entries [][]string `json:"entries"`
+	}
+	params := map[string]any{"storageId": map[string]any{"securityOrigin": origin, "isLocalStorage": local}}
+	if err := s.Send(ctx, "DOMStorage.getDOMStorageItems", params, &res); err != nil {
+		return nil, err
+	}
+	items := []StorageItem{}
+	for _, e := range res.Entries {
+		if len(e) == 2 {
+			items = append(items, StorageItem{Name: e[0], Value: e[1]})
+		}
+	}
+	return items, nil
+}
+
+// contextParams adds the session's browser context to params.
+func (s *Session) contextParams(params map[string]any) map[string]any {
+	if params == nil {
+		params = map[string]any{}
+	}
+	if s.Target.BrowserContextID != "" {
+		params["browserContextId"] = s.Target.BrowserContextID
+	}
+	return params
+}
+
+// SetStorageState seeds the session's browser context with the cookies and
+// localStorage of state, and the session's page with the sessionStorage of
+// state, since sessionStorage belongs to a single tab. To seed
+// sessionStorage, the page briefly loads an empty document of each origin and
+// is then navigated back to where it was.
+func (s *Session) SetStorageState(ctx context.Context, state *StorageState) error {
+	if len(state.Cookies) > 0 {
+		cookies := make([]map[string]any, 0, len(state.Cookies))
+		for _, c := range state.Cookies {
+			cookie := map[string]any{
+				"name": c.Name, "value": c.Value, "domain": c.Domain, "path": c.Path,
+				"httpOnly": c.HTTPOnly, "secure": c.Secure,
+			}
+			if c.Expires >= 0 {
+				cookie["expires"] = c.Expires
+			}
+			if c.SameSite != "" {
+				cookie["sameSite"] = c.SameSite
+			}
+			cookies = append(cookies, cookie)
+		}
+		if err := s.conn.Send(ctx, "Storage.setCookies", s.contextParams(map[string]any{"cookies": cookies}), nil); err != nil {
+			return err
+		}
+	}
+
+	for _, o := range state.Origins {
+		if len(o.LocalStorage) > 0 {
+			if err := s.seedLocalStorage(ctx, o.Origin, o.LocalStorage); err != nil {
+				return fmt.Errorf("cdp: failed to seed localStorage of %s: %w", o.Origin, err)
+			}
+		}
+	}
+	return s.seedSessionStorage(ctx, state.Origins)
+}
+
+// seedLocalStorage writes items to the localStorage of origin from a
+// temporary tab.
+func (s *Session) seedLocalStorage(ctx context.Context, origin string, items []StorageItem) error {
+	var created struct {
+		TargetID string `json:"targetId"`
+	}
+	if err := s.conn.Send(ctx, "Target.createTarget", s.contextParams(map[string]any{"url": "about:blank", "background": true}), &created); err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.conn.Send(context.WithoutCancel(ctx), "Target.closeTarget", map[string]any{"targetId": created.TargetID}, nil)
+	}()
+	tab, err := s.conn.Attach(ctx, TargetInfo{TargetID: created.TargetID, Type: "page", BrowserContextID: s.Target.BrowserContextID})
+	if err != nil {
+		return err
+	}
+	return tab.evaluateInOrigin(ctx, origin, fmt.Sprintf(`for (const [k, v] of %s) localStorage.setItem(k, v);`, storageEntries(items)))
+}
+
+// seedSessionStorage writes the sessionStorage of origins in the session's
+// own page, then navigates the page back to the URL it was showing.
+func (s *Session) seedSessionStorage(ctx context.Context, origins []OriginState) error {
+	var seeded bool
+	var current struct {
+		Result struct {
+			Value string `json:"value"`
+		} `json:"result"`
+	}
+	for _, o := range origins {
+		if len(o.SessionStorage) == 0 {
+			continue
+		}
+		if !seeded {
+			if err := s.Send(ctx, "Runtime.evaluate", map[string]any{"expression": "location.href", "returnByValue": true}, &current); err != nil {
+				return err
+			}
+			seeded = true
+		}
+		script := fmt.Sprintf(`for (const [k, v] of %s) sessionStorage.setItem(k, v);`, storageEntries(o.SessionStorage))
+		if err := s.evaluateInOrigin(ctx, o.Origin, script); err != nil {
+			return fmt.Errorf("cdp: failed to seed sessionStorage of %s: %w", o.Origin, err)
+		}
+	}
+	if !seeded {
+		return nil
+	}
+	back := current.Result.Value
+	if back == "" {
+		back = "about:blank"
+	}
+	return s.Send(ctx, "Page.navigate", map[string]any{"url": back}, nil)
+}
+
+// evaluateInOrigin loads an empty document of origin in the session's page
+// and evaluates script in it. The page's requests are answered with the empty
+// document while it loads, so nothing is loaded from the origin itself.
+func (s *Session) evaluateInOrigin(ctx context.Context, origin, script string) error {
+	loaded := make(chan struct{}, 1)
+	defer s.On("Page.loadEventFired", func(Event) {
+		select {
+		case loaded <- struct{}{}:
+		default:
+		}
+	})()
+	defer s.On("Fetch.requestPaused", func(ev Event) {
+		var p struct {
+			RequestID string `json:"requestId"`
+		}
+		_ = json.Unmarshal(ev.Params, &p)
+		_ = s.Send(ctx, "Fetch.fulfillRequest", map[string]any{
+			"requestId":       p.RequestID,
+			"responseCode":    200,
+			"responseHeaders": []map[string]string{{"name": "Content-Type", "value": "text/html"}},
+			"body":            base64.StdEncoding.EncodeToString([]byte("<html></html>")),
+		}, nil)
+	})()
+
+	if err := s.Send(ctx, "Page.enable", nil, nil); err != nil {
+		return err
+	}
+	if err := s.Send(ctx, "Fetch.enable", map[string]any{"patterns": []map[string]string{{"urlPattern": "*"}}}, nil); err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.Send(context.WithoutCancel(ctx), "Fetch.disable", nil, nil)
+	}()
+	if err := s.Send(ctx, "Page.navigate", map[string]any{"url": origin + "/"}, nil); err != nil {
+		return err
+	}
+	select {
+	case <-loaded:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	var eval struct {
+		ExceptionDetails *struct {
+			Text string `json:"text"`
+		} `json:"exceptionDetails"`
+	}
+	if err := s.Send(ctx, "Runtime.evaluate", map[string]any{"expression": script}, &eval); err != nil {
+		return err
+	}
+	if eval.ExceptionDetails != nil {
+		return fmt.Errorf("cdp: %s", eval.ExceptionDetails.Text)
+	}
+	return nil
+}
+
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// storageEntries returns items as a JavaScript array of key-value pairs.
+func storageEntries(items []StorageItem) string {
+	entries := make([][2]string, len(items))
+	for i, item := range items {
+		entries[i] = [2]string{item.Name, item.Value}
+	}
+	data, _ := json.Marshal(entries)
+	return string(data)
+}
+
+// StorageSeedError is returned by a start call made with [WithStorageState]
+// when the session started but could not be seeded. The session is left
+// running.
+type StorageSeedError struct {
+	SessionID string
+	Err       error
+}
+
+func (e *StorageSeedError) Error() string {
+	return fmt.Sprintf("cdp: session %s started but its storage state could not be applied: %v", e.SessionID, e.Err)
+}
+
+func (e *StorageSeedError) Unwrap() error {
+	return e.Err
+}
+
+// WithStorageState returns a RequestOption that seeds sessions started with
+// it with state, for local and Browserbase browsers alike, before the start
+// call returns. It only affects start calls, so it can be given to a single
+// Start call or to the client. The browser is reached through the CDP URL of
+// the start response, or else the one the session was asked to connect to.
+func WithStorageState(state *StorageState) option.RequestOption {
+	return requestconfig.RequestOptionFunc(func(r *requestconfig.RequestConfig) error {
+		if r.Request.Method != http.MethodPost || strings.Trim(r.Request.URL.Path, "/") != "v1/sessions/start" {
+			return nil
+		}
+		r.Middlewares = append(r.Middlewares, func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+			return seedStartedSession(req, next, state)
+		})
+		return nil
+	})
+}
+
+func seedStartedSession(req *http.Request, next option.MiddlewareNext, state *StorageState) (*http.Response, error) {
+	var requested string
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			_ = body.Close()
+			requested = gjson.GetBytes(data, "browser.launchOptions.cdpUrl").String()
+		}
+	}
+
+	res, err := next(req)
+	if err != nil || res.Body == nil || res.StatusCode >= http.StatusMultipleChoices {
+		return res, err
+	}
+	data, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return res, err
+	}
+	id := gjson.GetBytes(data, "data.sessionId").String()
+	cdpURL := gjson.GetBytes(data, "data.cdpUrl").String()
+	if cdpURL == "" {
+		cdpURL = requested
+	}
+	if cdpURL == "" {
+		return res, &StorageSeedError{SessionID: id, Err: fmt.Errorf("no CDP URL known for the session")}
+	}
+
+	page, err := AttachPage(req.Context(), cdpURL, nil)
+	if err == nil {
+		err = page.SetStorageState(req.Context(), state)
+		_ = page.Close()
+	}
+	if err != nil {
+		// The start response is returned too, so that the session is tracked
+		// and the start call is not retried.
+		return res, &StorageSeedError{SessionID: id, Err: err}
+	}
+	return res, nil
+}
@@ -0,0 +1,367 @@
+// Custom tests. Not generated by Stainless.
+package cdp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+func TestSession_StorageState(t *testing.T) {
+	b := newFakeBrowser(t)
+	b.withPages(TargetInfo{TargetID: "main", Type: "page", URL: "https://shop.test/account", BrowserContextID: "ctx-1"})
+	b.handle("Storage.getCookies", func(_ string, params json.RawMessage) (any, *Error) {
+		if !strings.Contains(string(params), `"browserContextId":"ctx-1"`) {
+			return nil, &Error{Code: -32000, Message: "expected the page's browser context"}
+		}
+		return map[string]any{"cookies": []map[string]any{
+			{"name": "sid", "value": "abc", "domain": "shop.test", "path": "/", "expires": -1, "httpOnly": true, "secure": true, "session": true, "sameSite": "Strict"},
+			{"name": "theme", "value": "dark", "domain": ".shop.test", "path": "/", "expires": 1.9e9, "session": false},
+		}}, nil
+	})
+	b.handle("Page.getFrameTree", func(string, json.RawMessage) (any, *Error) {
+		return map[string]any{"frameTree": frameNode("main", "https://shop.test/account", "",
+			frameNode("ad", "about:blank", ""),
+			frameNode("other", "https://shop.test/widget", ""),
+		)}, nil
+	})
+	b.handle("DOMStorage.enable", func(string, json.RawMessage) (any, *Error) { return nil, nil })
+	b.handle("DOMStorage.getDOMStorageItems", func(_ string, params json.RawMessage) (any, *Error) {
+		var p struct {
+			StorageID struct {
+				SecurityOrigin string `json:"securityOrigin"`
+				IsLocalStorage bool   `json:"isLocalStorage"`
+			} `json:"storageId"`
+		}
+		_ = json.Unmarshal(params, &p)
+		if p.StorageID.SecurityOrigin != "https://shop.test" {
+			return nil, &Error{Code: -32000, Message: "unexpected origin " + p.StorageID.SecurityOrigin}
+		}
+		if p.StorageID.IsLocalStorage {
+			return map[string]any{"entries": [][]string{{"token", "t-1"}}}, nil
+		}
+		return map[string]any{"entries": [][]string{{"step", "2"}}}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	page, err := AttachPage(ctx, b.URL(), nil)
+	if err != nil {
+		t.Fatalf("AttachPage: %v", err)
+	}
+	defer page.Close()
+
+	state, err := page.StorageState(ctx)
+	if err != nil {
+		t.Fatalf("StorageState: %v", err)
+	}
+	if len(state.Cookies) != 2 || state.Cookies[0].Expires != -1 || state.Cookies[0].SameSite != "Strict" || state.Cookies[1].SameSite != "Lax" {
+		t.Fatalf("unexpected cookies %+v", state.Cookies)
+	}
+	want := []OriginState{{
+		Origin:         "https://shop.test",
+		LocalStorage:   []StorageItem{{Name: "token", Value: "t-1"}},
+		SessionStorage: []StorageItem{{Name: "step", Value: "2"}},
+	}}
+	got, _ := json.Marshal(state.Origins)
+	wantJSON, _ := json.Marshal(want)
+	if string(got) != string(wantJSON) {
+		t.Fatalf("expected origins %s, got %s", wantJSON, got)
+	}
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := state.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := LoadStorageState(path)
+	if err != nil {
+		t.Fatalf("LoadStorageState: %v", err)
+	}
+	if len(loaded.Cookies) != 2 || loaded.Cookies[0].HTTPOnly != true || loaded.Origins[0].LocalStorage[0].Value != "t-1" {
+		t.Fatalf("unexpected loaded state %+v", loaded)
+	}
+}
+
+// seedBrowser is a browser that records how a storage state is applied. It
+// keeps the URL and the sessionStorage of each tab, the way a browser does,
+// so that sessionStorage can be read back from a later connection.
+type seedBrowser struct {
+	*fakeBrowser
+
+	mu       sync.Mutex
+	cookies  []map[string]any
+	scripts  map[string][]string
+	closed   []string
+	fulfills int
+	fetching map[string]bool
+	urls     map[string]string
+	session  map[string]map[string][][]string
+}
+
+func newSeedBrowser(t *testing.T) *seedBrowser {
+	b := &seedBrowser{
+		fakeBrowser: newFakeBrowser(t),
+		scripts:     map[string][]string{},
+		fetching:    map[string]bool{},
+		urls:        map[string]string{"main": "about:blank"},
+		session:     map[string]map[string][][]string{},
+	}
+	target := func(sessionID string) string { return strings.TrimPrefix(sessionID, "session-") }
+	b.withPages(TargetInfo{TargetID: "main", Type: "page", URL: "about:blank", Attached: true})
+	b.handle("Storage.setCookies", func(_ string, params json.RawMessage) (any, *Error) {
+		var p struct {
+			Cookies []map[string]any `json:"cookies"`
+		}
+		_ = json.Unmarshal(params, &p)
+		b.mu.Lock()
+		b.cookies = append(b.cookies, p.Cookies...)
+		b.mu.Unlock()
+		return nil, nil
+	})
+	b.handle("Target.createTarget", func(string, json.RawMessage) (any, *Error) {
+		return map[string]string{"targetId": "seed"}, nil
+	})
+	b.handle("Target.closeTarget", func(_ string, params json.RawMessage) (any, *Error) {
+		var p struct {
+			TargetID string `json:"targetId"`
+		}
+		_ = json.Unmarshal(params, &p)
+		b.mu.Lock()
+		b.closed = append(b.closed, p.TargetID)
+		b.mu.Unlock()
+		return nil, nil
+	})
+	b.handle("Page.enable", func(string, json.RawMessage) (any, *Error) { return nil, nil })
+	for method, enabled := range map[string]bool{"Fetch.enable": true, "Fetch.disable": false} {
+		b.handle(method, func(sessionID string, _ json.RawMessage) (any, *Error) {
+			b.mu.Lock()
+			b.fetching[sessionID] = enabled
+			b.mu.Unlock()
+			return nil, nil
+		})
+	}
+	b.handle("Page.navigate", func(sessionID string, params json.RawMessage) (any, *Error) {
+		var p struct {
+			URL string `json:"url"`
+		}
+		_ = json.Unmarshal(params, &p)
+		b.mu.Lock()
+		b.urls[target(sessionID)] = p.URL
+		fetching := b.fetching[sessionID]
+		b.mu.Unlock()
+		if fetching {
+			b.emit(sessionID, "Fetch.requestPaused", map[string]string{"requestId": "r-1"})
+		}
+		return map[string]string{"frameId": target(sessionID)}, nil
+	})
+	b.handle("Fetch.fulfillRequest", func(sessionID string, _ json.RawMessage) (any, *Error) {
+		b.mu.Lock()
+		b.fulfills++
+		b.mu.Unlock()
+		b.emit(sessionID, "Page.loadEventFired", map[string]float64{"timestamp": 1})
+		return nil, nil
+	})
+	b.handle("Runtime.evaluate", func(sessionID string, params json.RawMessage) (any, *Error) {
+		var p struct {
+			Expression string `json:"expression"`
+		}
+		_ = json.Unmarshal(params, &p)
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		url := b.urls[target(sessionID)]
+		if p.Expression == "location.href" {
+			return map[string]any{"result": map[string]any{"type": "string", "value": url}}, nil
+		}
+		b.scripts[sessionID] = append(b.scripts[sessionID], p.Expression)
+		if entries, ok := strings.CutSuffix(p.Expression, ") sessionStorage.setItem(k, v);"); ok {
+			var items [][]string
+			_ = json.Unmarshal([]byte(strings.TrimPrefix(entries, "for (const [k, v] of ")), &items)
+			origin := webOrigin(url)
+			if b.session[target(sessionID)] == nil {
+				b.session[target(sessionID)] = map[string][][]string{}
+			}
+			b.session[target(sessionID)][origin] = append(b.session[target(sessionID)][origin], items...)
+		}
+		return map[string]any{"result": map[string]any{"type": "undefined"}}, nil
+	})
+	b.handle("DOMStorage.getDOMStorageItems", func(sessionID string, params json.RawMessage) (any, *Error) {
+		var p struct {
+			StorageID struct {
+				SecurityOrigin string `json:"securityOrigin"`
+				IsLocalStorage bool   `json:"isLocalStorage"`
+			} `json:"storageId"`
+		}
+		_ = json.Unmarshal(params, &p)
+		if p.StorageID.IsLocalStorage {
+			return nil, &Error{Code: -32000, Message: "localStorage is not tracked"}
+		}
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		entries := b.session[target(sessionID)][p.StorageID.SecurityOrigin]
+		if entries == nil {
+			entries = [][]string{}
+		}
+		return map[string]any{"entries": entries}, nil
+	})
+	return b
+}
+
+var testState = &StorageState{
+	Cookies: []Cookie{{Name: "sid", Value: "abc", Domain: "shop.test", Path: "/", Expires: -1, HTTPOnly: true, SameSite: "Lax"}},
+	Origins: []OriginState{{
+		Origin:         "https://shop.test",
+		LocalStorage:   []StorageItem{{Name: "token", Value: "t-1"}},
+		SessionStorage: []StorageItem{{Name: "step", Value: "2"}},
+	}},
+}
+
+func TestSession_SetStorageState(t *testing.T) {
+	b := newSeedBrowser(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	page, err := AttachPage(ctx, b.URL(), nil)
+	if err != nil {
+		t.Fatalf("AttachPage: %v", err)
+	}
+	defer page.Close()
+
+	if err := page.SetStorageState(ctx, testState); err != nil {
+		t.Fatalf("SetStorageState: %v", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.cookies) != 1 || b.cookies[0]["name"] != "sid" || b.cookies[0]["expires"] != nil {
+		t.Fatalf("expected a session cookie, got %v", b.cookies)
+	}
+	if b.fulfills != 2 || len(b.closed) != 1 || b.closed[0] != "seed" {
+		t.Fatalf("expected the seeding tab to be served and closed, got %d fulfills, closed %v", b.fulfills, b.closed)
+	}
+	if s := b.scripts["session-seed"]; len(s) != 1 || !strings.Contains(s[0], `localStorage.setItem`) || !strings.Contains(s[0], `["token","t-1"]`) {
+		t.Fatalf("unexpected localStorage script %v", s)
+	}
+	if s := b.scripts["session-main"]; len(s) != 1 || !strings.Contains(s[0], `sessionStorage.setItem`) || !strings.Contains(s[0], `["step","2"]`) {
+		t.Fatalf("unexpected sessionStorage script %v", s)
+	}
+	if b.urls["main"] != "about:blank" || b.fetching["session-main"] {
+		t.Fatalf("expected the page to be restored, got %q with interception %v", b.urls["main"], b.fetching["session-main"])
+	}
+}
+
+func TestSession_SetStorageState_SessionStorageOutlivesConnection(t *testing.T) {
+	b := newSeedBrowser(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	page, err := AttachPage(ctx, b.URL(), nil)
+	if err != nil {
+		t.Fatalf("AttachPage: %v", err)
+	}
+	if err := page.SetStorageState(ctx, testState); err != nil {
+		t.Fatalf("SetStorageState: %v", err)
+	}
+	_ = page.Close()
+
+	page, err = AttachPage(ctx, b.URL(), nil)
+	if err != nil {
+		t.Fatalf("AttachPage: %v", err)
+	}
+	defer page.Close()
+	items, err := page.domStorageItems(ctx, "https://shop.test", false)
+	if err != nil {
+		t.Fatalf("domStorageItems: %v", err)
+	}
+	if len(items) != 1 || items[0] != (StorageItem{Name: "step", Value: "2"}) {
+		t.Fatalf("expected the seeded sessionStorage, got %+v", items)
+	}
+}
+
+func TestWithStorageState(t *testing.T) {
+	b := newSeedBrowser(t)
+	cdpURL := b.URL()
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://api.stagehand.test/"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(0),
+		option.WithHTTPClient(&http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if strings.HasSuffix(req.URL.Path, "/start") {
+				return jsonResponse(http.StatusOK, `{"success":true,"data":{"available":true,"sessionId":"session","cdpUrl":"`+cdpURL+`"}}`), nil
+			}
+			return jsonResponse(http.StatusOK, `{"success":true,"data":{}}`), nil
+		})}),
+	)
+	defer client.Close()
+
+	ctx := context.Background()
+	res, err := client.Sessions.Start(ctx, stagehand.SessionStartParams{ModelName: "openai/gpt-5.4-mini"}, WithStorageState(testState))
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if res.Data.SessionID != "session" {
+		t.Fatalf("unexpected response %+v", res.Data)
+	}
+	b.mu.Lock()
+	seeded := len(b.cookies) == 1
+	b.mu.Unlock()
+	if !seeded {
+		t.Fatalf("expected the session to be seeded")
+	}
+
+	cdpURL = "ws://127.0.0.1:1/devtools/browser/gone"
+	_, err = client.Sessions.Start(ctx, stagehand.SessionStartParams{ModelName: "openai/gpt-5.4-mini"}, WithStorageState(testState))
+	var seedErr *StorageSeedError
+	if !errors.As(err, &seedErr) || seedErr.SessionID != "session" {
+		t.Fatalf("expected a StorageSeedError, got %v", err)
+	}
+}
+
+func TestWithStorageState_SeedFailureIsNotRetried(t *testing.T) {
+	var mu sync.Mutex
+	var starts int
+	var ended []string
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://api.stagehand.test/"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(2),
+		option.WithHTTPClient(&http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case strings.HasSuffix(req.URL.Path, "/start"):
+				starts++
+				return jsonResponse(http.StatusOK, `{"success":true,"data":{"available":true,"sessionId":"session","cdpUrl":"ws://127.0.0.1:1/devtools/browser/gone"}}`), nil
+			case strings.HasSuffix(req.URL.Path, "/end"):
+				ended = append(ended, req.URL.Path)
+			}
+			return jsonResponse(http.StatusOK, `{"success":true,"data":{}}`), nil
+		})}),
+	)
+
+	_, err := client.Sessions.Start(context.Background(), stagehand.SessionStartParams{ModelName: "openai/gpt-5.4-mini"}, WithStorageState(testState))
+	var seedErr *StorageSeedError
+	if !errors.As(err, &seedErr) || seedErr.SessionID != "session" {
+		t.Fatalf("expected a StorageSeedError, got %v", err)
+	}
+	if starts != 1 {
+		t.Fatalf("expected a single start request, got %d", starts)
+	}
+	if open := client.OpenSessions(); len(open) != 1 || open[0] != "session" {
+		t.Fatalf("expected the started session to be tracked, got %v", open)
+	}
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ended) != 1 || !strings.Contains(ended[0], "/session/") {
+		t.Fatalf("expected the session to be ended on shutdown, got %v", ended)
+	}
+}
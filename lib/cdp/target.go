@@ -0,0 +1,148 @@
+// Custom code. Not generated by Stainless.
+package cdp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TargetInfo describes a browser target, as returned by Target.getTargets.
+type TargetInfo struct {
+	TargetID         string `json:"targetId"`
+	Type             string `json:"type"`
+	Title            string `json:"title"`
+	URL              string `json:"url"`
+	Attached         bool   `json:"attached"`
+	BrowserContextID string `json:"browserContextId,omitempty"`
+}
+
+// Targets returns the browser's targets.
+func (c *Conn) Targets(ctx context.Context) ([]TargetInfo, error) {
+	var res struct {
+		TargetInfos []TargetInfo `json:"targetInfos"`
+	}
+	if err := c.Send(ctx, "Target.getTargets", nil, &res); err != nil {
+		return nil, err
+	}
+	return res.TargetInfos, nil
+}
+
+// PageOptions select the page target to attach to.
+type PageOptions struct {
+	// URLContains, if set, only matches pages whose URL contains it.
+	URLContains string
+	// Match, if set, only matches pages it returns true for.
+	Match func(TargetInfo) bool
+	// PollInterval is how often targets are listed while waiting for a
+	// matching page. Defaults to 200 milliseconds.
+	PollInterval time.Duration
+}
+
+// ErrNoPage is returned by [FindPage] when no page target matches.
+var ErrNoPage = errors.New("cdp: no matching page target")
+
+// FindPage returns the page Stagehand is driving among targets: the first
+// matching page that another client, such as Stagehand, is attached to, or
+// else the first matching page.
+func FindPage(targets []TargetInfo, opts PageOptions) (TargetInfo, error) {
+	var found *TargetInfo
+	for i, t := range targets {
+		if t.Type != "page" {
+			continue
+		}
+		if opts.URLContains != "" && !strings.Contains(t.URL, opts.URLContains) {
+			continue
+		}
+		if opts.Match != nil && !opts.Match(t) {
+			continue
+		}
+		if t.Attached {
+			return t, nil
+		}
+		if found == nil {
+			found = &targets[i]
+		}
+	}
+	if found == nil {
+		return TargetInfo{}, ErrNoPage
+	}
+	return *found, nil
+}
+
+// WaitForPage lists the browser's targets until [FindPage] finds one, or ctx
+// is done.
+func (c *Conn) WaitForPage(ctx context.Context, opts PageOptions) (TargetInfo, error) {
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = 200 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		targets, err := c.Targets(ctx)
+		if err != nil && ctx.Err() == nil {
+			return TargetInfo{}, err
+		}
+		if err == nil {
+			if page, err := FindPage(targets, opts); err == nil {
+				return page, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if opts.URLContains != "" {
+				return TargetInfo{}, fmt.Errorf("cdp: no page target with URL containing %q: %w", opts.URLContains, ctx.Err())
+			}
+			return TargetInfo{}, fmt.Errorf("%w: %w", ErrNoPage, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Session is a flattened session attached to a single target. Commands sent
+// through it run against that target.
+type Session struct {
+	conn *Conn
+	// ID is the CDP session ID.
+	ID string
+	// Target is the target the session is attached to.
+	Target TargetInfo
+}
+
+// Attach attaches to target in flattened mode, so its commands and events go
+// through this connection.
+func (c *Conn) Attach(ctx context.Context, target TargetInfo) (*Session, error) {
+	var res struct {
+		SessionID string `json:"sessionId"`
+	}
+	params := map[string]any{"targetId": target.TargetID, "flatten": true}
+	if err := c.Send(ctx, "Target.attachToTarget", params, &res); err != nil {
+		return nil, err
+	}
+	return &Session{conn: c, ID: res.SessionID, Target: target}, nil
+}
+
+// Conn returns the connection the session belongs to.
+func (s *Session) Conn() *Conn {
+	return s.conn
+}
+
+// Send runs a command on the session's target and decodes its result into
+// result, which may be nil.
+func (s *Session) Send(ctx context.Context, method string, params, result any) error {
+	return s.conn.send(ctx, s.ID, method, params, result)
+}
+
+// On calls fn for every event of the session's target named method, or for
+// every event if method is empty. See [Conn.On].
+func (s *Session) On(method string, fn func(Event)) (remove func()) {
+	return s.conn.on(handler{method: method, sessionID: s.ID, fn: fn})
+}
+
+// Detach detaches from the target, leaving the connection open.
+func (s *Session) Detach(ctx context.Context) error {
+	return s.conn.Send(ctx, "Target.detachFromTarget", map[string]any{"sessionId": s.ID}, nil)
+}
@@ -0,0 +1,244 @@
+// Custom code. Not generated by Stainless.
+package cdp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/browserbase/stagehand-go/v3"
+)
+
+// UploadFile is a local file to give to a page.
+type UploadFile struct {
+	// Name is the file's name as the page sees it. Defaults to the base name
+	// of Path.
+	Name string
+	// Path is the file on this machine. It is ignored if Content is set.
+	Path string
+	// Content, if set, is the file's content.
+	Content io.Reader
+}
+
+// UploadParams configure [ActUpload].
+type UploadParams struct {
+	// Instruction describes the file input or the control that opens it,
+	// such as "the resume upload field".
+	Instruction string
+	Files       []UploadFile
+	// FrameID is the frame to look for the input in, if not the main one.
+	FrameID string
+}
+
+// FileInput locates a file input element by its backend node ID, or else by
+// a selector, which is an XPath if it starts with "/" or "xpath=", and a CSS
+// selector otherwise. An element that is not a file input itself stands for
+// the file input it labels or contains.
+type FileInput struct {
+	BackendNodeID int
+	Selector      string
+	// FrameID is the frame to evaluate the selector in. Defaults to the main
+	// frame.
+	FrameID string
+}
+
+// ActUpload sets files on the file input an instruction describes. The input
+// is found with an observe call on the session, and the files are sent to
+// the browser if it runs on another machine, through the client's uploads
+// service. It returns the element the instruction resolved to.
+func ActUpload(ctx context.Context, client *stagehand.Client, page *Page, sessionID string, params UploadParams) (*stagehand.SessionObserveResponseDataResult, error) {
+	if len(params.Files) == 0 {
+		return nil, errors.New("cdp: no files to upload")
+	}
+	observeParams := stagehand.SessionObserveParams{Instruction: stagehand.String(params.Instruction)}
+	if params.FrameID != "" {
+		observeParams.FrameID = stagehand.String(params.FrameID)
+	}
+	observed, err := client.Sessions.Observe(ctx, sessionID, observeParams)
+	if err != nil {
+		return nil, err
+	}
+	if len(observed.Data.Result) == 0 {
+		return nil, fmt.Errorf("cdp: no element found for %q", params.Instruction)
+	}
+	element := observed.Data.Result[0]
+
+	paths, err := transferFiles(ctx, client, page, sessionID, params.Files)
+	if err != nil {
+		return nil, err
+	}
+	input := FileInput{BackendNodeID: int(element.BackendNodeID), Selector: element.Selector, FrameID: params.FrameID}
+	if err := page.SetInputFiles(ctx, input, paths); err != nil {
+		return nil, err
+	}
+	return &element, nil
+}
+
+// transferFiles makes files available to the page's browser and returns their
+// paths there. Contents given as readers are written to a temporary
+// directory for local browsers, which is left in place since the page may
+// read the files at any later point.
+func transferFiles(ctx context.Context, client *stagehand.Client, page *Page, sessionID string, files []UploadFile) ([]string, error) {
+	remote := !isLocalURL(page.url)
+	var tmpDir string
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		name := f.Name
+		if name == "" {
+			name = filepath.Base(f.Path)
+		}
+		if f.Content == nil && f.Path == "" {
+			return nil, fmt.Errorf("cdp: file %q has neither a path nor content", name)
+		}
+
+		switch {
+		case remote:
+			content := f.Content
+			if content == nil {
+				file, err := os.Open(f.Path)
+				if err != nil {
+					return nil, err
+				}
+				defer file.Close()
+				content = file
+			}
+			path, err := client.Uploads.New(ctx, sessionID, name, content)
+			if err != nil {
+				return nil, fmt.Errorf("cdp: failed to upload %s: %w", name, err)
+			}
+			paths = append(paths, path)
+		case f.Content == nil:
+			path, err := filepath.Abs(f.Path)
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, path)
+		default:
+			if tmpDir == "" {
+				dir, err := os.MkdirTemp("", "stagehand-uploads-")
+				if err != nil {
+					return nil, err
+				}
+				tmpDir = dir
+			}
+			path := filepath.Join(tmpDir, filepath.Base(name))
+			if err := writeFile(path, f.Content); err != nil {
+				return nil, err
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+func writeFile(path string, content io.Reader) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// findFileInput returns the file input an element stands for: itself, the
+// control it labels, or a file input inside it or next to it.
+const findFileInput = `function() {
+	const isFileInput = (el) => el instanceof HTMLInputElement && el.type === "file";
+	if (isFileInput(this)) return this;
+	if (isFileInput(this.control)) return this.control;
+	const inside = this.querySelector && this.querySelector("input[type=file]");
+	if (inside) return inside;
+	return this.parentElement ? this.parentElement.querySelector("input[type=file]") : null;
+}`
+
+// SetInputFiles sets the files of a file input. paths are on the browser's
+// machine.
+func (s *Session) SetInputFiles(ctx context.Context, input FileInput, paths []string) error {
+	objectID, err := s.resolveElement(ctx, input)
+	if err != nil {
+		return err
+	}
+	var found struct {
+		Result struct {
+			ObjectID string `json:"objectId"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text string `json:"text"`
+		} `json:"exceptionDetails"`
+	}
+	err = s.Send(ctx, "Runtime.callFunctionOn", map[string]any{"objectId": objectID, "functionDeclaration": findFileInput}, &found)
+	if err != nil {
+		return err
+	}
+	if found.ExceptionDetails != nil {
+		return fmt.Errorf("cdp: %s", found.ExceptionDetails.Text)
+	}
+	if found.Result.ObjectID == "" {
+		return errors.New("cdp: the element is not a file input and has none")
+	}
+	return s.Send(ctx, "DOM.setFileInputFiles", map[string]any{"files": paths, "objectId": found.Result.ObjectID}, nil)
+}
+
+// resolveElement returns a remote object ID for the element.
+func (s *Session) resolveElement(ctx context.Context, input FileInput) (string, error) {
+	if input.BackendNodeID != 0 {
+		var resolved struct {
+			Object struct {
+				ObjectID string `json:"objectId"`
+			} `json:"object"`
+		}
+		err := s.Send(ctx, "DOM.resolveNode", map[string]any{"backendNodeId": input.BackendNodeID}, &resolved)
+		if err == nil && resolved.Object.ObjectID != "" {
+			return resolved.Object.ObjectID, nil
+		}
+		if input.Selector == "" {
+			return "", fmt.Errorf("cdp: failed to resolve node %d: %w", input.BackendNodeID, err)
+		}
+	}
+	if input.Selector == "" {
+		return "", errors.New("cdp: no element given")
+	}
+
+	expression := fmt.Sprintf("document.querySelector(%s)", jsonString(input.Selector))
+	if xpath, ok := strings.CutPrefix(input.Selector, "xpath="); ok || strings.HasPrefix(input.Selector, "/") {
+		if !ok {
+			xpath = input.Selector
+		}
+		expression = fmt.Sprintf("document.evaluate(%s, document, null, XPathResult.FIRST_ORDERED_NODE_TYPE, null).singleNodeValue", jsonString(xpath))
+	}
+	params := map[string]any{"expression": expression}
+	if input.FrameID != "" {
+		var world struct {
+			ExecutionContextID int `json:"executionContextId"`
+		}
+		if err := s.Send(ctx, "Page.createIsolatedWorld", map[string]any{"frameId": input.FrameID}, &world); err != nil {
+			return "", err
+		}
+		params["contextId"] = world.ExecutionContextID
+	}
+	var eval struct {
+		Result struct {
+			ObjectID string `json:"objectId"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text string `json:"text"`
+		} `json:"exceptionDetails"`
+	}
+	if err := s.Send(ctx, "Runtime.evaluate", params, &eval); err != nil {
+		return "", err
+	}
+	if eval.ExceptionDetails != nil {
+		return "", fmt.Errorf("cdp: invalid selector %q: %s", input.Selector, eval.ExceptionDetails.Text)
+	}
+	if eval.Result.ObjectID == "" {
+		return "", fmt.Errorf("cdp: no element matches %q", input.Selector)
+	}
+	return eval.Result.ObjectID, nil
+}
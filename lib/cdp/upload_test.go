@@ -0,0 +1,181 @@
+// Custom tests. Not generated by Stainless.
+package cdp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+// newUploadBrowser returns a browser whose page has a file input behind node
+// 42, and records the files set on it.
+func newUploadBrowser(t *testing.T) (*fakeBrowser, *[]string) {
+	b := newFakeBrowser(t)
+	b.withPages(TargetInfo{TargetID: "main", Type: "page", URL: "https://onboarding.test/", Attached: true})
+	b.handle("DOM.resolveNode", func(_ string, params json.RawMessage) (any, *Error) {
+		if !strings.Contains(string(params), `"backendNodeId":42`) {
+			return nil, &Error{Code: -32000, Message: "No node with given id found"}
+		}
+		return map[string]any{"object": map[string]string{"objectId": "button"}}, nil
+	})
+	b.handle("Runtime.callFunctionOn", func(_ string, params json.RawMessage) (any, *Error) {
+		var p struct {
+			ObjectID string `json:"objectId"`
+		}
+		_ = json.Unmarshal(params, &p)
+		if p.ObjectID != "button" {
+			return nil, &Error{Code: -32000, Message: "unexpected object " + p.ObjectID}
+		}
+		return map[string]any{"result": map[string]string{"type": "object", "objectId": "input"}}, nil
+	})
+	var files []string
+	b.handle("DOM.setFileInputFiles", func(_ string, params json.RawMessage) (any, *Error) {
+		var p struct {
+			Files    []string `json:"files"`
+			ObjectID string   `json:"objectId"`
+		}
+		_ = json.Unmarshal(params, &p)
+		if p.ObjectID != "input" {
+			return nil, &Error{Code: -32000, Message: "not a file input"}
+		}
+		files = p.Files
+		return nil, nil
+	})
+	return b, &files
+}
+
+func newUploadClient(t *testing.T, uploads map[string]string) *stagehand.Client {
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithBrowserbaseAPIURL("http://browserbase.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(0),
+		option.WithHTTPClient(&http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			switch {
+			case strings.HasSuffix(req.URL.Path, "/observe"):
+				return jsonResponse(http.StatusOK, `{"success":true,"data":{"result":[{"description":"Upload resume button","selector":"xpath=/html/body/button","backendNodeId":42,"method":"click"}]}}`), nil
+			case req.URL.Host == "browserbase.test" && strings.HasSuffix(req.URL.Path, "/session/uploads"):
+				_, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+				form, err := multipart.NewReader(req.Body, params["boundary"]).ReadForm(1 << 20)
+				if err != nil {
+					t.Errorf("invalid upload: %v", err)
+					return jsonResponse(http.StatusBadRequest, `{}`), nil
+				}
+				f, _ := form.File["file"][0].Open()
+				data, _ := io.ReadAll(f)
+				uploads[form.File["file"][0].Filename] = string(data)
+				return jsonResponse(http.StatusOK, `{"message":"File uploaded successfully"}`), nil
+			}
+			return jsonResponse(http.StatusNotFound, `{}`), nil
+		})}),
+	)
+	return &client
+}
+
+func TestActUpload_Local(t *testing.T) {
+	b, files := newUploadBrowser(t)
+	client := newUploadClient(t, map[string]string{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	page, err := AttachPage(ctx, b.URL(), nil)
+	if err != nil {
+		t.Fatalf("AttachPage: %v", err)
+	}
+	defer page.Close()
+
+	resume := filepath.Join(t.TempDir(), "resume.pdf")
+	if err := os.WriteFile(resume, []byte("%PDF"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	element, err := ActUpload(ctx, client, page, "session", UploadParams{
+		Instruction: "the resume upload field",
+		Files: []UploadFile{
+			{Path: resume},
+			{Name: "cover.txt", Content: strings.NewReader("Hello")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ActUpload: %v", err)
+	}
+	if element.Description != "Upload resume button" {
+		t.Fatalf("unexpected element %+v", element)
+	}
+	if len(*files) != 2 || (*files)[0] != resume || filepath.Base((*files)[1]) != "cover.txt" {
+		t.Fatalf("unexpected files %v", *files)
+	}
+	if data, err := os.ReadFile((*files)[1]); err != nil || string(data) != "Hello" {
+		t.Fatalf("unexpected content of the written file: %q, %v", data, err)
+	}
+	_ = os.RemoveAll(filepath.Dir((*files)[1]))
+}
+
+func TestActUpload_Remote(t *testing.T) {
+	b, files := newUploadBrowser(t)
+	uploads := map[string]string{}
+	client := newUploadClient(t, uploads)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	page, err := AttachPage(ctx, b.URL(), nil)
+	if err != nil {
+		t.Fatalf("AttachPage: %v", err)
+	}
+	defer page.Close()
+	page.url = "wss://connect.browserbase.test:443/?signingKey=key"
+
+	_, err = ActUpload(ctx, client, page, "session", UploadParams{
+		Instruction: "the resume upload field",
+		Files:       []UploadFile{{Name: "resume.pdf", Content: strings.NewReader("%PDF")}},
+	})
+	if err != nil {
+		t.Fatalf("ActUpload: %v", err)
+	}
+	if uploads["resume.pdf"] != "%PDF" {
+		t.Fatalf("expected the file to be uploaded, got %v", uploads)
+	}
+	if len(*files) != 1 || (*files)[0] != "/tmp/.uploads/resume.pdf" {
+		t.Fatalf("expected the uploaded file to be set, got %v", *files)
+	}
+}
+
+func TestSession_SetInputFilesBySelector(t *testing.T) {
+	b, files := newUploadBrowser(t)
+	var expressions []string
+	b.handle("Runtime.evaluate", func(_ string, params json.RawMessage) (any, *Error) {
+		var p struct {
+			Expression string `json:"expression"`
+		}
+		_ = json.Unmarshal(params, &p)
+		expressions = append(expressions, p.Expression)
+		return map[string]any{"result": map[string]string{"type": "object", "objectId": "button"}}, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	page, err := AttachPage(ctx, b.URL(), nil)
+	if err != nil {
+		t.Fatalf("AttachPage: %v", err)
+	}
+	defer page.Close()
+
+	for _, selector := range []string{"xpath=/html/body/button", "#upload"} {
+		if err := page.SetInputFiles(ctx, FileInput{Selector: selector}, []string{"/data/a.csv"}); err != nil {
+			t.Fatalf("SetInputFiles(%q): %v", selector, err)
+		}
+	}
+	if len(expressions) != 2 || !strings.HasPrefix(expressions[0], `document.evaluate("/html/body/button"`) || expressions[1] != `document.querySelector("#upload")` {
+		t.Fatalf("unexpected expressions %v", expressions)
+	}
+	if len(*files) != 1 || (*files)[0] != "/data/a.csv" {
+		t.Fatalf("unexpected files %v", *files)
+	}
+}
@@ -0,0 +1,270 @@
+// Custom code. Not generated by Stainless.
+package cdp
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// websocketGUID is appended to the handshake key to compute the accept value,
+// see RFC 6455 section 1.3.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxMessageSize bounds a single message. Screenshots of large pages are the
+// biggest CDP messages and stay well below it.
+const maxMessageSize = 1 << 30
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// wsConn is the client side of a WebSocket connection, implementing the subset
+// of RFC 6455 the DevTools protocol needs: unfragmented writes of text
+// messages and reads of possibly fragmented messages.
+type wsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+
+	writeMu sync.Mutex
+}
+
+// dialWebSocket opens a WebSocket connection to rawURL, a ws or wss URL.
+func dialWebSocket(ctx context.Context, rawURL string, opts DialOptions) (*wsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	var secure bool
+	switch u.Scheme {
+	case "ws":
+	case "wss":
+		secure = true
+	default:
+		return nil, fmt.Errorf("cdp: unsupported URL scheme %q", u.Scheme)
+	}
+	host := EnsurePort(rawURL)
+	if hu, err := url.Parse(host); err == nil {
+		host = hu.Host
+	}
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	if secure {
+		cfg := opts.TLSConfig.Clone()
+		if cfg == nil {
+			cfg = &tls.Config{}
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = u.Hostname()
+		}
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	// Bound the handshake by ctx; the connection itself outlives it.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	ws, err := handshake(conn, u, opts.Header)
+	if !stop() {
+		if err == nil {
+			err = ctx.Err()
+		}
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ws, nil
+}
+
+func handshake(conn net.Conn, u *url.URL, header http.Header) (*wsConn, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        &url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery},
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Host:       u.Host,
+	}
+	if req.URL.Path == "" {
+		req.URL.Path = "/"
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		res.Body.Close()
+		return nil, fmt.Errorf("cdp: websocket handshake failed: %s %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	if res.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		return nil, errors.New("cdp: websocket handshake returned an invalid accept key")
+	}
+	return &wsConn{conn: conn, br: br}, nil
+}
+
+// writeFrame writes a single masked frame, as required of clients.
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	header := make([]byte, 0, 14)
+	header = append(header, 0x80|opcode)
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, 0x80|byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 0x80|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 0x80|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	header = append(header, mask[:]...)
+
+	masked := make([]byte, len(header)+len(payload))
+	copy(masked, header)
+	for i, b := range payload {
+		masked[len(header)+i] = b ^ mask[i%4]
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.conn.Write(masked)
+	return err
+}
+
+// WriteMessage sends a text message.
+func (c *wsConn) WriteMessage(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+// ReadMessage returns the next text or binary message, answering pings and
+// reassembling fragments on the way. It returns io.EOF once the server closes
+// the connection.
+func (c *wsConn) ReadMessage() ([]byte, error) {
+	var message []byte
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+		case opPong:
+		case opClose:
+			_ = c.writeFrame(opClose, nil)
+			return nil, io.EOF
+		case opText, opBinary, opContinuation:
+			if len(message)+len(payload) > maxMessageSize {
+				return nil, errors.New("cdp: message too large")
+			}
+			message = append(message, payload...)
+			if fin {
+				return message, nil
+			}
+		default:
+			return nil, fmt.Errorf("cdp: unexpected websocket opcode %d", opcode)
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+	fin = head[0]&0x80 != 0
+	opcode = head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > maxMessageSize {
+		err = errors.New("cdp: message too large")
+		return
+	}
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return
+}
+
+// Close sends a close frame and closes the connection.
+func (c *wsConn) Close() error {
+	_ = c.writeFrame(opClose, []byte{0x03, 0xE8})
+	return c.conn.Close()
+}
@@ -0,0 +1,656 @@
+// Custom code. Not generated by Stainless.
+package local
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+	"github.com/browserbase/stagehand-go/v3/option"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// DefaultMinBrowserVersion is the oldest Chrome major version accepted when
+// [BrowserOptions.MinVersion] is not set.
+const DefaultMinBrowserVersion = 115
+
+const (
+	chromeForTestingVersionsURL = "https://googlechromelabs.github.io/chrome-for-testing/last-known-good-versions.json"
+	chromeForTestingDownloadURL = "https://storage.googleapis.com/chrome-for-testing-public"
+	browserVersionTimeout       = 10 * time.Second
+)
+
+// ErrBrowserNotFound is returned by [FindBrowser] when no suitable browser is
+// installed and installing one was not requested.
+var ErrBrowserNotFound = errors.New("no suitable Chrome or Chromium installation found")
+
+// Browser is a Chrome or Chromium installation.
+type Browser struct {
+	// Path is the browser's executable.
+	Path string
+	// Version is the full version, e.g. "131.0.6778.85". It is empty when it
+	// could not be read, which only happens on Windows.
+	Version string
+	// Source is where the browser was found: "env" for CHROME_PATH, "system",
+	// "snap", "flatpak", or "managed" for Chrome for Testing builds installed
+	// by [InstallBrowser].
+	Source string
+}
+
+// Major returns the major version of the browser, or 0 if it is unknown.
+func (b Browser) Major() int {
+	major, _, _ := strings.Cut(b.Version, ".")
+	n, _ := strconv.Atoi(major)
+	return n
+}
+
+// BrowserOptions configure [FindBrowser].
+type BrowserOptions struct {
+	// MinVersion is the oldest major version accepted. Defaults to
+	// DefaultMinBrowserVersion.
+	MinVersion int
+	// Version pins the Chrome for Testing build to use, e.g.
+	// "131.0.6778.85". Installed browsers are then ignored.
+	Version string
+	// Install downloads a Chrome for Testing build, the pinned one or else
+	// the latest stable one, when no suitable browser is found.
+	Install bool
+	// SHA256 is the hex-encoded SHA-256 digest of the archive of the pinned
+	// build. When set, installing a build whose archive does not match fails.
+	// It requires Version. Archives are always checked against the digests
+	// reported by the download server.
+	SHA256 string
+	// Transport is used to download browsers. Nil selects
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+// FindBrowser returns the browser local sessions should launch. The
+// CHROME_PATH environment variable takes precedence, then the pinned build if
+// there is one, then Chrome and Chromium installed on the system, including
+// snap and flatpak packages, then the newest build installed by
+// [InstallBrowser]. Browsers older than opts.MinVersion are skipped, and a
+// CHROME_PATH browser older than it is an error; on Windows, where versions
+// cannot be read, CHROME_PATH is used without the check. If none is found and
+// opts.Install is set, a Chrome for Testing build is installed. Installs are
+// safe to run from several processes at once.
+func FindBrowser(ctx context.Context, opts BrowserOptions) (*Browser, error) {
+	minVersion := opts.MinVersion
+	if minVersion == 0 {
+		minVersion = DefaultMinBrowserVersion
+	}
+
+	if path := os.Getenv("CHROME_PATH"); path != "" {
+		b, err := inspectBrowser(ctx, path, "env")
+		if err != nil {
+			return nil, fmt.Errorf("CHROME_PATH: %w", err)
+		}
+		// The version of Chrome cannot be read on Windows, where CHROME_PATH
+		// is trusted to name a recent enough browser.
+		if b.Version != "" && b.Major() < minVersion {
+			return nil, fmt.Errorf("CHROME_PATH: %s is version %s, older than the minimum %d", path, b.Version, minVersion)
+		}
+		return b, nil
+	}
+
+	if opts.SHA256 != "" && opts.Version == "" {
+		return nil, errors.New("BrowserOptions.SHA256 requires a pinned Version")
+	}
+	if opts.Version != "" {
+		if b, err := managedBrowser(opts.Version); err == nil {
+			return b, nil
+		}
+		if !opts.Install {
+			return nil, fmt.Errorf("%w: Chrome for Testing %s is not installed", ErrBrowserNotFound, opts.Version)
+		}
+		return installBrowser(ctx, opts.Version, opts.SHA256, opts.Transport)
+	}
+
+	if runtime.GOOS == "linux" {
+		for _, candidate := range systemBrowserCandidates() {
+			b, err := inspectBrowser(ctx, candidate, browserSource(candidate))
+			if err == nil && b.Major() >= minVersion {
+				return b, nil
+			}
+		}
+	}
+
+	if b := newestManagedBrowser(minVersion); b != nil {
+		return b, nil
+	}
+	if !opts.Install {
+		return nil, ErrBrowserNotFound
+	}
+	b, err := installBrowser(ctx, "", "", opts.Transport)
+	if err != nil {
+		return nil, err
+	}
+	if b.Major() < minVersion {
+		return nil, fmt.Errorf("latest Chrome for Testing %s is older than the minimum %d", b.Version, minVersion)
+	}
+	return b, nil
+}
+
+// systemBrowserCandidates lists the executables to look for on Linux, most
+// preferred first. It is a variable so tests can replace it.
+var systemBrowserCandidates = func() []string {
+	candidates := []string{
+		"/usr/bin/google-chrome-stable",
+		"/usr/bin/google-chrome",
+		"/opt/google/chrome/chrome",
+		"/usr/bin/chromium",
+		"/usr/bin/chromium-browser",
+		"/snap/bin/chromium",
+		"/var/lib/snapd/snap/bin/chromium",
+		"/var/lib/flatpak/exports/bin/com.google.Chrome",
+		"/var/lib/flatpak/exports/bin/org.chromium.Chromium",
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		userExports := filepath.Join(home, ".local", "share", "flatpak", "exports", "bin")
+		candidates = append(candidates,
+			filepath.Join(userExports, "com.google.Chrome"),
+			filepath.Join(userExports, "org.chromium.Chromium"),
+		)
+	}
+	for _, name := range []string{"google-chrome-stable", "google-chrome", "chromium", "chromium-browser"} {
+		if path, err := exec.LookPath(name); err == nil {
+			candidates = append(candidates, path)
+		}
+	}
+	return candidates
+}
+
+func browserSource(path string) string {
+	switch {
+	case strings.Contains(path, "/snap/"):
+		return "snap"
+	case strings.Contains(path, "/flatpak/"):
+		return "flatpak"
+	default:
+		return "system"
+	}
+}
+
+var browserVersionPattern = regexp.MustCompile(`\b(\d+\.\d+\.\d+\.\d+)\b`)
+
+// inspectBrowser checks that path is an executable and reads its version.
+func inspectBrowser(ctx context.Context, path, source string) (*Browser, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	b := &Browser{Path: path, Source: source}
+	// Chrome does not print its version on Windows.
+	if runtime.GOOS == "windows" {
+		return b, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, browserVersionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the version of %s: %w", path, err)
+	}
+	match := browserVersionPattern.FindSubmatch(out)
+	if match == nil {
+		return nil, fmt.Errorf("unrecognized version output of %s: %q", path, strings.TrimSpace(string(out)))
+	}
+	b.Version = string(match[1])
+	return b, nil
+}
+
+// chromeForTestingPlatform returns the Chrome for Testing name of the current
+// platform.
+func chromeForTestingPlatform() (string, error) {
+	switch runtime.GOOS + "/" + runtime.GOARCH {
+	case "linux/amd64":
+		return "linux64", nil
+	case "darwin/amd64":
+		return "mac-x64", nil
+	case "darwin/arm64":
+		return "mac-arm64", nil
+	case "windows/amd64":
+		return "win64", nil
+	case "windows/386":
+		return "win32", nil
+	default:
+		return "", fmt.Errorf("no Chrome for Testing builds for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+}
+
+// chromeForTestingExecutable returns the path of the executable within an
+// extracted Chrome for Testing archive.
+func chromeForTestingExecutable(platform string) string {
+	dir := "chrome-" + platform
+	switch {
+	case strings.HasPrefix(platform, "mac"):
+		return filepath.Join(dir, "Google Chrome for Testing.app", "Contents", "MacOS", "Google Chrome for Testing")
+	case strings.HasPrefix(platform, "win"):
+		return filepath.Join(dir, "chrome.exe")
+	default:
+		return filepath.Join(dir, "chrome")
+	}
+}
+
+// browserCacheDir returns where Chrome for Testing builds are installed, next
+// to the driver binaries.
+func browserCacheDir() (string, error) {
+	cacheRoot, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheRoot, "chrome"), nil
+}
+
+// managedBrowser returns the installed Chrome for Testing build of version.
+func managedBrowser(version string) (*Browser, error) {
+	platform, err := chromeForTestingPlatform()
+	if err != nil {
+		return nil, err
+	}
+	root, err := browserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(root, version, chromeForTestingExecutable(platform))
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	return &Browser{Path: path, Version: version, Source: "managed"}, nil
+}
+
+// newestManagedBrowser returns the newest installed Chrome for Testing build
+// of at least minVersion, or nil.
+func newestManagedBrowser(minVersion int) *Browser {
+	root, err := browserCacheDir()
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	var newest *Browser
+	for _, entry := range entries {
+		if !entry.IsDir() || !browserVersionPattern.MatchString(entry.Name()) {
+			continue
+		}
+		b, err := managedBrowser(entry.Name())
+		if err != nil || b.Major() < minVersion {
+			continue
+		}
+		if newest == nil || compareVersions(b.Version, newest.Version) > 0 {
+			newest = b
+		}
+	}
+	return newest
+}
+
+// compareVersions compares dotted numeric versions.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// InstallBrowser downloads a Chrome for Testing build into the cache
+// directory of the driver binaries, unless it is already there, and returns
+// it. An empty version selects the latest stable build. The archive is checked
+// against the digests reported by the download server before it is extracted.
+func InstallBrowser(ctx context.Context, version string) (*Browser, error) {
+	return installBrowser(ctx, version, "", nil)
+}
+
+// installBrowser is like InstallBrowser, also checking the archive against
+// wantSHA256 if it is set, and downloading through transport if it is not
+// nil.
+func installBrowser(ctx context.Context, version, wantSHA256 string, transport http.RoundTripper) (*Browser, error) {
+	platform, err := chromeForTestingPlatform()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, downloadTimeout)
+	defer cancel()
+
+	if version == "" {
+		version, err = fetchStableChromeVersion(ctx, transport)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve the latest Chrome for Testing version: %w", err)
+		}
+	}
+	if b, err := managedBrowser(version); err == nil {
+		return b, nil
+	}
+
+	downloadMu.Lock()
+	defer downloadMu.Unlock()
+	if b, err := managedBrowser(version); err == nil {
+		return b, nil
+	}
+
+	root, err := browserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/%s/%s/chrome-%s.zip", chromeForTestingDownloadURL, version, platform, platform)
+	if err := downloadArchive(ctx, transport, url, wantSHA256, filepath.Join(root, version)); err != nil {
+		return nil, fmt.Errorf("failed to install Chrome for Testing %s: %w", version, err)
+	}
+	return managedBrowser(version)
+}
+
+func fetchStableChromeVersion(ctx context.Context, transport http.RoundTripper) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, chromeForTestingVersionsURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", defaultUserAgent)
+
+	client := &http.Client{Timeout: apiTimeout, Transport: transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("failed to fetch versions: %s", resp.Status)
+	}
+
+	var versions struct {
+		Channels map[string]struct {
+			Version string `json:"version"`
+		} `json:"channels"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+		return "", err
+	}
+	version := versions.Channels["Stable"].Version
+	if version == "" {
+		return "", errors.New("no stable version listed")
+	}
+	return version, nil
+}
+
+// downloadArchive downloads a zip archive, checks it against wantSHA256 if it
+// is set and against the digests in the response headers, and extracts it to
+// destDir, which only appears once the archive is fully extracted.
+func downloadArchive(ctx context.Context, transport http.RoundTripper, url, wantSHA256, destDir string) error {
+	if err := os.MkdirAll(filepath.Dir(destDir), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", defaultUserAgent)
+
+	client := &http.Client{Transport: transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+
+	archive, err := os.CreateTemp(filepath.Dir(destDir), "chrome-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+	sha, sum, crc := sha256.New(), md5.New(), crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	size, err := io.Copy(io.MultiWriter(archive, sha, sum, crc), resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.ContentLength > 0 && size != resp.ContentLength {
+		return fmt.Errorf("downloaded %d bytes of %s, expected %d", size, url, resp.ContentLength)
+	}
+	if wantSHA256 != "" {
+		if got := hex.EncodeToString(sha.Sum(nil)); !strings.EqualFold(got, wantSHA256) {
+			return fmt.Errorf("SHA-256 of %s is %s, expected %s", url, got, wantSHA256)
+		}
+	}
+	if err := checkGoogHash(resp.Header, sum.Sum(nil), crc.Sum(nil)); err != nil {
+		return fmt.Errorf("%s: %w", url, err)
+	}
+
+	// Other processes may install the same build at the same time, so each
+	// extracts into a directory of its own, and the first to finish wins.
+	tmpDir, err := os.MkdirTemp(filepath.Dir(destDir), filepath.Base(destDir)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := extractZip(archive, size, tmpDir); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpDir, destDir); err != nil {
+		if info, statErr := os.Stat(destDir); statErr == nil && info.IsDir() {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// checkGoogHash checks the MD5 and CRC32C digests of a download against the
+// ones Google Cloud Storage reports in the x-goog-hash header, such as
+// "crc32c=n03x6A==,md5=Ojk9c3dhfxgoKVVHYwFbHQ==". Digests that are not
+// reported are not checked.
+func checkGoogHash(header http.Header, md5Sum, crc32cSum []byte) error {
+	for _, value := range header.Values("X-Goog-Hash") {
+		for _, field := range strings.Split(value, ",") {
+			name, encoded, ok := strings.Cut(strings.TrimSpace(field), "=")
+			if !ok {
+				continue
+			}
+			want, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return fmt.Errorf("malformed x-goog-hash %q", field)
+			}
+			var got []byte
+			switch name {
+			case "md5":
+				got = md5Sum
+			case "crc32c":
+				got = crc32cSum
+			default:
+				continue
+			}
+			if !bytes.Equal(got, want) {
+				return fmt.Errorf("%s digest mismatch: got %s, expected %s", name, base64.StdEncoding.EncodeToString(got), encoded)
+			}
+		}
+	}
+	return nil
+}
+
+func extractZip(r io.ReaderAt, size int64, destDir string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return err
+	}
+	// Symlink targets are checked against the real location of destDir.
+	root, err := filepath.EvalSymlinks(destDir)
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		path := filepath.Join(destDir, filepath.FromSlash(f.Name))
+		if !withinDir(destDir, path) {
+			return fmt.Errorf("archive entry %q is outside the archive", f.Name)
+		}
+		mode := f.Mode()
+		if mode.IsDir() {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := extractZipFile(f, root, path, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withinDir reports whether path is dir or lies below it.
+func withinDir(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+func extractZipFile(f *zip.File, root, path string, mode os.FileMode) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// The macOS builds contain symlinks within the app bundle. Links pointing
+	// outside of it are refused. Targets are cleaned, so that any ".." comes
+	// first and applies to the real parent directory checked here, and the
+	// links already extracted are known to stay within root.
+	if mode&os.ModeSymlink != 0 {
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return err
+		}
+		target := filepath.FromSlash(string(data))
+		if filepath.IsAbs(target) || filepath.VolumeName(target) != "" || strings.HasPrefix(target, string(filepath.Separator)) {
+			return fmt.Errorf("archive entry %q links to the absolute path %q", f.Name, data)
+		}
+		target = filepath.Clean(target)
+		parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		if !withinDir(root, filepath.Join(parent, target)) {
+			return fmt.Errorf("archive entry %q links to %q, outside the archive", f.Name, data)
+		}
+		return os.Symlink(target, path)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, rc); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WithBrowser returns a RequestOption that sets the executable path of
+// sessions started with a local browser, unless the call sets one itself or
+// connects to a running browser through a CDP URL. The browser is found with
+// [FindBrowser] on the first such start call, and reused afterwards. Use it
+// with clients in local mode, whose driver launches browsers on this machine.
+func WithBrowser(opts BrowserOptions) option.RequestOption {
+	finder := &browserFinder{opts: opts}
+	return requestconfig.RequestOptionFunc(func(r *requestconfig.RequestConfig) error {
+		if r.Request.Method != http.MethodPost || !strings.HasSuffix(strings.TrimRight(r.Request.URL.Path, "/"), "sessions/start") {
+			return nil
+		}
+		r.PostApply = append(r.PostApply, func(r *requestconfig.RequestConfig) error {
+			buffer, ok := r.Body.(*bytes.Buffer)
+			if !ok {
+				return nil
+			}
+			body := buffer.Bytes()
+			if gjson.GetBytes(body, "browser.type").String() != "local" ||
+				gjson.GetBytes(body, "browser.cdpUrl").String() != "" ||
+				gjson.GetBytes(body, "browser.launchOptions.cdpUrl").String() != "" ||
+				gjson.GetBytes(body, "browser.launchOptions.executablePath").String() != "" {
+				return nil
+			}
+			b, err := finder.find(r.Context)
+			if err != nil {
+				return err
+			}
+			body, err = sjson.SetBytes(body, "browser.launchOptions.executablePath", b.Path)
+			if err != nil {
+				return err
+			}
+			r.Body = bytes.NewBuffer(body)
+			return nil
+		})
+		return nil
+	})
+}
+
+// browserFinder caches the browser found for [WithBrowser]. Failures are not
+// cached, so a later start call tries again.
+type browserFinder struct {
+	opts BrowserOptions
+
+	mu      sync.Mutex
+	browser *Browser
+}
+
+func (f *browserFinder) find(ctx context.Context) (*Browser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.browser != nil {
+		return f.browser, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	b, err := FindBrowser(ctx, f.opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find a browser for local sessions: %w", err)
+	}
+	f.browser = b
+	return b, nil
+}
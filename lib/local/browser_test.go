@@ -0,0 +1,343 @@
+// Custom tests. Not generated by Stainless.
+package local
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+	"github.com/browserbase/stagehand-go/v3/option"
+	"github.com/tidwall/gjson"
+)
+
+// writeFakeBrowser writes a script that prints a Chrome version string.
+func writeFakeBrowser(t *testing.T, dir, name, version string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake browsers are shell scripts")
+	}
+	path := filepath.Join(dir, name)
+	script := "#!/bin/sh\necho 'Google Chrome " + version + " '\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write fake browser: %v", err)
+	}
+	return path
+}
+
+func withBrowserCandidates(t *testing.T, candidates ...string) {
+	t.Helper()
+	orig := systemBrowserCandidates
+	systemBrowserCandidates = func() []string { return candidates }
+	t.Cleanup(func() {
+		systemBrowserCandidates = orig
+	})
+}
+
+func TestFindBrowser_ChromePath(t *testing.T) {
+	withTempHome(t)
+	path := writeFakeBrowser(t, t.TempDir(), "chrome", "131.0.6778.85")
+	t.Setenv("CHROME_PATH", path)
+	withBrowserCandidates(t)
+
+	b, err := FindBrowser(context.Background(), BrowserOptions{})
+	if err != nil {
+		t.Fatalf("FindBrowser error: %v", err)
+	}
+	if b.Path != path || b.Version != "131.0.6778.85" || b.Source != "env" || b.Major() != 131 {
+		t.Fatalf("unexpected browser: %+v", b)
+	}
+
+	if _, err := FindBrowser(context.Background(), BrowserOptions{MinVersion: 132}); err == nil || !strings.Contains(err.Error(), "older than the minimum 132") {
+		t.Fatalf("expected a version error, got %v", err)
+	}
+}
+
+func TestFindBrowser_SkipsMissingAndOldSystemBrowsers(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("system browsers are only discovered on Linux")
+	}
+	withTempHome(t)
+	t.Setenv("CHROME_PATH", "")
+	dir := t.TempDir()
+	old := writeFakeBrowser(t, dir, "chromium-old", "109.0.5414.74")
+	snapDir := filepath.Join(dir, "snap", "bin")
+	if err := os.MkdirAll(snapDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	current := writeFakeBrowser(t, snapDir, "chromium", "130.0.6723.58")
+	withBrowserCandidates(t, filepath.Join(dir, "missing"), old, current)
+
+	b, err := FindBrowser(context.Background(), BrowserOptions{})
+	if err != nil {
+		t.Fatalf("FindBrowser error: %v", err)
+	}
+	if b.Path != current || b.Source != "snap" || b.Version != "130.0.6723.58" {
+		t.Fatalf("unexpected browser: %+v", b)
+	}
+
+	withBrowserCandidates(t, old)
+	if _, err := FindBrowser(context.Background(), BrowserOptions{}); !errors.Is(err, ErrBrowserNotFound) {
+		t.Fatalf("expected ErrBrowserNotFound, got %v", err)
+	}
+}
+
+// chromeArchive returns a Chrome for Testing archive for the current
+// platform, holding a fake executable.
+func chromeArchive(t *testing.T) []byte {
+	t.Helper()
+	platform, err := chromeForTestingPlatform()
+	if err != nil {
+		t.Skip(err)
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	header := &zip.FileHeader{Name: filepath.ToSlash(chromeForTestingExecutable(platform)), Method: zip.Deflate}
+	header.SetMode(0o755)
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		t.Fatalf("create zip entry: %v", err)
+	}
+	_, _ = w.Write([]byte("chrome"))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestFindBrowser_InstallsChromeForTesting(t *testing.T) {
+	withTempHome(t)
+	t.Setenv("CHROME_PATH", "")
+	withBrowserCandidates(t)
+	archive := chromeArchive(t)
+	platform, _ := chromeForTestingPlatform()
+
+	var requests []string
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.URL.String())
+		var body string
+		switch req.URL.String() {
+		case chromeForTestingVersionsURL:
+			body = `{"channels":{"Stable":{"channel":"Stable","version":"131.0.6778.85"}}}`
+		case "https://storage.googleapis.com/chrome-for-testing-public/131.0.6778.85/" + platform + "/chrome-" + platform + ".zip":
+			body = string(archive)
+		default:
+			t.Fatalf("unexpected request to %s", req.URL)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})
+
+	if _, err := FindBrowser(context.Background(), BrowserOptions{Transport: transport}); !errors.Is(err, ErrBrowserNotFound) {
+		t.Fatalf("expected ErrBrowserNotFound without Install, got %v", err)
+	}
+
+	b, err := FindBrowser(context.Background(), BrowserOptions{Install: true, Transport: transport})
+	if err != nil {
+		t.Fatalf("FindBrowser error: %v", err)
+	}
+	root, err := cacheDir()
+	if err != nil {
+		t.Fatalf("cacheDir failed: %v", err)
+	}
+	want := filepath.Join(root, "chrome", "131.0.6778.85", chromeForTestingExecutable(platform))
+	if b.Path != want || b.Version != "131.0.6778.85" || b.Source != "managed" {
+		t.Fatalf("unexpected browser: %+v, want path %s", b, want)
+	}
+	if data, err := os.ReadFile(b.Path); err != nil || string(data) != "chrome" {
+		t.Fatalf("unexpected executable content %q: %v", data, err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %v", requests)
+	}
+
+	// Installed builds are found without the network, pinned or not.
+	requests = nil
+	for _, opts := range []BrowserOptions{{}, {Version: "131.0.6778.85"}, {Version: "131.0.6778.85", Install: true}} {
+		b, err := FindBrowser(context.Background(), opts)
+		if err != nil || b.Path != want {
+			t.Fatalf("FindBrowser(%+v) = %+v, %v", opts, b, err)
+		}
+	}
+	if len(requests) != 0 {
+		t.Fatalf("unexpected requests: %v", requests)
+	}
+	if _, err := FindBrowser(context.Background(), BrowserOptions{MinVersion: 132}); !errors.Is(err, ErrBrowserNotFound) {
+		t.Fatalf("expected ErrBrowserNotFound for a newer minimum, got %v", err)
+	}
+}
+
+func TestDownloadArchive_ConcurrentInstall(t *testing.T) {
+	archive := chromeArchive(t)
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(archive))}, nil
+	})
+	root := t.TempDir()
+	dest := filepath.Join(root, "131.0.6778.85")
+
+	// Another process finished installing the same build first.
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dest, "installed"), []byte("other"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := downloadArchive(context.Background(), transport, "https://example.test/chrome.zip", "", dest); err != nil {
+		t.Fatalf("expected an existing install to count as success, got %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "installed")); err != nil || string(data) != "other" {
+		t.Fatalf("expected the existing install to be kept, got %q: %v", data, err)
+	}
+	entries, _ := os.ReadDir(root)
+	if len(entries) != 1 {
+		t.Fatalf("expected no leftover directories, got %v", entries)
+	}
+}
+
+// symlinkArchive returns an archive holding the given symlinks, each entry
+// name followed by its target, after a directory and a file.
+func symlinkArchive(t *testing.T, links ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	add := func(name string, mode os.FileMode, content string) {
+		header := &zip.FileHeader{Name: name}
+		header.SetMode(mode)
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("create zip entry: %v", err)
+		}
+		_, _ = w.Write([]byte(content))
+	}
+	add("Versions/131/", os.ModeDir|0o755, "")
+	add("Versions/131/chrome", 0o755, "chrome")
+	for i := 0; i+1 < len(links); i += 2 {
+		add(links[i], os.ModeSymlink|0o777, links[i+1])
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractZip_Symlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	extract := func(archive []byte) (string, error) {
+		dest := filepath.Join(t.TempDir(), "chrome")
+		return dest, extractZip(bytes.NewReader(archive), int64(len(archive)), dest)
+	}
+
+	dest, err := extract(symlinkArchive(t, "Versions/Current", "131", "chrome", "Versions/Current/chrome", "Versions/131/self", "../131/./chrome"))
+	if err != nil {
+		t.Fatalf("extractZip error: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "chrome")); err != nil || string(data) != "chrome" {
+		t.Fatalf("unexpected content through links %q: %v", data, err)
+	}
+
+	for _, links := range [][]string{
+		{"passwd", "/etc/passwd"},
+		{"up", ".."},
+		{"Versions/131/up", "../../../outside"},
+		// Each link stays within the archive, but following the second
+		// through the first would not.
+		{"Versions/root", "..", "Versions/root/up", ".."},
+	} {
+		if _, err := extract(symlinkArchive(t, links...)); err == nil || !strings.Contains(err.Error(), "links to") {
+			t.Fatalf("links %q: expected an error, got %v", links, err)
+		}
+	}
+}
+
+func TestFindBrowser_ChecksArchiveDigests(t *testing.T) {
+	withTempHome(t)
+	t.Setenv("CHROME_PATH", "")
+	withBrowserCandidates(t)
+	archive := chromeArchive(t)
+	md5Sum := md5.Sum(archive)
+	shaSum := sha256.Sum256(archive)
+	goodHash := "md5=" + base64.StdEncoding.EncodeToString(md5Sum[:])
+
+	var goog string
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{}
+		if goog != "" {
+			header.Set("X-Goog-Hash", goog)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(bytes.NewReader(archive))}, nil
+	})
+	find := func(sha string) error {
+		_, err := FindBrowser(context.Background(), BrowserOptions{Version: "131.0.6778.85", Install: true, SHA256: sha, Transport: transport})
+		return err
+	}
+
+	goog = "crc32c=AAAAAA==," + goodHash
+	if err := find(""); err == nil || !strings.Contains(err.Error(), "crc32c digest mismatch") {
+		t.Fatalf("expected a CRC32C mismatch, got %v", err)
+	}
+	goog = goodHash
+	if err := find(strings.Repeat("0", 64)); err == nil || !strings.Contains(err.Error(), "SHA-256") {
+		t.Fatalf("expected a SHA-256 mismatch, got %v", err)
+	}
+	if _, err := managedBrowser("131.0.6778.85"); err == nil {
+		t.Fatal("a mismatched archive was installed")
+	}
+	if err := find(hex.EncodeToString(shaSum[:])); err != nil {
+		t.Fatalf("FindBrowser error: %v", err)
+	}
+
+	if _, err := FindBrowser(context.Background(), BrowserOptions{SHA256: hex.EncodeToString(shaSum[:])}); err == nil {
+		t.Fatal("expected SHA256 without Version to fail")
+	}
+}
+
+func TestWithBrowser_FillsExecutablePath(t *testing.T) {
+	withTempHome(t)
+	path := writeFakeBrowser(t, t.TempDir(), "chrome", "131.0.6778.85")
+	t.Setenv("CHROME_PATH", path)
+
+	var bodies []string
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		data, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(data))
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(`{}`))}, nil
+	})}
+	opts := []option.RequestOption{
+		option.WithBaseURL("http://127.0.0.1:1/"),
+		option.WithHTTPClient(client),
+		WithBrowser(BrowserOptions{}),
+	}
+
+	for _, body := range []string{
+		`{"modelName":"m","browser":{"type":"local"}}`,
+		`{"modelName":"m","browser":{"type":"local","launchOptions":{"executablePath":"/custom/chrome"}}}`,
+		`{"modelName":"m","browser":{"type":"local","cdpUrl":"ws://127.0.0.1:9222"}}`,
+		`{"modelName":"m","browser":{"type":"browserbase"}}`,
+	} {
+		if err := requestconfig.ExecuteNewRequest(context.Background(), http.MethodPost, "v1/sessions/start", []byte(body), nil, opts...); err != nil {
+			t.Fatalf("start failed: %v", err)
+		}
+	}
+
+	got := []string{}
+	for _, body := range bodies {
+		got = append(got, gjson.Get(body, "browser.launchOptions.executablePath").String())
+	}
+	want := []string{path, "/custom/chrome", "", ""}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("executable paths = %q, want %q", got, want)
+	}
+}
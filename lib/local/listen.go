@@ -0,0 +1,116 @@
+// Custom code. Not generated by Stainless.
+package local
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// listenLinePattern matches the line the driver logs once its HTTP listener is
+// bound, e.g. "Server listening at http://127.0.0.1:43567". The driver logs
+// through pino, so the line may be embedded in a JSON record.
+var listenLinePattern = regexp.MustCompile(`(?i)listening (?:at|on) (https?://[^\s"',]+)`)
+
+// parseListenAddress extracts the base URL the driver reported it is bound to.
+// Wildcard hosts are rewritten to the loopback host the SDK connects through.
+func parseListenAddress(line string) (string, bool) {
+	match := listenLinePattern.FindStringSubmatch(line)
+	if match == nil {
+		return "", false
+	}
+
+	u, err := url.Parse(match[1])
+	if err != nil {
+		return "", false
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil || port <= 0 {
+		return "", false
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		host = defaultHost
+	}
+	if host == "" {
+		host = defaultHost
+	}
+
+	return u.Scheme + "://" + net.JoinHostPort(host, strconv.Itoa(port)), true
+}
+
+// listenWatcher forwards driver output to an underlying writer while scanning
+// it line by line for the listen address. The first address found is
+// delivered on the channel returned by Address.
+type listenWatcher struct {
+	out io.Writer
+
+	mu    sync.Mutex
+	buf   []byte
+	found bool
+	addr  chan string
+}
+
+func newListenWatcher(out io.Writer) *listenWatcher {
+	return &listenWatcher{
+		out:  out,
+		addr: make(chan string, 1),
+	}
+}
+
+// Address returns a channel that receives the listen address once it has
+// been reported by the driver.
+func (w *listenWatcher) Address() <-chan string {
+	return w.addr
+}
+
+func (w *listenWatcher) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	if !w.found {
+		w.buf = append(w.buf, p...)
+		for {
+			i := bytes.IndexByte(w.buf, '\n')
+			if i < 0 {
+				break
+			}
+			line := string(w.buf[:i])
+			w.buf = w.buf[i+1:]
+			if addr, ok := parseListenAddress(line); ok {
+				w.found = true
+				w.buf = nil
+				w.addr <- addr
+				break
+			}
+		}
+	}
+	w.mu.Unlock()
+
+	if w.out == nil {
+		return len(p), nil
+	}
+	return w.out.Write(p)
+}
+
+// tailFile returns a function that copies whatever has been appended to the
+// file at path since the previous call into w.
+func tailFile(path string, w io.Writer) func() {
+	var offset int64
+	return func() {
+		f, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return
+		}
+		n, _ := io.Copy(w, f)
+		offset += n
+	}
+}
@@ -4,9 +4,9 @@ package local
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"os"
 	"os/exec"
@@ -14,7 +14,6 @@ import (
 	"runtime"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 
 	"github.com/browserbase/stagehand-go/v3/internal"
@@ -115,6 +114,20 @@ func cacheDir() (string, error) {
 
 // ResolveBinaryPath ensures the local mode binary exists and returns its path.
 func ResolveBinaryPath() (string, error) {
+	return ResolveBinaryPathForVersion("")
+}
+
+// ResolveBinaryPathForVersion ensures the local mode binary for the given
+// driver release exists and returns its path. Pinned versions are cached
+// separately from the default binary. An empty version uses the
+// STAGEHAND_SERVER_VERSION environment variable, or the latest release.
+func ResolveBinaryPathForVersion(version string) (string, error) {
+	return resolveBinaryPath(version, nil)
+}
+
+// resolveBinaryPath is like ResolveBinaryPathForVersion, downloading through
+// transport if it is not nil.
+func resolveBinaryPath(version string, transport http.RoundTripper) (string, error) {
 	filename := binaryFilename()
 
 	// Check cache directory first.
@@ -123,6 +136,9 @@ func ResolveBinaryPath() (string, error) {
 		return "", err
 	}
 	cachePath := filepath.Join(cacheRoot, filename)
+	if version != "" {
+		cachePath = filepath.Join(cacheRoot, "driver", strings.ReplaceAll(version, "/", "_"), filename)
+	}
 	if _, err := os.Stat(cachePath); err == nil {
 		return cachePath, nil
 	}
@@ -134,7 +150,9 @@ func ResolveBinaryPath() (string, error) {
 		return cachePath, nil
 	}
 
-	version := os.Getenv("STAGEHAND_SERVER_VERSION")
+	if version == "" {
+		version = os.Getenv("STAGEHAND_SERVER_VERSION")
+	}
 	if version == "" {
 		version = "latest"
 	}
@@ -142,12 +160,12 @@ func ResolveBinaryPath() (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
 	defer cancel()
 
-	tag, err := resolveVersion(ctx, version)
+	tag, err := resolveVersion(ctx, version, transport)
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve stagehand driver binary version: %w (possibly blocked by firewall or sandbox settings). %s", err, manualDownloadHint(filename, cachePath))
 	}
 
-	if err := downloadBinary(ctx, tag, cachePath); err != nil {
+	if err := downloadBinary(ctx, transport, tag, cachePath); err != nil {
 		return "", fmt.Errorf("failed to download latest stagehand driver binary to: %w (possibly blocked by firewall or sandbox settings). %s", err, manualDownloadHint(filename, cachePath))
 	}
 
@@ -167,9 +185,9 @@ func manualDownloadHint(filename, destPath string) string {
 	)
 }
 
-func resolveVersion(ctx context.Context, version string) (string, error) {
+func resolveVersion(ctx context.Context, version string, transport http.RoundTripper) (string, error) {
 	if version == "" || version == "latest" {
-		return fetchLatestTag(ctx)
+		return fetchLatestTag(ctx, transport)
 	}
 	if !strings.HasPrefix(version, "stagehand-server-v3/") {
 		version = "stagehand-server-v3/" + version
@@ -177,7 +195,7 @@ func resolveVersion(ctx context.Context, version string) (string, error) {
 	return version, nil
 }
 
-func fetchLatestTag(ctx context.Context) (string, error) {
+func fetchLatestTag(ctx context.Context, transport http.RoundTripper) (string, error) {
 	url := fmt.Sprintf("https://api.github.com/repos/%s/releases?per_page=15", stagehandRepo)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -185,7 +203,7 @@ func fetchLatestTag(ctx context.Context) (string, error) {
 	}
 	req.Header.Set("User-Agent", defaultUserAgent)
 
-	client := &http.Client{Timeout: apiTimeout}
+	client := &http.Client{Timeout: apiTimeout, Transport: transport}
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
@@ -209,7 +227,7 @@ func fetchLatestTag(ctx context.Context) (string, error) {
 	return "", fmt.Errorf("failed to find stagehand-server-v3 release tag")
 }
 
-func downloadBinary(ctx context.Context, version, destPath string) error {
+func downloadBinary(ctx context.Context, transport http.RoundTripper, version, destPath string) error {
 	filename := binaryFilename()
 	url := fmt.Sprintf("https://github.com/%s/releases/download/%s/%s", stagehandRepo, version, filename)
 
@@ -223,7 +241,7 @@ func downloadBinary(ctx context.Context, version, destPath string) error {
 	}
 	req.Header.Set("User-Agent", defaultUserAgent)
 
-	client := &http.Client{}
+	client := &http.Client{Transport: transport}
 	resp, err := client.Do(req)
 	if err != nil {
 		return err
@@ -269,6 +287,22 @@ type ServerManager struct {
 	browserbaseProjectID string
 	mu                   sync.Mutex
 	started              bool
+	// credentialsChanged is set when a credential is changed after the driver
+	// has started with the previous value.
+	credentialsChanged bool
+
+	// registryKey and refs are guarded by registry.mu and are only set for
+	// managers handed out by AcquireServerManager.
+	registryKey string
+	refs        int
+	// host is set when the driver is shared with other processes on this host.
+	host *hostShare
+	// pidFile records the running driver so a later run can reap it if this
+	// process dies without stopping it.
+	pidFile string
+	// transport is used to download the driver and to poll its health. Nil
+	// selects http.DefaultTransport.
+	transport http.RoundTripper
 }
 
 const (
@@ -276,16 +310,28 @@ const (
 	defaultReadyTimeout = 30 * time.Second
 )
 
+// ErrCredentialsChanged is returned by [ServerManager.EnsureRunning] when a
+// credential was changed after the driver started. The driver only reads its
+// credentials at startup, so it would otherwise keep using the old ones. Stop
+// the manager with Close before starting it with new credentials, or use a
+// separate manager per credential set.
+var ErrCredentialsChanged = errors.New("local mode credentials changed after the driver started")
+
 // NewServerManager creates a new ServerManager.
 // It resolves the binary path immediately and returns an error if not found.
 func NewServerManager() (*ServerManager, error) {
-	binaryPath, err := ResolveBinaryPath()
+	return newServerManager("", nil)
+}
+
+func newServerManager(version string, transport http.RoundTripper) (*ServerManager, error) {
+	binaryPath, err := resolveBinaryPath(version, transport)
 	if err != nil {
 		return nil, err
 	}
 
 	return &ServerManager{
 		binaryPath: binaryPath,
+		transport:  transport,
 	}, nil
 }
 
@@ -295,6 +341,18 @@ func (m *ServerManager) EnsureRunning(ctx context.Context) (string, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.credentialsChanged {
+		if m.host != nil || (m.started && m.isProcessRunning()) {
+			return "", ErrCredentialsChanged
+		}
+		// The driver is gone, so the next one starts with the new credentials.
+		m.credentialsChanged = false
+	}
+
+	if m.host != nil {
+		return m.ensureHostRunningLocked(ctx)
+	}
+
 	if m.started && m.cmd != nil && m.cmd.Process != nil {
 		// Check if process is still running
 		if m.isProcessRunning() {
@@ -310,32 +368,34 @@ func (m *ServerManager) EnsureRunning(ctx context.Context) (string, error) {
 
 // SetModelAPIKey sets the model API key used when starting local mode.
 func (m *ServerManager) SetModelAPIKey(key string) {
-	if key == "" {
-		return
-	}
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.modelAPIKey = key
+	m.setCredential(&m.modelAPIKey, key)
 }
 
 // SetBrowserbaseAPIKey sets the Browserbase API key used by local mode.
 func (m *ServerManager) SetBrowserbaseAPIKey(key string) {
-	if key == "" {
-		return
-	}
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.browserbaseAPIKey = key
+	m.setCredential(&m.browserbaseAPIKey, key)
 }
 
 // SetBrowserbaseProjectID sets the Browserbase project ID used by local mode.
 func (m *ServerManager) SetBrowserbaseProjectID(projectID string) {
-	if projectID == "" {
+	m.setCredential(&m.browserbaseProjectID, projectID)
+}
+
+// setCredential updates a credential, recording the change if the driver is
+// already running with a different value.
+func (m *ServerManager) setCredential(field *string, value string) {
+	if value == "" {
 		return
 	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.browserbaseProjectID = projectID
+	if *field == value {
+		return
+	}
+	*field = value
+	if m.started || (m.host != nil && m.host.registered) {
+		m.credentialsChanged = true
+	}
 }
 
 // startLocked starts local mode. Must be called with m.mu held.
@@ -344,19 +404,14 @@ func (m *ServerManager) startLocked(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("MODEL_API_KEY is required for local mode")
 	}
 
-	// Pick a free port if not specified
-	port, err := findFreePort()
-	if err != nil {
-		return "", fmt.Errorf("failed to find free port: %w", err)
-	}
-
-	// Build environment
+	// Let the driver bind an ephemeral port itself and report it back, rather
+	// than reserving one here and racing other processes for it.
 	env := os.Environ()
 	env = append(env,
 		"NODE_ENV=production",
 		"BB_ENV=local",
 		fmt.Sprintf("HOST=%s", defaultHost),
-		fmt.Sprintf("PORT=%d", port),
+		"PORT=0",
 	)
 
 	if m.modelAPIKey != "" {
@@ -369,40 +424,104 @@ func (m *ServerManager) startLocked(ctx context.Context) (string, error) {
 		env = append(env, fmt.Sprintf("BROWSERBASE_PROJECT_ID=%s", m.browserbaseProjectID))
 	}
 
-	// Start the process
+	sweepStaleDriversOnce.Do(sweepStaleDrivers)
+
+	// Start the process. A driver shared with other processes must outlive
+	// this one; any other driver is tied to this process' lifetime.
 	m.cmd = exec.Command(m.binaryPath)
 	m.cmd.Env = env
-	m.cmd.Stdout = os.Stdout
-	m.cmd.Stderr = os.Stderr
+	configureCommand(m.cmd, m.host != nil)
+
+	var watchers []*listenWatcher
+	var poll func()
+	if m.host != nil {
+		// A driver shared between processes may outlive this one, so it
+		// must not write to a pipe owned by this process. Its output goes to
+		// a log file that is tailed for the listen address instead.
+		logFile, err := os.OpenFile(m.host.logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return "", fmt.Errorf("failed to open local mode log: %w", err)
+		}
+		defer logFile.Close()
+		m.cmd.Stdout = logFile
+		m.cmd.Stderr = logFile
+
+		watcher := newListenWatcher(nil)
+		watchers = append(watchers, watcher)
+		poll = tailFile(m.host.logPath, watcher)
+	} else {
+		stdout := newListenWatcher(os.Stdout)
+		stderr := newListenWatcher(os.Stderr)
+		watchers = append(watchers, stdout, stderr)
+		m.cmd.Stdout = stdout
+		m.cmd.Stderr = stderr
+	}
 
 	if err := m.cmd.Start(); err != nil {
 		return "", fmt.Errorf("failed to start local mode: %w", err)
 	}
 
-	m.baseURL = fmt.Sprintf("http://%s:%d", defaultHost, port)
 	m.started = true
+	if m.host == nil {
+		m.writePIDFile()
+	}
+	deadline := time.Now().Add(defaultReadyTimeout)
 
-	// Wait for local mode to be ready.
-	if err := m.waitForReady(ctx); err != nil {
+	// Wait for the driver to report its address, then for it to be ready.
+	baseURL, err := m.waitForAddress(ctx, deadline, poll, watchers...)
+	if err == nil {
+		m.baseURL = baseURL
+		err = m.waitForReady(ctx, deadline)
+	}
+	if err != nil {
 		// Kill the process if we fail to connect
-		_ = m.Close()
+		_ = m.stopLocked()
 		return "", err
 	}
 
 	return m.baseURL, nil
 }
 
-// waitForReady polls health endpoints until local mode is ready or timeout.
-func (m *ServerManager) waitForReady(ctx context.Context) error {
-	healthEndpoints := []string{"/readyz", "/healthz", "/health"}
-
-	deadline := time.Now().Add(defaultReadyTimeout)
+// waitForAddress waits until the driver reports the address it is listening
+// on to one of the watchers. If poll is non-nil it is called periodically to
+// feed the watchers.
+func (m *ServerManager) waitForAddress(ctx context.Context, deadline time.Time, poll func(), watchers ...*listenWatcher) (string, error) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
-	client := &http.Client{
-		Timeout: 2 * time.Second,
+	for {
+		for _, w := range watchers {
+			select {
+			case addr := <-w.Address():
+				return addr, nil
+			default:
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-timer.C:
+			return "", fmt.Errorf("local mode did not report a listen address within %v", defaultReadyTimeout)
+		case <-ticker.C:
+			if poll != nil {
+				poll()
+			}
+			if !m.isProcessRunning() {
+				return "", fmt.Errorf("local mode process exited unexpectedly")
+			}
+		}
 	}
+}
+
+// waitForReady polls health endpoints until local mode is ready or timeout.
+func (m *ServerManager) waitForReady(ctx context.Context, deadline time.Time) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	client := m.healthClient()
 
 	for {
 		select {
@@ -418,23 +537,38 @@ func (m *ServerManager) waitForReady(ctx context.Context) error {
 				return fmt.Errorf("local mode process exited unexpectedly")
 			}
 
-			// Try each health endpoint
-			for _, endpoint := range healthEndpoints {
-				url := m.baseURL + endpoint
-				resp, err := client.Get(url)
-				if err != nil {
-					continue
-				}
-				resp.Body.Close()
-
-				if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-					return nil
-				}
+			if probeHealth(client, m.baseURL) {
+				return nil
 			}
 		}
 	}
 }
 
+// healthClient returns the client used to poll the driver's health.
+func (m *ServerManager) healthClient() *http.Client {
+	return &http.Client{
+		Timeout:   2 * time.Second,
+		Transport: m.transport,
+	}
+}
+
+// probeHealth reports whether any of the driver's health endpoints answers
+// with a 2xx status.
+func probeHealth(client *http.Client, baseURL string) bool {
+	for _, endpoint := range []string{"/readyz", "/healthz", "/health"} {
+		resp, err := client.Get(baseURL + endpoint)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return true
+		}
+	}
+	return false
+}
+
 // isProcessRunning checks if the process is still running.
 func (m *ServerManager) isProcessRunning() bool {
 	return processRunning(m.cmd)
@@ -442,16 +576,28 @@ func (m *ServerManager) isProcessRunning() bool {
 
 // Close stops local mode gracefully.
 // It sends SIGTERM first, waits up to 3 seconds, then sends SIGKILL if needed.
+// For a driver shared with other processes, the driver is only stopped once
+// no other process is using it.
 func (m *ServerManager) Close() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.credentialsChanged = false
+	if m.host != nil {
+		return m.releaseHostLocked()
+	}
+	return m.stopLocked()
+}
+
+// stopLocked stops local mode. Must be called with m.mu held.
+func (m *ServerManager) stopLocked() error {
 	if !m.started || m.cmd == nil || m.cmd.Process == nil {
 		return nil
 	}
+	defer m.removePIDFile()
 
-	// Send SIGTERM
-	if err := m.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+	// Send SIGTERM to the driver and anything it launched
+	if err := terminateProcess(m.cmd); err != nil {
 		// Process might already be dead
 		if !m.isProcessRunning() {
 			m.started = false
@@ -467,12 +613,13 @@ func (m *ServerManager) Close() error {
 
 	select {
 	case <-done:
-		// Process exited
+		// Process exited, make sure nothing it launched outlives it
+		_ = killProcess(m.cmd)
 		m.started = false
 		return nil
 	case <-time.After(3 * time.Second):
 		// Timeout, force kill
-		if err := m.cmd.Process.Kill(); err != nil {
+		if err := killProcess(m.cmd); err != nil {
 			return fmt.Errorf("failed to kill process: %w", err)
 		}
 		<-done // Wait for Wait() to complete
@@ -480,15 +627,3 @@ func (m *ServerManager) Close() error {
 		return nil
 	}
 }
-
-// findFreePort finds an available TCP port on localhost.
-func findFreePort() (int, error) {
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		return 0, err
-	}
-	defer listener.Close()
-
-	addr := listener.Addr().(*net.TCPAddr)
-	return addr.Port, nil
-}
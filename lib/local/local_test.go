@@ -3,17 +3,116 @@ package local
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
+// fakeDriverEnv makes the test binary act as a stand-in for the driver when it
+// is started by a ServerManager, so lifecycle tests do not need the real SEA.
+const fakeDriverEnv = "STAGEHAND_LOCAL_FAKE_DRIVER"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(fakeDriverEnv) {
+	case "1":
+		runFakeDriver()
+		return
+	case "sleep":
+		// Stands in for a browser launched by the driver.
+		time.Sleep(time.Hour)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runFakeDriver binds the port given in PORT (0 for ephemeral), logs the bound
+// address the way the driver does and serves health checks until terminated.
+func runFakeDriver() {
+	listener, err := net.Listen("tcp", net.JoinHostPort(os.Getenv("HOST"), os.Getenv("PORT")))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("{\"level\":30,\"msg\":\"Server listening at http://%s\"}\n", listener.Addr())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/child", func(w http.ResponseWriter, r *http.Request) {
+		pid, err := startSleeper(nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, pid)
+	})
+	_ = http.Serve(listener, mux)
+}
+
+// installFakeDriver points the binary cache at the test binary so managers
+// created through NewServerManager start the fake driver.
+func installFakeDriver(t *testing.T) {
+	t.Helper()
+	withTempHome(t)
+	t.Setenv(fakeDriverEnv, "1")
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable: %v", err)
+	}
+	root, path := expectedCachePath(t)
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatalf("mkdir cache: %v", err)
+	}
+	if err := os.Symlink(exe, path); err != nil {
+		t.Fatalf("symlink fake driver: %v", err)
+	}
+}
+
+// startSleeper starts a copy of the test binary that sleeps for an hour and
+// returns its pid.
+func startSleeper(configure func(*exec.Cmd)) (int, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+	cmd := exec.Command(exe)
+	cmd.Env = append(os.Environ(), fakeDriverEnv+"=sleep")
+	if configure != nil {
+		configure(cmd)
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	go func() { _ = cmd.Wait() }()
+	return cmd.Process.Pid, nil
+}
+
+func newFakeServerManager(t *testing.T) *ServerManager {
+	t.Helper()
+	t.Setenv(fakeDriverEnv, "1")
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable: %v", err)
+	}
+	m := &ServerManager{binaryPath: exe}
+	m.SetModelAPIKey("model-key")
+	t.Cleanup(func() { _ = m.Close() })
+	return m
+}
+
 type roundTripperFunc func(*http.Request) (*http.Response, error)
 
 func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -161,3 +260,271 @@ func TestResolveBinaryPath_ResolvesLatestTag(t *testing.T) {
 		t.Fatalf("expected at least 2 calls, got %d", len(calls))
 	}
 }
+
+func TestResolveBinaryPath_UsesTransport(t *testing.T) {
+	withTempHome(t)
+	setDefaultTransport(t, roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		t.Fatalf("unexpected call through the default transport: %s", req.URL.String())
+		return nil, nil
+	}))
+
+	var calls []string
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls = append(calls, req.URL.String())
+		if strings.Contains(req.URL.Host, "api.github.com") {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`[{"tag_name":"stagehand-server-v3/v9.9.9"}]`))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString("binary"))}, nil
+	})
+
+	if _, err := resolveBinaryPath("", transport); err != nil {
+		t.Fatalf("resolveBinaryPath error: %v", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected tag lookup and download through the transport, got %v", calls)
+	}
+}
+
+func TestParseListenAddress(t *testing.T) {
+	cases := []struct {
+		line string
+		want string
+		ok   bool
+	}{
+		{"Server listening at http://127.0.0.1:43567", "http://127.0.0.1:43567", true},
+		{`{"level":30,"msg":"Server listening at http://127.0.0.1:8080"}`, "http://127.0.0.1:8080", true},
+		{"listening on http://0.0.0.0:9000", "http://127.0.0.1:9000", true},
+		{"Server listening at http://[::]:9001", "http://127.0.0.1:9001", true},
+		{"Server listening at http://127.0.0.1:0", "", false},
+		{"starting stagehand server", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := parseListenAddress(tc.line)
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("parseListenAddress(%q) = %q, %v; want %q, %v", tc.line, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestListenWatcher_SplitWrites(t *testing.T) {
+	var out bytes.Buffer
+	w := newListenWatcher(&out)
+	_, _ = w.Write([]byte("booting\nServer listen"))
+	select {
+	case addr := <-w.Address():
+		t.Fatalf("unexpected address %q before line completed", addr)
+	default:
+	}
+	_, _ = w.Write([]byte("ing at http://127.0.0.1:5555\nmore\n"))
+
+	select {
+	case addr := <-w.Address():
+		if addr != "http://127.0.0.1:5555" {
+			t.Fatalf("unexpected address %q", addr)
+		}
+	default:
+		t.Fatalf("expected address after line completed")
+	}
+	if out.String() != "booting\nServer listening at http://127.0.0.1:5555\nmore\n" {
+		t.Fatalf("output not forwarded verbatim: %q", out.String())
+	}
+}
+
+func TestServerManager_DiscoversDriverPort(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake driver relies on SIGTERM")
+	}
+	m := newFakeServerManager(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	baseURL, err := m.EnsureRunning(ctx)
+	if err != nil {
+		t.Fatalf("EnsureRunning: %v", err)
+	}
+	if strings.HasSuffix(baseURL, ":0") {
+		t.Fatalf("expected a discovered port, got %s", baseURL)
+	}
+	resp, err := http.Get(baseURL + "/healthz")
+	if err != nil {
+		t.Fatalf("health check: %v", err)
+	}
+	resp.Body.Close()
+
+	again, err := m.EnsureRunning(ctx)
+	if err != nil || again != baseURL {
+		t.Fatalf("expected running driver to be reused, got %s, %v", again, err)
+	}
+}
+
+func TestServerManager_PollsHealthThroughTransport(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake driver relies on SIGTERM")
+	}
+	m := newFakeServerManager(t)
+	var probes int
+	m.transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		probes++
+		return http.DefaultTransport.RoundTrip(req)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := m.EnsureRunning(ctx); err != nil {
+		t.Fatalf("EnsureRunning: %v", err)
+	}
+	if probes == 0 {
+		t.Fatalf("expected health checks through the transport")
+	}
+}
+
+func TestServerManager_CredentialsChanged(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake driver relies on SIGTERM")
+	}
+	m := newFakeServerManager(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := m.EnsureRunning(ctx); err != nil {
+		t.Fatalf("EnsureRunning: %v", err)
+	}
+	m.SetModelAPIKey("model-key")
+	if _, err := m.EnsureRunning(ctx); err != nil {
+		t.Fatalf("expected unchanged credentials to be accepted, got %v", err)
+	}
+
+	m.SetModelAPIKey("other-key")
+	if _, err := m.EnsureRunning(ctx); !errors.Is(err, ErrCredentialsChanged) {
+		t.Fatalf("expected ErrCredentialsChanged, got %v", err)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := m.EnsureRunning(ctx); err != nil {
+		t.Fatalf("expected restart with new credentials, got %v", err)
+	}
+}
+
+func TestAcquireServerManager_SharesByCredentials(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake driver relies on SIGTERM")
+	}
+	installFakeDriver(t)
+
+	creds := Credentials{ModelAPIKey: "model-key"}
+	a, err := AcquireServerManager(creds, ShareProcess)
+	if err != nil {
+		t.Fatalf("AcquireServerManager: %v", err)
+	}
+	b, err := AcquireServerManager(creds, ShareProcess)
+	if err != nil {
+		t.Fatalf("AcquireServerManager: %v", err)
+	}
+	other, err := AcquireServerManager(Credentials{ModelAPIKey: "other-key"}, ShareProcess)
+	if err != nil {
+		t.Fatalf("AcquireServerManager: %v", err)
+	}
+	defer other.Release()
+
+	if a != b {
+		t.Fatalf("expected managers with equal credentials to be shared")
+	}
+	if a == other {
+		t.Fatalf("expected managers with different credentials to be distinct")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := a.EnsureRunning(ctx); err != nil {
+		t.Fatalf("EnsureRunning: %v", err)
+	}
+
+	if err := a.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if !b.isProcessRunning() {
+		t.Fatalf("driver stopped while a reference was still held")
+	}
+	if err := b.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if b.started {
+		t.Fatalf("driver still running after last reference was released")
+	}
+}
+
+func TestAcquireServerManager_HostScope(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sharing between processes is not supported on windows")
+	}
+	installFakeDriver(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	creds := Credentials{ModelAPIKey: "model-key"}
+	first, err := AcquireServerManager(creds, ShareHost)
+	if err != nil {
+		t.Fatalf("AcquireServerManager: %v", err)
+	}
+	baseURL, err := first.EnsureRunning(ctx)
+	if err != nil {
+		t.Fatalf("EnsureRunning: %v", err)
+	}
+
+	st, err := first.host.readState()
+	if err != nil || st == nil {
+		t.Fatalf("expected state file, got %v, %v", st, err)
+	}
+	if st.BaseURL != baseURL || st.PID != first.cmd.Process.Pid {
+		t.Fatalf("unexpected state %+v", st)
+	}
+	driverPID := st.PID
+
+	// Pretend another process is still using the driver.
+	st.Clients = append(st.Clients, os.Getppid())
+	if err := first.host.writeState(st); err != nil {
+		t.Fatalf("writeState: %v", err)
+	}
+	if err := first.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if !pidRunning(driverPID) {
+		t.Fatalf("driver stopped while another process was registered")
+	}
+
+	// A manager in a "new process" attaches to the running driver.
+	host, err := newHostShare(creds, "")
+	if err != nil {
+		t.Fatalf("newHostShare: %v", err)
+	}
+	second := &ServerManager{binaryPath: first.binaryPath, modelAPIKey: creds.ModelAPIKey, host: host}
+	attached, err := second.EnsureRunning(ctx)
+	if err != nil {
+		t.Fatalf("EnsureRunning: %v", err)
+	}
+	if attached != baseURL || host.attachedPID != driverPID {
+		t.Fatalf("expected to attach to %s (pid %d), got %s (pid %d)", baseURL, driverPID, attached, host.attachedPID)
+	}
+
+	// Once the other process is gone, the last release stops the driver.
+	st, _ = host.readState()
+	st.Clients = []int{os.Getpid()}
+	if err := host.writeState(st); err != nil {
+		t.Fatalf("writeState: %v", err)
+	}
+	if err := second.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if pidRunning(driverPID) {
+		t.Fatalf("driver still running after last process released it")
+	}
+	if _, err := os.Stat(host.statePath); !os.IsNotExist(err) {
+		t.Fatalf("expected state file to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+// Custom code. Not generated by Stainless.
+package local
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var sweepStaleDriversOnce sync.Once
+
+// driverRecord is the content of a driver pid file.
+type driverRecord struct {
+	PID int `json:"pid"`
+	// Owner is the process that started the driver.
+	Owner  int    `json:"owner"`
+	Binary string `json:"binary"`
+}
+
+// pidDir returns the directory holding one pid file per running driver.
+func pidDir() (string, error) {
+	root, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, "run", "pids"), nil
+}
+
+// writePIDFile records the driver just started. Failing to record it only
+// means it cannot be reaped by a later run, so errors are ignored.
+func (m *ServerManager) writePIDFile() {
+	dir, err := pidDir()
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return
+	}
+	record := driverRecord{PID: m.cmd.Process.Pid, Owner: os.Getpid(), Binary: m.binaryPath}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	path := filepath.Join(dir, strconv.Itoa(record.PID)+".json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return
+	}
+	m.pidFile = path
+}
+
+func (m *ServerManager) removePIDFile() {
+	if m.pidFile == "" {
+		return
+	}
+	_ = os.Remove(m.pidFile)
+	m.pidFile = ""
+}
+
+// sweepStaleDrivers stops drivers recorded by earlier runs whose owning
+// process has exited without stopping them, e.g. because it crashed or was
+// killed. A driver is only stopped if its pid still belongs to the recorded
+// binary, so a recycled pid is never signalled.
+func sweepStaleDrivers() {
+	dir, err := pidDir()
+	if err != nil {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var record driverRecord
+		if err := json.Unmarshal(data, &record); err != nil || record.PID <= 0 {
+			_ = os.Remove(path)
+			continue
+		}
+		if record.Owner != os.Getpid() && pidRunning(record.Owner) {
+			continue
+		}
+		if pidRunning(record.PID) {
+			if !isDriverProcess(record.PID, record.Binary) {
+				// Either the pid was recycled or it cannot be verified on
+				// this platform; leave the process alone.
+				if _, ok := processExecutable(record.PID); ok {
+					_ = os.Remove(path)
+				}
+				continue
+			}
+			_ = terminatePID(record.PID)
+		}
+		_ = os.Remove(path)
+	}
+}
+
+// isDriverProcess reports whether pid is running the given driver binary.
+func isDriverProcess(pid int, binary string) bool {
+	exe, ok := processExecutable(pid)
+	if !ok || binary == "" {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(binary); err == nil {
+		binary = resolved
+	}
+	return exe == binary
+}
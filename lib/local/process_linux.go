@@ -0,0 +1,26 @@
+// Custom code. Not generated by Stainless.
+
+package local
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// setParentDeathSignal asks the kernel to kill the driver if this process
+// exits. The signal is tied to the OS thread that started the driver, which is
+// fine as long as that goroutine is not locked to its thread.
+func setParentDeathSignal(attr *syscall.SysProcAttr) {
+	attr.Pdeathsig = syscall.SIGKILL
+}
+
+// processExecutable returns the path of the executable pid is running.
+func processExecutable(pid int) (string, bool) {
+	exe, err := os.Readlink("/proc/" + strconv.Itoa(pid) + "/exe")
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSuffix(exe, " (deleted)"), true
+}
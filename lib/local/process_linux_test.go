@@ -0,0 +1,140 @@
+// Custom tests. Not generated by Stainless.
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// processGone reports whether pid has exited. Zombies count as exited, since
+// they may not be reaped promptly when the test runs as a container's init.
+func processGone(pid int) bool {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true
+	}
+	fields := strings.Fields(string(data))
+	return len(fields) > 2 && fields[2] == "Z"
+}
+
+func waitGone(t *testing.T, pid int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !processGone(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("process %d still running", pid)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestServerManager_CloseStopsProcessGroup(t *testing.T) {
+	m := newFakeServerManager(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	baseURL, err := m.EnsureRunning(ctx)
+	if err != nil {
+		t.Fatalf("EnsureRunning: %v", err)
+	}
+
+	if pgid, err := syscall.Getpgid(m.cmd.Process.Pid); err != nil || pgid != m.cmd.Process.Pid {
+		t.Fatalf("expected driver to lead its own process group, got %d, %v", pgid, err)
+	}
+	if m.cmd.SysProcAttr.Pdeathsig != syscall.SIGKILL {
+		t.Fatalf("expected driver to be killed with its parent")
+	}
+
+	resp, err := http.Get(baseURL + "/child")
+	if err != nil {
+		t.Fatalf("spawn child: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	childPID, err := strconv.Atoi(string(body))
+	if err != nil {
+		t.Fatalf("unexpected child pid %q", body)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	waitGone(t, childPID)
+	if m.pidFile != "" {
+		t.Fatalf("expected pid file to be removed")
+	}
+}
+
+func TestSweepStaleDrivers(t *testing.T) {
+	withTempHome(t)
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable: %v", err)
+	}
+
+	// An owner that has already exited.
+	owner := exec.Command(exe, "-test.run=^$")
+	if err := owner.Run(); err != nil {
+		t.Fatalf("run owner: %v", err)
+	}
+
+	stalePID, err := startSleeper(func(cmd *exec.Cmd) {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	})
+	if err != nil {
+		t.Fatalf("start stale driver: %v", err)
+	}
+	alivePID, err := startSleeper(nil)
+	if err != nil {
+		t.Fatalf("start live driver: %v", err)
+	}
+	defer syscall.Kill(alivePID, syscall.SIGKILL)
+
+	dir, err := pidDir()
+	if err != nil {
+		t.Fatalf("pidDir: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	write := func(record driverRecord) string {
+		data, _ := json.Marshal(record)
+		path := filepath.Join(dir, strconv.Itoa(record.PID)+".json")
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("write pid file: %v", err)
+		}
+		return path
+	}
+	stale := write(driverRecord{PID: stalePID, Owner: owner.Process.Pid, Binary: exe})
+	owned := write(driverRecord{PID: alivePID, Owner: os.Getppid(), Binary: exe})
+	recycled := write(driverRecord{PID: os.Getppid(), Owner: owner.Process.Pid, Binary: exe})
+
+	sweepStaleDrivers()
+
+	waitGone(t, stalePID)
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected stale pid file to be removed")
+	}
+	if processGone(alivePID) {
+		t.Fatalf("driver with a live owner was stopped")
+	}
+	if _, err := os.Stat(owned); err != nil {
+		t.Fatalf("expected pid file with a live owner to be kept: %v", err)
+	}
+	if processGone(os.Getppid()) {
+		t.Fatalf("process with a recycled pid was stopped")
+	}
+	if _, err := os.Stat(recycled); !os.IsNotExist(err) {
+		t.Fatalf("expected pid file for a recycled pid to be removed")
+	}
+}
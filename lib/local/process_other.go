@@ -0,0 +1,16 @@
+//go:build !linux && !windows
+
+// Custom code. Not generated by Stainless.
+
+package local
+
+import "syscall"
+
+// setParentDeathSignal is a no-op: only Linux can kill a child when its parent
+// exits.
+func setParentDeathSignal(*syscall.SysProcAttr) {}
+
+// processExecutable cannot be determined on this platform.
+func processExecutable(int) (string, bool) {
+	return "", false
+}
@@ -5,8 +5,11 @@
 package local
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
 	"syscall"
+	"time"
 )
 
 func processRunning(cmd *exec.Cmd) bool {
@@ -18,3 +21,85 @@ func processRunning(cmd *exec.Cmd) bool {
 	err := cmd.Process.Signal(syscall.Signal(0))
 	return err == nil
 }
+
+// configureCommand places the driver in its own process group so that it and
+// the browsers it launches can be signalled together. Unless detached, the
+// driver is also killed when this process exits.
+func configureCommand(cmd *exec.Cmd, detached bool) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if !detached {
+		setParentDeathSignal(cmd.SysProcAttr)
+	}
+}
+
+// terminateProcess sends SIGTERM to the driver's process group.
+func terminateProcess(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+}
+
+// killProcess sends SIGKILL to the driver's process group. The group outlives
+// the driver itself, so this also reaches processes it leaves behind.
+func killProcess(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// signalGroup signals the process group led by pid, falling back to pid alone
+// if it does not lead a group.
+func signalGroup(pid int, sig syscall.Signal) error {
+	err := syscall.Kill(-pid, sig)
+	if err == syscall.ESRCH {
+		err = syscall.Kill(pid, sig)
+	}
+	return err
+}
+
+// pidRunning reports whether a process with the given pid exists. Unlike
+// processRunning it works for processes that are not children of this one.
+func pidRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
+
+// terminatePID stops a process that is not a child of this one, along with its
+// process group. It sends SIGTERM first, waits up to 3 seconds, then sends SIGKILL if needed.
+func terminatePID(pid int) error {
+	if err := signalGroup(pid, syscall.SIGTERM); err != nil {
+		if err == syscall.ESRCH {
+			return nil
+		}
+		return fmt.Errorf("failed to stop process %d: %w", pid, err)
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if !pidRunning(pid) {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if err := signalGroup(pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+		return fmt.Errorf("failed to kill process %d: %w", pid, err)
+	}
+	return nil
+}
+
+// lockFile takes an exclusive advisory lock on the file at path, blocking
+// until it is available. The returned function releases the lock.
+func lockFile(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -5,6 +5,8 @@
 package local
 
 import (
+	"errors"
+	"os"
 	"os/exec"
 	"syscall"
 )
@@ -14,7 +16,38 @@ func processRunning(cmd *exec.Cmd) bool {
 		return false
 	}
 
-	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(cmd.Process.Pid))
+	return pidRunning(cmd.Process.Pid)
+}
+
+// configureCommand is a no-op on Windows.
+func configureCommand(*exec.Cmd, bool) {}
+
+// terminateProcess asks the driver to stop.
+func terminateProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Signal(syscall.SIGTERM)
+}
+
+// killProcess forcibly stops the driver.
+func killProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// processExecutable cannot be determined on Windows.
+func processExecutable(int) (string, bool) {
+	return "", false
+}
+
+// stillActive is the exit code reported for a process that has not exited.
+const stillActive = 259
+
+// pidRunning reports whether a process with the given pid exists. Unlike
+// processRunning it works for processes that are not children of this one.
+func pidRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
 	if err != nil {
 		return false
 	}
@@ -25,5 +58,20 @@ func processRunning(cmd *exec.Cmd) bool {
 		return false
 	}
 
-	return exitCode == syscall.STILL_ACTIVE
+	return exitCode == stillActive
+}
+
+// terminatePID stops a process that is not a child of this one.
+func terminatePID(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return nil
+	}
+	return p.Kill()
+}
+
+// lockFile is not supported on Windows, so drivers cannot be shared between
+// processes there.
+func lockFile(path string) (func(), error) {
+	return nil, errors.New("sharing local mode between processes is not supported on windows")
 }
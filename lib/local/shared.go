@@ -0,0 +1,319 @@
+// Custom code. Not generated by Stainless.
+package local
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Credentials are the keys a local driver process is started with. Clients can
+// only share a driver when their credentials are identical.
+type Credentials struct {
+	ModelAPIKey          string
+	BrowserbaseAPIKey    string
+	BrowserbaseProjectID string
+}
+
+// fingerprint returns a stable, non-reversible identifier for the credentials.
+func (c Credentials) fingerprint() string {
+	sum := sha256.Sum256([]byte(c.ModelAPIKey + "\x00" + c.BrowserbaseAPIKey + "\x00" + c.BrowserbaseProjectID))
+	return hex.EncodeToString(sum[:])
+}
+
+// ShareScope controls how widely a local driver is shared.
+type ShareScope int
+
+const (
+	// ShareProcess shares one driver between all clients in this process that
+	// use the same credentials.
+	ShareProcess ShareScope = iota
+	// ShareHost additionally shares one driver between processes on this host,
+	// coordinated through a lock file and a state file in the cache directory.
+	ShareHost
+)
+
+var registry = struct {
+	mu       sync.Mutex
+	managers map[string]*ServerManager
+}{managers: map[string]*ServerManager{}}
+
+// AcquireServerManager returns a ServerManager shared by every caller that
+// acquires it with the same credentials and scope. Each successful call must
+// be paired with a call to [ServerManager.Release]; the driver is stopped when
+// the last reference is released.
+func AcquireServerManager(creds Credentials, scope ShareScope) (*ServerManager, error) {
+	return AcquireServerManagerForVersion(creds, scope, "")
+}
+
+// AcquireServerManagerForVersion is like [AcquireServerManager], but runs the
+// given driver release. See [ResolveBinaryPathForVersion].
+func AcquireServerManagerForVersion(creds Credentials, scope ShareScope, version string) (*ServerManager, error) {
+	return AcquireServerManagerWithOptions(creds, scope, ManagerOptions{Version: version})
+}
+
+// ManagerOptions configures a ServerManager acquired with
+// [AcquireServerManagerWithOptions].
+type ManagerOptions struct {
+	// Version is the driver release to run. See [ResolveBinaryPathForVersion].
+	Version string
+	// Transport is used to download the driver binary and to poll the
+	// driver's health, for example to go through a proxy or trust a private
+	// CA. Nil selects http.DefaultTransport. Managers are shared regardless of
+	// their transport, so a manager already acquired by another caller keeps
+	// the transport it was created with.
+	Transport http.RoundTripper
+}
+
+// AcquireServerManagerWithOptions is like [AcquireServerManager], configured
+// by opts.
+func AcquireServerManagerWithOptions(creds Credentials, scope ShareScope, opts ManagerOptions) (*ServerManager, error) {
+	if creds.ModelAPIKey == "" {
+		return nil, fmt.Errorf("MODEL_API_KEY is required for local mode")
+	}
+
+	version := opts.Version
+	key := fmt.Sprintf("%d:%s:%s", scope, creds.fingerprint(), version)
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if m, ok := registry.managers[key]; ok {
+		m.refs++
+		return m, nil
+	}
+
+	m, err := newServerManager(version, opts.Transport)
+	if err != nil {
+		return nil, err
+	}
+	m.modelAPIKey = creds.ModelAPIKey
+	m.browserbaseAPIKey = creds.BrowserbaseAPIKey
+	m.browserbaseProjectID = creds.BrowserbaseProjectID
+	if scope == ShareHost {
+		m.host, err = newHostShare(creds, version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.registryKey = key
+	m.refs = 1
+	registry.managers[key] = m
+	return m, nil
+}
+
+// Release drops a reference obtained from [AcquireServerManager] and stops the
+// driver once no references remain. For a manager created with
+// [NewServerManager], Release is equivalent to Close.
+func (m *ServerManager) Release() error {
+	registry.mu.Lock()
+	if m.registryKey == "" {
+		registry.mu.Unlock()
+		return m.Close()
+	}
+	m.refs--
+	if m.refs > 0 {
+		registry.mu.Unlock()
+		return nil
+	}
+	delete(registry.managers, m.registryKey)
+	m.registryKey = ""
+	registry.mu.Unlock()
+
+	return m.Close()
+}
+
+// hostShare locates the lock and state files that coordinate a driver shared
+// between processes.
+type hostShare struct {
+	lockPath  string
+	statePath string
+	logPath   string
+	// attachedPID is the driver started by another process that this manager
+	// is using, or zero.
+	attachedPID int
+	registered  bool
+}
+
+// hostState is the content of the state file.
+type hostState struct {
+	PID     int    `json:"pid"`
+	BaseURL string `json:"baseURL"`
+	// Clients lists the processes currently using the driver.
+	Clients []int `json:"clients"`
+}
+
+func newHostShare(creds Credentials, version string) (*hostShare, error) {
+	root, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(root, "run")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create local mode state directory: %w", err)
+	}
+	name := creds.fingerprint()[:16]
+	if version != "" {
+		name += "-" + strings.ReplaceAll(version, "/", "_")
+	}
+	return &hostShare{
+		lockPath:  filepath.Join(dir, name+".lock"),
+		statePath: filepath.Join(dir, name+".json"),
+		logPath:   filepath.Join(dir, name+".log"),
+	}, nil
+}
+
+func (h *hostShare) readState() (*hostState, error) {
+	data, err := os.ReadFile(h.statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st hostState
+	if err := json.Unmarshal(data, &st); err != nil {
+		// A corrupt state file is treated like a missing one.
+		return nil, nil
+	}
+	return &st, nil
+}
+
+func (h *hostShare) writeState(st *hostState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	tmpPath := h.statePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, h.statePath)
+}
+
+// liveClients returns the clients that are still running, with self added or
+// removed.
+func liveClients(clients []int, self int, include bool) []int {
+	out := make([]int, 0, len(clients)+1)
+	for _, pid := range clients {
+		if pid != self && pidRunning(pid) {
+			out = append(out, pid)
+		}
+	}
+	if include {
+		out = append(out, self)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// ensureHostRunningLocked attaches to a driver already started by another
+// process on this host, or starts one and records it for others to find.
+// Must be called with m.mu held.
+func (m *ServerManager) ensureHostRunningLocked(ctx context.Context) (string, error) {
+	h := m.host
+	if h.attachedPID != 0 && pidRunning(h.attachedPID) {
+		return m.baseURL, nil
+	}
+	if m.started && m.isProcessRunning() {
+		return m.baseURL, nil
+	}
+
+	unlock, err := lockFile(h.lockPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to lock local mode state: %w", err)
+	}
+	defer unlock()
+
+	st, err := h.readState()
+	if err != nil {
+		return "", fmt.Errorf("failed to read local mode state: %w", err)
+	}
+
+	self := os.Getpid()
+	client := m.healthClient()
+	if st != nil && st.PID != 0 && pidRunning(st.PID) && probeHealth(client, st.BaseURL) {
+		st.Clients = liveClients(st.Clients, self, true)
+		if err := h.writeState(st); err != nil {
+			return "", fmt.Errorf("failed to write local mode state: %w", err)
+		}
+		m.baseURL = st.BaseURL
+		h.attachedPID = st.PID
+		h.registered = true
+		return m.baseURL, nil
+	}
+
+	m.started = false
+	m.cmd = nil
+	baseURL, err := m.startLocked(ctx)
+	if err != nil {
+		return "", err
+	}
+	st = &hostState{
+		PID:     m.cmd.Process.Pid,
+		BaseURL: baseURL,
+		Clients: []int{self},
+	}
+	if err := h.writeState(st); err != nil {
+		_ = m.stopLocked()
+		return "", fmt.Errorf("failed to write local mode state: %w", err)
+	}
+	h.attachedPID = 0
+	h.registered = true
+	return baseURL, nil
+}
+
+// releaseHostLocked unregisters this process from the shared driver and stops
+// the driver if no other process is using it. Must be called with m.mu held.
+func (m *ServerManager) releaseHostLocked() error {
+	h := m.host
+	if !h.registered {
+		return nil
+	}
+
+	unlock, err := lockFile(h.lockPath)
+	if err != nil {
+		return fmt.Errorf("failed to lock local mode state: %w", err)
+	}
+	defer unlock()
+
+	h.registered = false
+	attached := h.attachedPID
+	h.attachedPID = 0
+
+	st, err := h.readState()
+	if err != nil {
+		return fmt.Errorf("failed to read local mode state: %w", err)
+	}
+	if st != nil {
+		st.Clients = liveClients(st.Clients, os.Getpid(), false)
+		if len(st.Clients) > 0 {
+			// Someone else still uses the driver. Leave it running even if
+			// this process started it, but reap it should it exit while this
+			// process is still alive.
+			if m.cmd != nil && m.cmd.Process != nil {
+				go func(cmd *exec.Cmd) { _ = cmd.Wait() }(m.cmd)
+			}
+			m.started = false
+			m.cmd = nil
+			return h.writeState(st)
+		}
+		_ = os.Remove(h.statePath)
+	}
+
+	if attached != 0 {
+		return terminatePID(attached)
+	}
+	return m.stopLocked()
+}
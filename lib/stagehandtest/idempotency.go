@@ -0,0 +1,70 @@
+// Custom code. Not generated by Stainless.
+
+// Package stagehandtest provides helpers for testing code that uses the
+// Stagehand client against a fake server built with net/http/httptest.
+package stagehandtest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// IdempotentHandler wraps a fake server handler so that requests repeating an
+// Idempotency-Key already seen are answered with the recorded response
+// instead of being handled again, as the API does. Requests without the header
+// are passed through.
+type IdempotentHandler struct {
+	Handler http.Handler
+
+	mu      sync.Mutex
+	entries map[string]*idempotentEntry
+}
+
+type idempotentEntry struct {
+	done   chan struct{}
+	header http.Header
+	status int
+	body   []byte
+}
+
+func (h *IdempotentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" {
+		h.Handler.ServeHTTP(w, r)
+		return
+	}
+
+	h.mu.Lock()
+	if h.entries == nil {
+		h.entries = map[string]*idempotentEntry{}
+	}
+	entry, seen := h.entries[key]
+	if !seen {
+		entry = &idempotentEntry{done: make(chan struct{})}
+		h.entries[key] = entry
+	}
+	h.mu.Unlock()
+
+	if !seen {
+		rec := httptest.NewRecorder()
+		h.Handler.ServeHTTP(rec, r)
+		entry.header = rec.Header().Clone()
+		entry.status = rec.Code
+		entry.body = rec.Body.Bytes()
+		close(entry.done)
+	} else {
+		// A concurrent duplicate waits for the original to finish.
+		<-entry.done
+	}
+
+	for k, v := range entry.header {
+		w.Header()[k] = v
+	}
+	if seen {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+	w.WriteHeader(entry.status)
+	_, _ = bytes.NewReader(entry.body).WriteTo(w)
+}
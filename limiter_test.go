@@ -0,0 +1,135 @@
+// Custom tests. Not generated by Stainless.
+package stagehand_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+func TestLimiterMaxConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	limiter := option.NewLimiter(option.LimiterConfig{MaxConcurrency: 2})
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		limiter,
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					mu.Lock()
+					inFlight++
+					peak = max(peak, inFlight)
+					mu.Unlock()
+					time.Sleep(20 * time.Millisecond)
+					mu.Lock()
+					inFlight--
+					mu.Unlock()
+					return jsonResponse(http.StatusOK, `{"success":true,"data":{"available":true,"sessionId":"session"}}`), nil
+				},
+			},
+		}),
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Sessions.Start(context.Background(), stagehand.SessionStartParams{ModelName: "openai/gpt-5.4-mini"}); err != nil {
+				t.Errorf("Start: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak != 2 {
+		t.Fatalf("expected at most 2 requests in flight, saw %d", peak)
+	}
+	stats := limiter.Stats()
+	if stats.Requests != 6 || stats.Delayed == 0 || stats.TotalWait == 0 || stats.InFlight != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestLimiterRateAndRetryAfter(t *testing.T) {
+	status := http.StatusOK
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(0),
+		option.WithRateLimit(20, 1),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					res := jsonResponse(status, `{"success":true,"data":{"available":true,"sessionId":"session"}}`)
+					if status == http.StatusTooManyRequests {
+						res.Header.Set("Retry-After-Ms", "200")
+					}
+					return res, nil
+				},
+			},
+		}),
+	)
+	start := func() {
+		_, _ = client.Sessions.Start(context.Background(), stagehand.SessionStartParams{ModelName: "openai/gpt-5.4-mini"})
+	}
+
+	began := time.Now()
+	for i := 0; i < 3; i++ {
+		start()
+	}
+	if elapsed := time.Since(began); elapsed < 90*time.Millisecond {
+		t.Fatalf("expected 3 requests at 20 rps to take at least 100ms, took %v", elapsed)
+	}
+
+	status = http.StatusTooManyRequests
+	start()
+	status = http.StatusOK
+	began = time.Now()
+	start()
+	if elapsed := time.Since(began); elapsed < 190*time.Millisecond {
+		t.Fatalf("expected the limiter to honour Retry-After-Ms, waited %v", elapsed)
+	}
+}
+
+func TestLimiterClampsRetryAfter(t *testing.T) {
+	status := http.StatusTooManyRequests
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(0),
+		option.NewLimiter(option.LimiterConfig{MaxPause: 100 * time.Millisecond}),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					res := jsonResponse(status, `{"success":true,"data":{"available":true,"sessionId":"session"}}`)
+					res.Header.Set("Retry-After", "3600")
+					return res, nil
+				},
+			},
+		}),
+	)
+	start := func(ctx context.Context) error {
+		_, err := client.Sessions.Start(ctx, stagehand.SessionStartParams{ModelName: "openai/gpt-5.4-mini"})
+		return err
+	}
+
+	_ = start(context.Background())
+	status = http.StatusOK
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	began := time.Now()
+	if err := start(ctx); err != nil {
+		t.Fatalf("expected the pause to end after MaxPause, got %v", err)
+	}
+	if elapsed := time.Since(began); elapsed < 90*time.Millisecond || elapsed > 2*time.Second {
+		t.Fatalf("expected a pause of about 100ms, waited %v", elapsed)
+	}
+}
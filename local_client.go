@@ -3,88 +3,142 @@ package stagehand
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
 	"github.com/browserbase/stagehand-go/v3/lib/local"
 	"github.com/browserbase/stagehand-go/v3/option"
 )
 
+var (
+	// localDriverIdleTimeout is how long a local driver may go without
+	// requests or open sessions before it is released.
+	localDriverIdleTimeout = 5 * time.Minute
+	// localDriverReplaceGrace is how long a local driver must have been unused
+	// before a driver for other credentials replaces it.
+	localDriverReplaceGrace = 10 * time.Second
+)
+
+// localServerOption routes requests to a local driver. Each distinct set of
+// credentials gets its own driver, since credentials are fixed when a driver
+// starts. A driver is released once it sits idle, or once a driver for other
+// credentials replaces it while it has no requests or sessions in flight.
 type localServerOption struct {
-	mu      sync.Mutex
-	manager *local.ServerManager
-	initErr error
+	mu        sync.Mutex
+	scope     local.ShareScope
+	version   string
+	transport http.RoundTripper
+	drivers   map[local.Credentials]*localDriver
+}
+
+// localDriver is a local driver acquired for one set of credentials, with what
+// is needed to tell whether it is still in use.
+type localDriver struct {
+	manager  *local.ServerManager
+	sessions *sessionTracker
+	inflight int
+	lastUsed time.Time
+	timer    *time.Timer
 }
 
-func newLocalServerOption() *localServerOption {
-	return &localServerOption{}
+// idle reports whether the driver has had no requests or open sessions for at
+// least after. The caller must hold the option's lock.
+func (d *localDriver) idle(after time.Duration) bool {
+	return d.inflight == 0 && len(d.sessions.open()) == 0 && time.Since(d.lastUsed) >= after
+}
+
+func newLocalServerOption(scope local.ShareScope, version string, transport http.RoundTripper) *localServerOption {
+	return &localServerOption{
+		scope:     scope,
+		version:   version,
+		transport: transport,
+		drivers:   map[local.Credentials]*localDriver{},
+	}
 }
 
 func (o *localServerOption) Apply(cfg *requestconfig.RequestConfig) error {
-	var modelAPIKey string
-	var browserbaseAPIKey string
-	var browserbaseProjectID string
-	if cfg != nil {
-		modelAPIKey = cfg.ModelAPIKey
-		browserbaseAPIKey = cfg.BrowserbaseAPIKey
-		browserbaseProjectID = cfg.BrowserbaseProjectID
-	}
-	if modelAPIKey == "" {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.ModelAPIKey == "" {
 		if key := os.Getenv("MODEL_API_KEY"); key != "" {
-			modelAPIKey = key
-		}
-		if modelAPIKey != "" && cfg != nil {
-			if err := option.WithModelAPIKey(modelAPIKey).Apply(cfg); err != nil {
+			if err := option.WithModelAPIKey(key).Apply(cfg); err != nil {
 				return err
 			}
 		}
 	}
-	if browserbaseAPIKey == "" {
+	if cfg.BrowserbaseAPIKey == "" {
 		if key := os.Getenv("BROWSERBASE_API_KEY"); key != "" {
-			browserbaseAPIKey = key
-			if cfg != nil {
-				if err := option.WithBrowserbaseAPIKey(browserbaseAPIKey).Apply(cfg); err != nil {
-					return err
-				}
+			if err := option.WithBrowserbaseAPIKey(key).Apply(cfg); err != nil {
+				return err
 			}
 		}
 	}
-	if browserbaseProjectID == "" {
+	if cfg.BrowserbaseProjectID == "" {
 		if key := os.Getenv("BROWSERBASE_PROJECT_ID"); key != "" {
-			browserbaseProjectID = key
-			if cfg != nil {
-				if err := option.WithBrowserbaseProjectID(browserbaseProjectID).Apply(cfg); err != nil {
-					return err
-				}
+			if err := option.WithBrowserbaseProjectID(key).Apply(cfg); err != nil {
+				return err
 			}
 		}
 	}
-	if modelAPIKey == "" {
+
+	// Requests to absolute URLs, such as those to the Browserbase API, are not
+	// for the driver.
+	if cfg.Request != nil && cfg.Request.URL.IsAbs() {
+		return nil
+	}
+
+	// Request-specific options are applied after this client-level option, so
+	// the driver is only picked once the request's credentials are final.
+	cfg.PostApply = append(cfg.PostApply, o.route)
+	return nil
+}
+
+// route points the request at the driver for its credentials, starting the
+// driver if needed.
+func (o *localServerOption) route(cfg *requestconfig.RequestConfig) error {
+	if cfg.ModelAPIKey == "" {
 		return fmt.Errorf("MODEL_API_KEY is required for local mode")
 	}
 
-	manager, err := o.ensureManager()
+	driver, err := o.ensureDriver(local.Credentials{
+		ModelAPIKey:          cfg.ModelAPIKey,
+		BrowserbaseAPIKey:    cfg.BrowserbaseAPIKey,
+		BrowserbaseProjectID: cfg.BrowserbaseProjectID,
+	})
 	if err != nil {
 		return err
 	}
-	if modelAPIKey != "" {
-		manager.SetModelAPIKey(modelAPIKey)
-	}
-	if browserbaseAPIKey != "" {
-		manager.SetBrowserbaseAPIKey(browserbaseAPIKey)
-	}
-	if browserbaseProjectID != "" {
-		manager.SetBrowserbaseProjectID(browserbaseProjectID)
+
+	// The driver is kept while the request runs, retries included, and while
+	// sessions it started are open.
+	if err := driver.sessions.Apply(cfg); err != nil {
+		return err
 	}
+	cfg.Around = append(cfg.Around, func(_ *requestconfig.RequestConfig, execute func() error) error {
+		o.mu.Lock()
+		driver.inflight++
+		o.mu.Unlock()
+		defer func() {
+			o.mu.Lock()
+			driver.inflight--
+			driver.lastUsed = time.Now()
+			o.mu.Unlock()
+		}()
+		return execute()
+	})
 
 	ctx := cfg.Context
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	baseURL, err := manager.EnsureRunning(ctx)
+	baseURL, err := driver.manager.EnsureRunning(ctx)
 	if err != nil {
 		return err
 	}
@@ -94,32 +148,85 @@ func (o *localServerOption) Apply(cfg *requestconfig.RequestConfig) error {
 
 func (o *localServerOption) Close() error {
 	o.mu.Lock()
-	manager := o.manager
+	drivers := o.drivers
+	o.drivers = map[local.Credentials]*localDriver{}
+	for _, driver := range drivers {
+		driver.timer.Stop()
+	}
 	o.mu.Unlock()
 
-	if manager == nil {
-		return nil
+	var errs []error
+	for _, driver := range drivers {
+		if err := driver.manager.Release(); err != nil {
+			errs = append(errs, err)
+		}
 	}
-
-	return manager.Close()
+	return errors.Join(errs...)
 }
 
-func (o *localServerOption) ensureManager() (*local.ServerManager, error) {
+// ensureDriver acquires the driver shared by clients with the given
+// credentials the first time they are used, and releases the drivers for
+// other credentials that are no longer in use. Failures to acquire a driver
+// are not remembered, so the next request tries again.
+func (o *localServerOption) ensureDriver(creds local.Credentials) (*localDriver, error) {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
-	if o.manager != nil || o.initErr != nil {
-		return o.manager, o.initErr
+	if driver, ok := o.drivers[creds]; ok {
+		driver.lastUsed = time.Now()
+		return driver, nil
 	}
 
-	manager, err := local.NewServerManager()
+	manager, err := local.AcquireServerManagerWithOptions(creds, o.scope, local.ManagerOptions{
+		Version:   o.version,
+		Transport: o.transport,
+	})
 	if err != nil {
-		o.initErr = err
 		return nil, err
 	}
 
-	o.manager = manager
-	return manager, nil
+	for other, driver := range o.drivers {
+		if driver.idle(localDriverReplaceGrace) {
+			o.releaseLocked(other, driver)
+		}
+	}
+
+	driver := &localDriver{
+		manager:  manager,
+		sessions: newSessionTracker(),
+		lastUsed: time.Now(),
+	}
+	driver.timer = time.AfterFunc(localDriverIdleTimeout, func() { o.releaseIfIdle(creds, driver) })
+	o.drivers[creds] = driver
+	return driver, nil
+}
+
+// releaseIfIdle releases the driver if it has been idle for
+// localDriverIdleTimeout, and checks again later otherwise.
+func (o *localServerOption) releaseIfIdle(creds local.Credentials, driver *localDriver) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.drivers[creds] != driver {
+		return
+	}
+	if driver.idle(localDriverIdleTimeout) {
+		o.releaseLocked(creds, driver)
+		return
+	}
+	wait := localDriverIdleTimeout - time.Since(driver.lastUsed)
+	if wait <= 0 {
+		wait = localDriverIdleTimeout
+	}
+	driver.timer.Reset(wait)
+}
+
+// releaseLocked forgets the driver and releases it in the background, since
+// stopping a driver can take a while. The caller must hold the lock.
+func (o *localServerOption) releaseLocked(creds local.Credentials, driver *localDriver) {
+	delete(o.drivers, creds)
+	driver.timer.Stop()
+	go func() { _ = driver.manager.Release() }()
 }
 
 func serverModeFromOptions(opts []option.RequestOption) string {
@@ -132,15 +239,39 @@ func serverModeFromOptions(opts []option.RequestOption) string {
 	return mode
 }
 
-// Close shuts down any local mode processes associated with this client.
-func (c Client) Close() error {
-	var firstErr error
-	for _, opt := range c.Options {
-		if closer, ok := opt.(interface{ Close() error }); ok {
-			if err := closer.Close(); err != nil && firstErr == nil {
-				firstErr = err
-			}
+func localServerScopeFromOptions(opts []option.RequestOption) local.ShareScope {
+	scope := local.ShareProcess
+	for _, opt := range opts {
+		scopeOpt, ok := opt.(option.LocalServerScopeOption)
+		if !ok {
+			continue
+		}
+		switch scopeOpt.LocalServerScope() {
+		case "host":
+			scope = local.ShareHost
+		case "process":
+			scope = local.ShareProcess
 		}
 	}
-	return firstErr
+	return scope
+}
+
+func localDriverVersionFromOptions(opts []option.RequestOption) string {
+	version := ""
+	for _, opt := range opts {
+		if versionOpt, ok := opt.(option.LocalDriverVersionOption); ok {
+			version = versionOpt.LocalDriverVersion()
+		}
+	}
+	return version
+}
+
+// Close ends any sessions started through this client that are still open and
+// shuts down any local mode processes associated with this client. Ending
+// sessions is bounded by a 10 second timeout; use [Client.Shutdown] to control
+// the deadline.
+func (c Client) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloseTimeout)
+	defer cancel()
+	return c.Shutdown(ctx)
 }
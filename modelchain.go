@@ -0,0 +1,24 @@
+// Custom code. Not generated by Stainless.
+package stagehand
+
+import (
+	"github.com/tidwall/gjson"
+)
+
+// ServedModel returns the model that served the call when it was made with
+// option.WithModelChain, or an empty string otherwise.
+func (r SessionActResponse) ServedModel() string {
+	return gjson.Get(r.RawJSON(), "servedModel").String()
+}
+
+// ServedModel returns the model that served the call when it was made with
+// option.WithModelChain, or an empty string otherwise.
+func (r SessionObserveResponse) ServedModel() string {
+	return gjson.Get(r.RawJSON(), "servedModel").String()
+}
+
+// ServedModel returns the model that served the call when it was made with
+// option.WithModelChain, or an empty string otherwise.
+func (r SessionExtractResponse) ServedModel() string {
+	return gjson.Get(r.RawJSON(), "servedModel").String()
+}
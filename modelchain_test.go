@@ -0,0 +1,221 @@
+// Custom tests. Not generated by Stainless.
+package stagehand_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+	"github.com/tidwall/gjson"
+)
+
+func TestModelChain(t *testing.T) {
+	tried := []string{}
+	keys := []string{}
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(0),
+		option.WithModelProviderAPIKey("anthropic", "anthropic-key"),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					body, _ := io.ReadAll(req.Body)
+					model := gjson.GetBytes(body, "options.model")
+					name := model.String()
+					if model.IsObject() {
+						name = model.Get("modelName").String()
+					}
+					tried = append(tried, name)
+					keys = append(keys, model.Get("apiKey").String())
+					switch name {
+					case "openai/gpt-5.4-mini":
+						return jsonResponse(http.StatusTooManyRequests, `{"success":false,"name":"AI_APICallError","message":"Rate limit reached"}`), nil
+					case "google/gemini-2.5-flash":
+						return jsonResponse(http.StatusServiceUnavailable, `{"success":false,"error":{"message":"overloaded","provider":"google"}}`), nil
+					}
+					return jsonResponse(http.StatusOK, `{"success":true,"data":{"result":{"success":true,"message":"ok","actionDescription":"click","actions":[]}}}`), nil
+				},
+			},
+		}),
+	)
+
+	res, err := client.Sessions.Act(context.Background(), "session", stagehand.SessionActParams{
+		Input: stagehand.SessionActParamsInputUnion{OfString: stagehand.String("click")},
+	}, option.WithModelChain(
+		"openai/gpt-5.4-mini",
+		stagehand.ModelConfigParam{ModelName: "google/gemini-2.5-flash"},
+		"anthropic/claude-haiku-4-5",
+	))
+	if err != nil {
+		t.Fatalf("Act: %v", err)
+	}
+	if want := []string{"openai/gpt-5.4-mini", "google/gemini-2.5-flash", "anthropic/claude-haiku-4-5"}; !reflect.DeepEqual(tried, want) {
+		t.Fatalf("expected models %v to be tried, got %v", want, tried)
+	}
+	if keys[2] != "anthropic-key" {
+		t.Fatalf("expected the provider key to be added, got %q", keys[2])
+	}
+	if got := res.ServedModel(); got != "anthropic/claude-haiku-4-5" {
+		t.Fatalf("expected the served model to be recorded, got %q", got)
+	}
+	if !res.Data.Result.Success {
+		t.Fatalf("expected the result to be decoded")
+	}
+
+	tried = nil
+	_, err = client.Sessions.Observe(context.Background(), "session", stagehand.SessionObserveParams{},
+		option.WithModelChain("openai/gpt-5.4-mini", "google/gemini-2.5-flash"))
+	if err == nil || len(tried) != 2 {
+		t.Fatalf("expected the last failure to be returned after trying both models, got %v after %v", err, tried)
+	}
+}
+
+func TestModelChain_RetriesAndBreakerPerModel(t *testing.T) {
+	var mu sync.Mutex
+	tried := []string{}
+	apiRateLimited := false
+	breaker := option.NewCircuitBreaker(option.CircuitBreakerConfig{MinRequests: 1, FailureRatio: 1})
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(2),
+		option.WithRetryPolicy(option.ExponentialRetryPolicy(time.Millisecond, time.Millisecond, 0)),
+		breaker,
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					body, _ := io.ReadAll(req.Body)
+					name := gjson.GetBytes(body, "options.model").String()
+					tried = append(tried, name)
+					if apiRateLimited {
+						return jsonResponse(http.StatusTooManyRequests, `{"success":false,"message":"Too many requests"}`), nil
+					}
+					if name == "openai/gpt-5.4-mini" {
+						return jsonResponse(http.StatusInternalServerError, `{"success":false,"error":{"name":"AI_APICallError","message":"upstream failed"}}`), nil
+					}
+					return jsonResponse(http.StatusOK, `{"success":true,"data":{"result":[]}}`), nil
+				},
+			},
+		}),
+	)
+	chain := option.WithModelChain("openai/gpt-5.4-mini", "anthropic/claude-haiku-4-5")
+
+	// A provider failure moves on to the next model without retrying.
+	res, err := client.Sessions.Observe(context.Background(), "session", stagehand.SessionObserveParams{}, chain)
+	if err != nil {
+		t.Fatalf("Observe: %v", err)
+	}
+	if want := []string{"openai/gpt-5.4-mini", "anthropic/claude-haiku-4-5"}; !reflect.DeepEqual(tried, want) {
+		t.Fatalf("expected models %v to be tried, got %v", want, tried)
+	}
+	if got := res.ServedModel(); got != "anthropic/claude-haiku-4-5" {
+		t.Fatalf("expected the served model to be recorded, got %q", got)
+	}
+
+	// The breaker tracks each model of the chain.
+	if state := breaker.State(option.CircuitKey{BaseURL: "http://stagehand.test", Model: "openai/gpt-5.4-mini"}); state != option.CircuitOpen {
+		t.Fatalf("expected the failing model's circuit to be open, got %v", state)
+	}
+	if state := breaker.State(option.CircuitKey{BaseURL: "http://stagehand.test", Model: "anthropic/claude-haiku-4-5"}); state != option.CircuitClosed {
+		t.Fatalf("expected the fallback model's circuit to be closed, got %v", state)
+	}
+	tried = nil
+	if _, err := client.Sessions.Observe(context.Background(), "session", stagehand.SessionObserveParams{}, chain); err != nil {
+		t.Fatalf("Observe with an open circuit: %v", err)
+	}
+	if want := []string{"anthropic/claude-haiku-4-5"}; !reflect.DeepEqual(tried, want) {
+		t.Fatalf("expected the open circuit to be skipped, got %v", tried)
+	}
+
+	// Errors of the Stagehand API itself are retried on the same model and
+	// do not fall back.
+	tried = nil
+	apiRateLimited = true
+	_, err = client.Sessions.Observe(context.Background(), "session", stagehand.SessionObserveParams{},
+		option.WithModelChain("google/gemini-2.5-flash", "anthropic/claude-haiku-4-5"))
+	var apiErr *stagehand.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected the rate limit error, got %v", err)
+	}
+	if want := []string{"google/gemini-2.5-flash", "google/gemini-2.5-flash", "google/gemini-2.5-flash"}; !reflect.DeepEqual(tried, want) {
+		t.Fatalf("expected the first model to be retried, got %v", tried)
+	}
+}
+
+func TestModelChain_ProviderNamedInMessageIsNotAFailure(t *testing.T) {
+	tried := []string{}
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(0),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					body, _ := io.ReadAll(req.Body)
+					tried = append(tried, gjson.GetBytes(body, "options.model").String())
+					return jsonResponse(http.StatusServiceUnavailable, `{"success":false,"message":"cannot reach openai/gpt-5.4-mini: Stagehand is restarting"}`), nil
+				},
+			},
+		}),
+	)
+
+	_, err := client.Sessions.Observe(context.Background(), "session", stagehand.SessionObserveParams{},
+		option.WithModelChain("openai/gpt-5.4-mini", "anthropic/claude-haiku-4-5"))
+	if err == nil || !reflect.DeepEqual(tried, []string{"openai/gpt-5.4-mini"}) {
+		t.Fatalf("expected only the first model to be tried, got %v after %v", err, tried)
+	}
+}
+
+func TestModelChain_IdempotencyKeyPerModel(t *testing.T) {
+	sent := []string{}
+	seen := []string{}
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithRetryPolicy(option.ExponentialRetryPolicy(time.Millisecond, time.Millisecond, 0)),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					sent = append(sent, req.Header.Get("Idempotency-Key"))
+					body, _ := io.ReadAll(req.Body)
+					if gjson.GetBytes(body, "options.model").String() == "openai/gpt-5.4-mini" {
+						return jsonResponse(http.StatusInternalServerError, `{"success":false,"name":"AI_APICallError"}`), nil
+					}
+					if len(sent) == 2 {
+						// The fallback model is retried once.
+						return jsonResponse(http.StatusBadGateway, `{"success":false}`), nil
+					}
+					return jsonResponse(http.StatusOK, `{"success":true,"data":{"result":{"success":true,"message":"ok","actionDescription":"click","actions":[]}}}`), nil
+				},
+			},
+		}),
+	)
+
+	_, err := client.Sessions.Act(context.Background(), "session", stagehand.SessionActParams{
+		Input: stagehand.SessionActParamsInputUnion{OfString: stagehand.String("click")},
+	}, option.WithIdempotencyKey("order-42"), option.WithModelChain("openai/gpt-5.4-mini", "anthropic/claude-haiku-4-5"),
+		option.WithMiddleware(func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+			res, err := next(req)
+			seen = append(seen, req.Header.Get("Idempotency-Key"))
+			return res, err
+		}))
+	if err != nil {
+		t.Fatalf("Act: %v", err)
+	}
+	if want := []string{"order-42", "order-42-1", "order-42-1"}; !reflect.DeepEqual(sent, want) {
+		t.Fatalf("expected keys %v, got %v", want, sent)
+	}
+	if !reflect.DeepEqual(seen, sent) {
+		t.Fatalf("expected middlewares to see the keys sent, got %v", seen)
+	}
+}
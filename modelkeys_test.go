@@ -0,0 +1,75 @@
+// Custom tests. Not generated by Stainless.
+package stagehand_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+	"github.com/tidwall/gjson"
+)
+
+func TestModelProviderAPIKeys(t *testing.T) {
+	var header string
+	var body []byte
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("default-key"),
+		option.WithModelProviderAPIKeys(map[string]string{
+			"anthropic": "anthropic-key",
+			"google":    "google-key",
+		}),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					header = req.Header.Get("x-model-api-key")
+					body, _ = io.ReadAll(req.Body)
+					return jsonResponse(http.StatusOK, `{"success":true,"data":{"available":true,"sessionId":"session"}}`), nil
+				},
+			},
+		}),
+	)
+	ctx := context.Background()
+
+	_, _ = client.Sessions.Start(ctx, stagehand.SessionStartParams{ModelName: "anthropic/claude-sonnet-4-5"})
+	if header != "anthropic-key" {
+		t.Fatalf("expected the anthropic key for the session, got %q", header)
+	}
+	_, _ = client.Sessions.Start(ctx, stagehand.SessionStartParams{ModelName: "openai/gpt-5.4-mini"})
+	if header != "default-key" {
+		t.Fatalf("expected the default key for an unmapped provider, got %q", header)
+	}
+
+	_, _ = client.Sessions.Act(ctx, "session", stagehand.SessionActParams{
+		Input: stagehand.SessionActParamsInputUnion{OfString: stagehand.String("click")},
+		Options: stagehand.SessionActParamsOptions{
+			Model: stagehand.SessionActParamsOptionsModelUnion{OfString: stagehand.String("anthropic/claude-haiku-4-5")},
+		},
+	})
+	if got := gjson.GetBytes(body, "options.model").Raw; got != `{"apiKey":"anthropic-key","modelName":"anthropic/claude-haiku-4-5"}` {
+		t.Fatalf("unexpected act model %s", got)
+	}
+
+	_, _ = client.Sessions.Execute(ctx, "session", stagehand.SessionExecuteParams{
+		AgentConfig: stagehand.SessionExecuteParamsAgentConfig{
+			Model: stagehand.SessionExecuteParamsAgentConfigModelUnion{OfModelConfig: &stagehand.ModelConfigParam{
+				ModelName: "claude-sonnet-4-5",
+				Provider:  stagehand.ModelConfigProviderAnthropic,
+				APIKey:    stagehand.String("explicit-key"),
+			}},
+			ExecutionModel: stagehand.SessionExecuteParamsAgentConfigExecutionModelUnion{OfModelConfig: &stagehand.ModelConfigParam{
+				ModelName: "google/gemini-2.5-flash",
+			}},
+		},
+		ExecuteOptions: stagehand.SessionExecuteParamsExecuteOptions{Instruction: "buy"},
+	})
+	if got := gjson.GetBytes(body, "agentConfig.model.apiKey").String(); got != "explicit-key" {
+		t.Fatalf("expected an explicit key to be kept, got %q", got)
+	}
+	if got := gjson.GetBytes(body, "agentConfig.executionModel.apiKey").String(); got != "google-key" {
+		t.Fatalf("expected the google key for the execution model, got %q", got)
+	}
+}
@@ -0,0 +1,295 @@
+// Custom code. Not generated by Stainless.
+package option
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3/internal/apierror"
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+	"github.com/tidwall/gjson"
+)
+
+// CircuitOpenError is returned without sending the request when the circuit
+// breaker for its base URL and model is open. Requests failing with it are not
+// retried.
+type CircuitOpenError = apierror.CircuitOpenError
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails requests immediately with a [CircuitOpenError].
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe requests through to
+	// find out whether the target has recovered.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitKey identifies the target a circuit breaker tracks. Requests that do
+// not name a model share the key with an empty Model.
+type CircuitKey struct {
+	BaseURL string
+	Model   string
+}
+
+// CircuitBreakerConfig configures a [CircuitBreaker]. Zero values select the
+// documented defaults.
+type CircuitBreakerConfig struct {
+	// FailureRatio is the share of failed requests within Window that opens
+	// the circuit. Defaults to 0.5.
+	FailureRatio float64
+	// MinRequests is the number of requests within Window required before the
+	// circuit can open. Defaults to 10.
+	MinRequests int
+	// Window is the period over which failures are counted. Defaults to 1
+	// minute.
+	Window time.Duration
+	// OpenTimeout is how long the circuit stays open before probing.
+	// Defaults to 30 seconds.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of probe requests that must succeed to
+	// close the circuit again. Defaults to 1.
+	HalfOpenProbes int
+	// IsFailure decides whether an attempt counts as a failure. By default
+	// transport errors and 5xx responses do. Attempts cancelled through their
+	// context count as neither a failure nor a success, whatever IsFailure
+	// says.
+	IsFailure func(res *http.Response, err error) bool
+	// OnStateChange, if set, is called whenever a circuit changes state.
+	OnStateChange func(key CircuitKey, from, to CircuitState)
+}
+
+// CircuitBreaker is a RequestOption that stops sending requests to a base URL
+// and model whose recent requests mostly failed, so callers fail fast instead
+// of waiting through every retry. Once the circuit has been open for
+// OpenTimeout, probe requests are let through and the circuit closes again if
+// they succeed.
+//
+// A CircuitBreaker given to several clients tracks their requests together.
+type CircuitBreaker struct {
+	cfg CircuitBreakerConfig
+
+	mu       sync.Mutex
+	circuits map[CircuitKey]*circuit
+}
+
+type circuit struct {
+	state       CircuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	successes   int
+}
+
+// NewCircuitBreaker returns a CircuitBreaker with the given configuration.
+func NewCircuitBreaker(cfg CircuitBreakerConfig) *CircuitBreaker {
+	if cfg.FailureRatio <= 0 {
+		cfg.FailureRatio = 0.5
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 10
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = time.Minute
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = 1
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = isCircuitFailure
+	}
+	return &CircuitBreaker{cfg: cfg, circuits: map[CircuitKey]*circuit{}}
+}
+
+func isCircuitFailure(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return res != nil && res.StatusCode >= http.StatusInternalServerError
+}
+
+func (b *CircuitBreaker) Apply(r *requestconfig.RequestConfig) error {
+	r.Middlewares = append(r.Middlewares, b.middleware)
+	return nil
+}
+
+// State returns the current state of the circuit for key.
+func (b *CircuitBreaker) State(key CircuitKey) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[key]; ok {
+		b.advanceLocked(key, c, time.Now())
+		return c.state
+	}
+	return CircuitClosed
+}
+
+func (b *CircuitBreaker) middleware(req *http.Request, next MiddlewareNext) (*http.Response, error) {
+	key := CircuitKey{BaseURL: req.URL.Scheme + "://" + req.URL.Host, Model: requestModel(req)}
+
+	probe, err := b.admit(key)
+	if err != nil {
+		return nil, err
+	}
+	res, err := next(req)
+	if errors.Is(err, context.Canceled) {
+		// The caller gave up, which says nothing about the target.
+		b.abandon(key, probe)
+		return res, err
+	}
+	b.record(key, probe, b.cfg.IsFailure(res, err))
+	return res, err
+}
+
+// admit reports whether the request may be sent, and whether it is a probe.
+func (b *CircuitBreaker) admit(key CircuitKey) (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{windowStart: now}
+		b.circuits[key] = c
+	}
+	b.advanceLocked(key, c, now)
+
+	switch c.state {
+	case CircuitOpen:
+		return false, &CircuitOpenError{BaseURL: key.BaseURL, Model: key.Model, RetryAt: c.openedAt.Add(b.cfg.OpenTimeout)}
+	case CircuitHalfOpen:
+		if c.probes >= b.cfg.HalfOpenProbes {
+			// The probes decide when the circuit admits requests again.
+			return false, &CircuitOpenError{BaseURL: key.BaseURL, Model: key.Model}
+		}
+		c.probes++
+		return true, nil
+	}
+	return false, nil
+}
+
+func (b *CircuitBreaker) record(key CircuitKey, probe bool, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuits[key]
+	now := time.Now()
+	if probe {
+		if c.state != CircuitHalfOpen {
+			return
+		}
+		if failed {
+			b.setStateLocked(key, c, CircuitOpen, now)
+			return
+		}
+		c.successes++
+		if c.successes >= b.cfg.HalfOpenProbes {
+			b.setStateLocked(key, c, CircuitClosed, now)
+		}
+		return
+	}
+	if c.state != CircuitClosed {
+		return
+	}
+
+	if now.Sub(c.windowStart) > b.cfg.Window {
+		c.windowStart, c.requests, c.failures = now, 0, 0
+	}
+	c.requests++
+	if failed {
+		c.failures++
+	}
+	if c.requests >= b.cfg.MinRequests && float64(c.failures)/float64(c.requests) >= b.cfg.FailureRatio {
+		b.setStateLocked(key, c, CircuitOpen, now)
+	}
+}
+
+// abandon frees the probe slot taken by a request that was cancelled, so
+// another request can probe the target.
+func (b *CircuitBreaker) abandon(key CircuitKey, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c := b.circuits[key]; probe && c.state == CircuitHalfOpen && c.probes > 0 {
+		c.probes--
+	}
+}
+
+// advanceLocked moves an open circuit to half-open once OpenTimeout passed.
+func (b *CircuitBreaker) advanceLocked(key CircuitKey, c *circuit, now time.Time) {
+	if c.state == CircuitOpen && now.Sub(c.openedAt) >= b.cfg.OpenTimeout {
+		b.setStateLocked(key, c, CircuitHalfOpen, now)
+	}
+}
+
+func (b *CircuitBreaker) setStateLocked(key CircuitKey, c *circuit, to CircuitState, now time.Time) {
+	from := c.state
+	c.state = to
+	c.probes, c.successes = 0, 0
+	switch to {
+	case CircuitOpen:
+		c.openedAt = now
+	case CircuitClosed:
+		c.windowStart, c.requests, c.failures = now, 0, 0
+	}
+	if b.cfg.OnStateChange != nil && from != to {
+		// Called with the lock held so that callbacks observe transitions in
+		// order; they must not use the breaker.
+		b.cfg.OnStateChange(key, from, to)
+	}
+}
+
+// modelPaths are the request body fields that name the model, in order of
+// preference. Each may hold a model name or a model configuration object.
+var modelPaths = []string{"modelName", "options.model", "agentConfig.model"}
+
+// requestModel returns the model named in the request body, if any.
+func requestModel(req *http.Request) string {
+	if req.GetBody == nil {
+		return ""
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	data, err := io.ReadAll(body)
+	_ = body.Close()
+	if err != nil {
+		return ""
+	}
+	for _, path := range modelPaths {
+		v := gjson.GetBytes(data, path)
+		if v.Type == gjson.String {
+			return v.String()
+		}
+		if name := v.Get("modelName"); name.Type == gjson.String {
+			return name.String()
+		}
+	}
+	return ""
+}
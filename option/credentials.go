@@ -0,0 +1,258 @@
+// Custom code. Not generated by Stainless.
+package option
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+)
+
+// Credentials are the keys sent with each request. Empty fields are left as
+// configured by other options.
+type Credentials struct {
+	BrowserbaseAPIKey    string
+	BrowserbaseProjectID string
+	ModelAPIKey          string
+}
+
+// CredentialsProvider supplies the credentials for a request. It is called
+// once per request, so implementations that read from slow sources should
+// cache, for example with [CachedCredentials].
+//
+// If the provider also has an Invalidate method, it is called when the API
+// answers 401 Unauthorized, so the next request resolves fresh credentials.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// CredentialsProviderFunc adapts a function to a [CredentialsProvider].
+type CredentialsProviderFunc func(ctx context.Context) (Credentials, error)
+
+func (f CredentialsProviderFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+// WithCredentialsProvider returns a RequestOption that resolves the
+// credentials of each request from p, so keys can rotate without recreating
+// the client. Options applied after it, such as a per-request
+// [WithModelAPIKey], take precedence.
+func WithCredentialsProvider(p CredentialsProvider) RequestOption {
+	return requestconfig.RequestOptionFunc(func(r *requestconfig.RequestConfig) error {
+		ctx := r.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		creds, err := p.Credentials(ctx)
+		if err != nil {
+			return fmt.Errorf("option: failed to resolve credentials: %w", err)
+		}
+		if creds.BrowserbaseAPIKey != "" {
+			if err := r.Apply(WithBrowserbaseAPIKey(creds.BrowserbaseAPIKey)); err != nil {
+				return err
+			}
+		}
+		if creds.BrowserbaseProjectID != "" {
+			if err := r.Apply(WithBrowserbaseProjectID(creds.BrowserbaseProjectID)); err != nil {
+				return err
+			}
+		}
+		if creds.ModelAPIKey != "" {
+			if err := r.Apply(WithModelAPIKey(creds.ModelAPIKey)); err != nil {
+				return err
+			}
+		}
+
+		if inv, ok := p.(interface{ Invalidate() }); ok {
+			r.Middlewares = append(r.Middlewares, func(req *http.Request, next MiddlewareNext) (*http.Response, error) {
+				res, err := next(req)
+				if res != nil && res.StatusCode == http.StatusUnauthorized {
+					inv.Invalidate()
+				}
+				return res, err
+			})
+		}
+		return nil
+	})
+}
+
+// EnvCredentials returns a provider that reads BROWSERBASE_API_KEY,
+// BROWSERBASE_PROJECT_ID and MODEL_API_KEY from the environment on every
+// request.
+func EnvCredentials() CredentialsProvider {
+	return CredentialsProviderFunc(func(context.Context) (Credentials, error) {
+		return Credentials{
+			BrowserbaseAPIKey:    os.Getenv("BROWSERBASE_API_KEY"),
+			BrowserbaseProjectID: os.Getenv("BROWSERBASE_PROJECT_ID"),
+			ModelAPIKey:          os.Getenv("MODEL_API_KEY"),
+		}, nil
+	})
+}
+
+// CredentialFiles names the files holding each credential. Empty paths are
+// skipped.
+type CredentialFiles struct {
+	BrowserbaseAPIKey    string
+	BrowserbaseProjectID string
+	ModelAPIKey          string
+}
+
+// FileCredentials returns a provider that reads each credential from its
+// file, such as a mounted Kubernetes secret. The files are checked for changes
+// on every request and re-read when their size or modification time changes,
+// so rotated secrets are picked up without a restart. Surrounding whitespace
+// is trimmed.
+func FileCredentials(files CredentialFiles) CredentialsProvider {
+	return &fileCredentials{paths: files}
+}
+
+type fileCredentials struct {
+	paths CredentialFiles
+
+	mu    sync.Mutex
+	cache [3]fileCredential
+}
+
+type fileCredential struct {
+	modTime time.Time
+	size    int64
+	value   string
+}
+
+func (f *fileCredentials) Credentials(context.Context) (Credentials, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var creds Credentials
+	fields := []struct {
+		path string
+		dst  *string
+	}{
+		{f.paths.BrowserbaseAPIKey, &creds.BrowserbaseAPIKey},
+		{f.paths.BrowserbaseProjectID, &creds.BrowserbaseProjectID},
+		{f.paths.ModelAPIKey, &creds.ModelAPIKey},
+	}
+	for i, field := range fields {
+		if field.path == "" {
+			continue
+		}
+		value, err := f.cache[i].read(field.path)
+		if err != nil {
+			return Credentials{}, err
+		}
+		*field.dst = value
+	}
+	return creds, nil
+}
+
+// read returns the trimmed content of the file at path, re-reading it only if
+// it changed since the last call.
+func (c *fileCredential) read(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if c.value != "" && info.ModTime().Equal(c.modTime) && info.Size() == c.size {
+		return c.value, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	c.value = strings.TrimSpace(string(data))
+	c.modTime = info.ModTime()
+	c.size = info.Size()
+	return c.value, nil
+}
+
+// ChainCredentials returns a provider that takes each credential from the
+// first provider that supplies it. Providers that fail are skipped; an error
+// is only returned if every provider failed.
+func ChainCredentials(providers ...CredentialsProvider) CredentialsProvider {
+	return CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+		var creds Credentials
+		var errs []error
+		for _, p := range providers {
+			c, err := p.Credentials(ctx)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if creds.BrowserbaseAPIKey == "" {
+				creds.BrowserbaseAPIKey = c.BrowserbaseAPIKey
+			}
+			if creds.BrowserbaseProjectID == "" {
+				creds.BrowserbaseProjectID = c.BrowserbaseProjectID
+			}
+			if creds.ModelAPIKey == "" {
+				creds.ModelAPIKey = c.ModelAPIKey
+			}
+		}
+		if len(providers) > 0 && len(errs) == len(providers) {
+			return Credentials{}, errors.Join(errs...)
+		}
+		return creds, nil
+	})
+}
+
+// credentialsRefreshBackoff is how long [CachedCredentials] keeps using the
+// previous credentials after a failed refresh before trying again, unless its
+// ttl is shorter.
+const credentialsRefreshBackoff = 5 * time.Second
+
+// CachedCredentials returns a provider that reuses the credentials resolved
+// by p for ttl before asking p again. If refreshing fails, the previous
+// credentials keep being used until a refresh succeeds, and p is asked again
+// after a few seconds rather than on every request. The cache is also dropped
+// when the API rejects the credentials with 401 Unauthorized.
+func CachedCredentials(p CredentialsProvider, ttl time.Duration) CredentialsProvider {
+	return &cachedCredentials{provider: p, ttl: ttl}
+}
+
+type cachedCredentials struct {
+	provider CredentialsProvider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	creds   Credentials
+	expires time.Time
+	valid   bool
+}
+
+func (c *cachedCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.valid && time.Now().Before(c.expires) {
+		return c.creds, nil
+	}
+	creds, err := c.provider.Credentials(ctx)
+	if err != nil {
+		if c.valid {
+			c.expires = time.Now().Add(min(c.ttl, credentialsRefreshBackoff))
+			return c.creds, nil
+		}
+		return Credentials{}, err
+	}
+	c.creds = creds
+	c.expires = time.Now().Add(c.ttl)
+	c.valid = true
+	return creds, nil
+}
+
+// Invalidate drops the cached credentials so the next request resolves them
+// again.
+func (c *cachedCredentials) Invalidate() {
+	c.mu.Lock()
+	c.expires = time.Time{}
+	c.mu.Unlock()
+	if inv, ok := c.provider.(interface{ Invalidate() }); ok {
+		inv.Invalidate()
+	}
+}
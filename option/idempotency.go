@@ -0,0 +1,20 @@
+// Custom code. Not generated by Stainless.
+package option
+
+import (
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+)
+
+// WithIdempotencyKey returns a RequestOption that sends key as the
+// Idempotency-Key header of the request, instead of the key the client
+// generates for mutating session operations. Every retry of the request
+// carries the same key, so the server performs the operation at most once.
+//
+// Keys must be unique per logical operation, so pass this option to a single
+// method call rather than to the client.
+func WithIdempotencyKey(key string) RequestOption {
+	return requestconfig.RequestOptionFunc(func(r *requestconfig.RequestConfig) error {
+		r.Request.Header.Set("Idempotency-Key", key)
+		return nil
+	})
+}
@@ -0,0 +1,237 @@
+// Custom code. Not generated by Stainless.
+package option
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+)
+
+// LimiterConfig configures a [Limiter].
+type LimiterConfig struct {
+	// RPS is the sustained number of requests per second. Zero disables rate
+	// limiting.
+	RPS float64
+	// Burst is the number of requests that may be sent at once before RPS
+	// applies. Values below 1 are treated as 1.
+	Burst int
+	// MaxConcurrency bounds the number of requests in flight. Zero disables
+	// the bound.
+	MaxConcurrency int
+	// PerProject applies the limits separately to each Browserbase project,
+	// as identified by the x-bb-project-id header, rather than to all requests
+	// together.
+	PerProject bool
+	// MaxPause bounds how long a Retry-After-Ms or Retry-After header can
+	// hold back requests. Zero means one minute, the bound retries use.
+	MaxPause time.Duration
+	// OnWait, if set, is called with the project and the time a request spent
+	// waiting for the limiter. The project is empty unless PerProject is set.
+	OnWait func(project string, wait time.Duration)
+}
+
+// LimiterStats reports how a [Limiter] delayed requests.
+type LimiterStats struct {
+	// Requests is the number of requests admitted, including retries.
+	Requests int64
+	// Delayed is the number of requests that had to wait.
+	Delayed int64
+	// TotalWait is the time requests spent waiting in total.
+	TotalWait time.Duration
+	// MaxWait is the longest time a single request waited.
+	MaxWait time.Duration
+	// InFlight is the number of requests currently being sent.
+	InFlight int
+}
+
+// Limiter is a RequestOption that limits the rate and concurrency of requests.
+// Every attempt, including retries, is admitted separately. When a response
+// carries a Retry-After-Ms or Retry-After header with a 429 or 503 status, the
+// limiter holds back further requests until that time has passed, for at most
+// [LimiterConfig.MaxPause].
+//
+// A Limiter given to several clients limits them together.
+type Limiter struct {
+	cfg LimiterConfig
+
+	mu     sync.Mutex
+	scopes map[string]*limiterScope
+	stats  LimiterStats
+}
+
+type limiterScope struct {
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	sem         chan struct{}
+}
+
+// NewLimiter returns a Limiter with the given configuration.
+func NewLimiter(cfg LimiterConfig) *Limiter {
+	if cfg.Burst < 1 {
+		cfg.Burst = 1
+	}
+	return &Limiter{cfg: cfg, scopes: map[string]*limiterScope{}}
+}
+
+// WithRateLimit returns a RequestOption that allows rps requests per second
+// with bursts of up to burst requests. Use [NewLimiter] to share limits
+// between clients, limit each project separately, or read wait statistics.
+func WithRateLimit(rps float64, burst int) RequestOption {
+	return NewLimiter(LimiterConfig{RPS: rps, Burst: burst})
+}
+
+// WithMaxConcurrency returns a RequestOption that allows at most n requests in
+// flight at once. Use [NewLimiter] to share limits between clients, limit each
+// project separately, or read wait statistics.
+func WithMaxConcurrency(n int) RequestOption {
+	return NewLimiter(LimiterConfig{MaxConcurrency: n})
+}
+
+func (l *Limiter) Apply(r *requestconfig.RequestConfig) error {
+	r.Middlewares = append(r.Middlewares, l.middleware)
+	return nil
+}
+
+// Stats returns the statistics collected since the limiter was created.
+func (l *Limiter) Stats() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+func (l *Limiter) middleware(req *http.Request, next MiddlewareNext) (*http.Response, error) {
+	project := ""
+	if l.cfg.PerProject {
+		project = req.Header.Get("x-bb-project-id")
+	}
+
+	start := time.Now()
+	release, delayed, err := l.acquire(req.Context(), project)
+	if err != nil {
+		return nil, err
+	}
+	wait := time.Since(start)
+	l.record(wait, delayed)
+	if l.cfg.OnWait != nil {
+		l.cfg.OnWait(project, wait)
+	}
+
+	res, err := next(req)
+	release()
+
+	if res != nil && (res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusServiceUnavailable) {
+		if d, ok := requestconfig.RetryAfter(res, l.cfg.MaxPause); ok && d > 0 {
+			l.pause(project, d)
+		}
+	}
+	return res, err
+}
+
+func (l *Limiter) scope(project string) *limiterScope {
+	s, ok := l.scopes[project]
+	if !ok {
+		s = &limiterScope{tokens: float64(l.cfg.Burst), last: time.Now()}
+		if l.cfg.MaxConcurrency > 0 {
+			s.sem = make(chan struct{}, l.cfg.MaxConcurrency)
+		}
+		l.scopes[project] = s
+	}
+	return s
+}
+
+// acquire waits until the request may be sent and returns a function that
+// marks it as finished, and whether the request had to wait.
+func (l *Limiter) acquire(ctx context.Context, project string) (release func(), delayed bool, err error) {
+	delayed, err = l.waitTurn(ctx, project)
+	if err != nil {
+		return nil, false, err
+	}
+
+	l.mu.Lock()
+	sem := l.scope(project).sem
+	l.mu.Unlock()
+	if sem != nil {
+		select {
+		case sem <- struct{}{}:
+		default:
+			delayed = true
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return nil, false, ctx.Err()
+			}
+		}
+	}
+
+	l.mu.Lock()
+	l.stats.InFlight++
+	l.mu.Unlock()
+
+	return func() {
+		l.mu.Lock()
+		l.stats.InFlight--
+		l.mu.Unlock()
+		if sem != nil {
+			<-sem
+		}
+	}, delayed, nil
+}
+
+// waitTurn waits for a rate limit token and for any pause requested by the
+// server to end.
+func (l *Limiter) waitTurn(ctx context.Context, project string) (delayed bool, err error) {
+	for {
+		l.mu.Lock()
+		s := l.scope(project)
+		now := time.Now()
+		var wait time.Duration
+		if now.Before(s.pausedUntil) {
+			wait = s.pausedUntil.Sub(now)
+		} else if l.cfg.RPS > 0 {
+			s.tokens = min(float64(l.cfg.Burst), s.tokens+now.Sub(s.last).Seconds()*l.cfg.RPS)
+			s.last = now
+			if s.tokens < 1 {
+				wait = time.Duration((1 - s.tokens) / l.cfg.RPS * float64(time.Second))
+			} else {
+				s.tokens--
+			}
+		}
+		l.mu.Unlock()
+
+		if wait <= 0 {
+			return delayed, nil
+		}
+		delayed = true
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return false, ctx.Err()
+		}
+	}
+}
+
+func (l *Limiter) pause(project string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := l.scope(project)
+	if until := time.Now().Add(d); until.After(s.pausedUntil) {
+		s.pausedUntil = until
+	}
+}
+
+func (l *Limiter) record(wait time.Duration, delayed bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats.Requests++
+	if delayed {
+		l.stats.Delayed++
+	}
+	l.stats.TotalWait += wait
+	l.stats.MaxWait = max(l.stats.MaxWait, wait)
+}
@@ -0,0 +1,257 @@
+// Custom code. Not generated by Stainless.
+package option
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3/internal/apierror"
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// servedModelField is the field added to the response body to record which
+// model of a chain served the request.
+const servedModelField = "servedModel"
+
+// chainOperations are the session operations a model chain applies to.
+var chainOperations = []string{"act", "observe", "extract"}
+
+// WithModelChain returns a RequestOption that runs act, observe and extract
+// calls on each of models in turn until one does not fail because of its
+// provider. Each model is either a model name string or a model
+// configuration such as a stagehand.ModelConfigParam, and replaces the model
+// set in the call's options.
+//
+// A provider failure is a 408, 429 or 5xx error response that the model
+// provider caused rather than the Stagehand API, as shown by an error name,
+// type or code starting with "AI_" or by a provider field, or a
+// [CircuitOpenError] for the model. Each model is sent through the client's middlewares, such as a
+// [CircuitBreaker], which therefore tracks every model of the chain
+// separately. Provider failures of all but the last model are not retried;
+// the next model is tried instead. The last model's response is returned as
+// is. The model that served the call is recorded in the response, see for
+// example stagehand.SessionActResponse.ServedModel. Keys set with
+// [WithModelProviderAPIKey] are added to each model.
+func WithModelChain(models ...any) RequestOption {
+	return requestconfig.RequestOptionFunc(func(r *requestconfig.RequestConfig) error {
+		if len(models) == 0 || r.Request.Method != http.MethodPost || !isChainOperation(r.Request.URL.Path) {
+			return nil
+		}
+
+		raw := make([]json.RawMessage, len(models))
+		for i, model := range models {
+			data, err := json.Marshal(model)
+			if err != nil {
+				return fmt.Errorf("option: invalid model chain entry %d: %w", i, err)
+			}
+			if m := gjson.ParseBytes(data); m.Type != gjson.String && !m.IsObject() {
+				return fmt.Errorf("option: model chain entry %d must be a model name or configuration", i)
+			}
+			raw[i] = data
+		}
+
+		r.PostApply = append(r.PostApply, func(r *requestconfig.RequestConfig) error {
+			chain := &modelChain{models: raw, keys: r.ModelProviderAPIKeys}
+			// The chain wraps the retries and the middlewares, so that each
+			// model is retried, rate limited and circuit broken on its own.
+			r.Around = append(r.Around, chain.execute)
+			return nil
+		})
+		return nil
+	})
+}
+
+type modelChain struct {
+	models []json.RawMessage
+	keys   map[string]string
+}
+
+func isChainOperation(path string) bool {
+	path = strings.TrimRight(path, "/")
+	if !strings.Contains(path, "sessions/") {
+		return false
+	}
+	for _, op := range chainOperations {
+		if strings.HasSuffix(path, "/"+op) {
+			return true
+		}
+	}
+	return false
+}
+
+// providerErrorPrefix starts the names of the errors the Stagehand API
+// reports when a model provider call fails.
+const providerErrorPrefix = "AI_"
+
+// isProviderFailure reports whether res is an error response caused by a
+// model provider, so another model may succeed. Only structured signals are
+// trusted: the status, an error name, type or code starting with
+// providerErrorPrefix, or a provider field. Errors of the Stagehand API
+// itself, such as its own rate limiting, carry none of them.
+func isProviderFailure(res *http.Response) bool {
+	if res == nil || (res.StatusCode != http.StatusRequestTimeout &&
+		res.StatusCode != http.StatusTooManyRequests &&
+		res.StatusCode < http.StatusInternalServerError) {
+		return false
+	}
+	if res.Body == nil || !strings.Contains(res.Header.Get("Content-Type"), "json") {
+		return false
+	}
+	data, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil || !gjson.ValidBytes(data) {
+		return false
+	}
+
+	body := gjson.ParseBytes(data)
+	for _, field := range []string{"name", "type", "code", "error.name", "error.type", "error.code"} {
+		if strings.HasPrefix(body.Get(field).String(), providerErrorPrefix) {
+			return true
+		}
+	}
+	return body.Get("provider").Exists() || body.Get("error.provider").Exists()
+}
+
+func modelName(model json.RawMessage) string {
+	m := gjson.ParseBytes(model)
+	if m.IsObject() {
+		return m.Get("modelName").String()
+	}
+	return m.String()
+}
+
+// shouldFallBack reports whether err, returned for model, lets the next
+// model of the chain be tried.
+func shouldFallBack(err error, model json.RawMessage) bool {
+	var circuitErr *CircuitOpenError
+	if errors.As(err, &circuitErr) {
+		return circuitErr.Model != "" && circuitErr.Model == modelName(model)
+	}
+	var apiErr *apierror.Error
+	return errors.As(err, &apiErr) && isProviderFailure(apiErr.Response)
+}
+
+func (c *modelChain) execute(r *requestconfig.RequestConfig, execute func() error) error {
+	body := []byte("{}")
+	if buffer, ok := r.Body.(*bytes.Buffer); ok && buffer.Len() > 0 {
+		body = bytes.Clone(buffer.Bytes())
+	} else if r.Request.GetBody != nil {
+		rc, err := r.Request.GetBody()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+		if len(data) > 0 {
+			body = data
+		}
+	}
+	idempotencyKey := r.Request.Header.Get("Idempotency-Key")
+	middlewares := r.Middlewares
+	policy := r.RetryPolicy
+	defer func() {
+		r.Middlewares, r.RetryPolicy = middlewares, policy
+	}()
+
+	for i, model := range c.models {
+		b, err := sjson.SetRawBytes(body, "options.model", model)
+		if err != nil {
+			return err
+		}
+		if b, err = applyModelProviderAPIKey(b, "options.model", c.keys); err != nil {
+			return err
+		}
+		r.Body = bytes.NewBuffer(b)
+		r.Request.Body, r.Request.GetBody = nil, nil
+
+		last := i == len(c.models)-1
+		r.RetryPolicy = policy
+		if !last {
+			r.RetryPolicy = fallBackPolicy{policy: policy}
+		}
+		r.Middlewares = append(middlewares[:len(middlewares):len(middlewares)], servedModelMiddleware(model))
+		if idempotencyKey != "" && i > 0 {
+			// A different model is a different operation; reusing the key
+			// would make the server replay the failed response.
+			r.Middlewares = append([]Middleware{idempotencyKeyMiddleware(idempotencyKey + "-" + strconv.Itoa(i))}, r.Middlewares...)
+		}
+
+		err = execute()
+		if !last && shouldFallBack(err, model) {
+			continue
+		}
+		return err
+	}
+	return fmt.Errorf("option: empty model chain")
+}
+
+// fallBackPolicy does not retry provider failures, which the next model of
+// the chain handles instead, and otherwise defers to policy.
+type fallBackPolicy struct {
+	policy requestconfig.RetryPolicy
+}
+
+func (p fallBackPolicy) Retry(req *http.Request, res *http.Response, err error, attempt int) (time.Duration, bool) {
+	if isProviderFailure(res) {
+		return 0, false
+	}
+	policy := p.policy
+	if policy == nil {
+		policy = requestconfig.DefaultRetryPolicy
+	}
+	return policy.Retry(req, res, err, attempt)
+}
+
+// idempotencyKeyMiddleware sends key as the Idempotency-Key of a copy of each
+// request, leaving the call's own key in place for the other models.
+func idempotencyKeyMiddleware(key string) Middleware {
+	return func(req *http.Request, next MiddlewareNext) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.Header.Set("Idempotency-Key", key)
+		return next(req)
+	}
+}
+
+// servedModelMiddleware records model in successful responses.
+func servedModelMiddleware(model json.RawMessage) Middleware {
+	return func(req *http.Request, next MiddlewareNext) (*http.Response, error) {
+		res, err := next(req)
+		if err != nil || res == nil || res.StatusCode >= http.StatusMultipleChoices {
+			return res, err
+		}
+		return recordServedModel(res, model)
+	}
+}
+
+// recordServedModel adds the name of model to the JSON response body.
+func recordServedModel(res *http.Response, model json.RawMessage) (*http.Response, error) {
+	if res.Body == nil || !strings.Contains(res.Header.Get("Content-Type"), "json") {
+		return res, nil
+	}
+	name := modelName(model)
+
+	data, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return res, err
+	}
+	if updated, err := sjson.SetBytes(data, servedModelField, name); err == nil && gjson.ValidBytes(data) {
+		data = updated
+	}
+	res.Body = io.NopCloser(bytes.NewReader(data))
+	res.ContentLength = int64(len(data))
+	return res, nil
+}
@@ -0,0 +1,114 @@
+// Custom code. Not generated by Stainless.
+package option
+
+import (
+	"bytes"
+	"maps"
+	"strings"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// WithModelProviderAPIKey returns a RequestOption that sets the API key used
+// for models of provider, such as "anthropic" or "google". Call it once per
+// provider.
+//
+// The provider of a model is taken from its provider field or from the prefix
+// of its name, as in "anthropic/claude-sonnet-4-5". When the model given to
+// Act, Observe, Extract or Execute, including the agent's execution model,
+// belongs to a provider with a key and carries no API key of its own, the key
+// is added to the model configuration. When a session is started with such a
+// model, the key is sent as the model API key header instead of the one set
+// by [WithModelAPIKey].
+func WithModelProviderAPIKey(provider, key string) RequestOption {
+	return requestconfig.RequestOptionFunc(func(r *requestconfig.RequestConfig) error {
+		if r.ModelProviderAPIKeys == nil {
+			r.PostApply = append(r.PostApply, injectModelProviderAPIKeys)
+		}
+		keys := maps.Clone(r.ModelProviderAPIKeys)
+		if keys == nil {
+			keys = map[string]string{}
+		}
+		keys[strings.ToLower(provider)] = key
+		r.ModelProviderAPIKeys = keys
+		return nil
+	})
+}
+
+// WithModelProviderAPIKeys returns a RequestOption that sets the API keys used
+// for models of several providers at once. See [WithModelProviderAPIKey].
+func WithModelProviderAPIKeys(keys map[string]string) RequestOption {
+	return requestconfig.RequestOptionFunc(func(r *requestconfig.RequestConfig) error {
+		for provider, key := range keys {
+			if err := WithModelProviderAPIKey(provider, key).Apply(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// modelConfigPaths are the request body fields holding a model name or model
+// configuration object.
+var modelConfigPaths = []string{"options.model", "agentConfig.model", "agentConfig.executionModel"}
+
+func injectModelProviderAPIKeys(r *requestconfig.RequestConfig) error {
+	buffer, ok := r.Body.(*bytes.Buffer)
+	if !ok || len(r.ModelProviderAPIKeys) == 0 {
+		return nil
+	}
+	body := buffer.Bytes()
+
+	if name := gjson.GetBytes(body, "modelName"); name.Type == gjson.String && strings.HasSuffix(r.Request.URL.Path, "sessions/start") {
+		if key, ok := r.ModelProviderAPIKeys[modelProvider(name.String())]; ok {
+			if err := r.Apply(WithModelAPIKey(key)); err != nil {
+				return err
+			}
+		}
+	}
+
+	var err error
+	for _, path := range modelConfigPaths {
+		body, err = applyModelProviderAPIKey(body, path, r.ModelProviderAPIKeys)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.Body = bytes.NewBuffer(body)
+	return nil
+}
+
+// applyModelProviderAPIKey adds the key of its provider to the model at path
+// in body, turning a model name into a model configuration if needed. Models
+// that already carry a key or whose provider has none are left alone.
+func applyModelProviderAPIKey(body []byte, path string, keys map[string]string) ([]byte, error) {
+	model := gjson.GetBytes(body, path)
+	switch {
+	case model.Type == gjson.String:
+		if key, ok := keys[modelProvider(model.String())]; ok {
+			return sjson.SetBytes(body, path, map[string]string{"modelName": model.String(), "apiKey": key})
+		}
+	case model.IsObject() && !model.Get("apiKey").Exists():
+		provider := model.Get("provider").String()
+		if provider == "" {
+			provider = modelProvider(model.Get("modelName").String())
+		}
+		if key, ok := keys[strings.ToLower(provider)]; ok {
+			return sjson.SetBytes(body, path+".apiKey", key)
+		}
+	}
+	return body, nil
+}
+
+// modelProvider returns the provider prefix of a model name such as
+// "anthropic/claude-sonnet-4-5", or an empty string if it has none.
+func modelProvider(name string) string {
+	provider, _, ok := strings.Cut(name, "/")
+	if !ok {
+		return ""
+	}
+	return strings.ToLower(provider)
+}
@@ -0,0 +1,71 @@
+// Custom code. Not generated by Stainless.
+package option
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+)
+
+// RetryPolicy decides whether a failed attempt is retried and how long to wait
+// before the next one. Retry receives the request, the response if one was
+// received, the transport error if not, and the zero-based number of the
+// attempt that failed.
+//
+// The number of retries stays bounded by [WithMaxRetries], and requests whose
+// body cannot be replayed are never retried.
+type RetryPolicy = requestconfig.RetryPolicy
+
+// RetryPolicyFunc adapts a function to a [RetryPolicy].
+type RetryPolicyFunc = requestconfig.RetryPolicyFunc
+
+// WithRetryPolicy returns a RequestOption that sets the policy deciding which
+// attempts are retried and how long to wait between them. Passing nil restores
+// the default policy, which retries connection errors, 408, 409, 429 and 5xx
+// responses with exponential backoff.
+func WithRetryPolicy(policy RetryPolicy) RequestOption {
+	return requestconfig.RequestOptionFunc(func(r *requestconfig.RequestConfig) error {
+		r.RetryPolicy = policy
+		return nil
+	})
+}
+
+// DefaultRetryPolicy returns the policy used when none is set.
+func DefaultRetryPolicy() RetryPolicy {
+	return requestconfig.DefaultRetryPolicy
+}
+
+// ExponentialRetryPolicy retries the same responses as the default policy,
+// waiting initial doubled on each attempt and capped at maxDelay, with up to
+// jitter (a fraction between 0 and 1) of each delay taken off at random. A
+// Retry-After header from the server takes precedence, capped at maxDelay, or
+// at a minute if maxDelay is zero.
+func ExponentialRetryPolicy(initial, maxDelay time.Duration, jitter float64) RetryPolicy {
+	return requestconfig.ExponentialRetryPolicy{Initial: initial, Max: maxDelay, Jitter: jitter}
+}
+
+// DecorrelatedJitterRetryPolicy retries the same responses as the default
+// policy, waiting a random duration between base and a bound that triples on
+// each attempt, capped at maxDelay. This spreads out retries from clients that
+// failed at the same time. A Retry-After header from the server takes
+// precedence, capped at maxDelay, or at a minute if maxDelay is zero.
+func DecorrelatedJitterRetryPolicy(base, maxDelay time.Duration) RetryPolicy {
+	return requestconfig.DecorrelatedJitterRetryPolicy{Base: base, Max: maxDelay}
+}
+
+// NoRetryNonIdempotentPolicy wraps policy, or the default policy if nil, so
+// that session operations which may change the page, such as act and
+// agentExecute, are never retried once they may have reached the server.
+// Repeating a half-executed click is worse than reporting the failure.
+// Requests that failed to connect are still retried by policy.
+func NoRetryNonIdempotentPolicy(policy RetryPolicy) RetryPolicy {
+	return requestconfig.NoRetryNonIdempotentPolicy{Policy: policy}
+}
+
+// IsNonIdempotentOperation reports whether req performs a session operation
+// that may change the page, such as act or agentExecute. It is useful for
+// building custom retry policies.
+func IsNonIdempotentOperation(req *http.Request) bool {
+	return requestconfig.IsNonIdempotentOperation(req)
+}
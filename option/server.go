@@ -33,8 +33,113 @@ func WithServer(server string) RequestOption {
 	case "remote", "local":
 		return serverOption{server: mode}
 	default:
-		return requestconfig.RequestOptionFunc(func(*requestconfig.RequestConfig) error {
-			return fmt.Errorf("option: invalid server %q", server)
-		})
+		return invalidOption{fmt.Errorf("option: invalid server %q", server)}
 	}
 }
+
+// LocalServerScopeOption represents how widely a local mode driver is shared.
+type LocalServerScopeOption interface {
+	LocalServerScope() string
+}
+
+type localServerScopeOption struct {
+	scope string
+}
+
+func (o localServerScopeOption) Apply(*requestconfig.RequestConfig) error {
+	return nil
+}
+
+func (o localServerScopeOption) LocalServerScope() string {
+	return o.scope
+}
+
+// WithLocalServerScope sets how widely the local mode driver is shared between
+// clients that use the same credentials.
+// Valid values: "process" (default) shares one driver between all clients in
+// this process, "host" additionally shares it between processes on this host.
+func WithLocalServerScope(scope string) RequestOption {
+	s := strings.ToLower(scope)
+	switch s {
+	case "process", "host":
+		return localServerScopeOption{scope: s}
+	default:
+		return invalidOption{fmt.Errorf("option: invalid local server scope %q", scope)}
+	}
+}
+
+// LocalDriverVersionOption represents the driver release used by local mode.
+type LocalDriverVersionOption interface {
+	LocalDriverVersion() string
+}
+
+type localDriverVersionOption struct {
+	version string
+}
+
+func (o localDriverVersionOption) Apply(*requestconfig.RequestConfig) error {
+	return nil
+}
+
+func (o localDriverVersionOption) LocalDriverVersion() string {
+	return o.version
+}
+
+// WithLocalDriverVersion pins the driver release local mode downloads and
+// runs, e.g. "3.0.1". By default the STAGEHAND_SERVER_VERSION environment
+// variable is used, or the latest release.
+func WithLocalDriverVersion(version string) RequestOption {
+	return localDriverVersionOption{version: version}
+}
+
+// RegionalEndpointOption represents the API endpoint serving a Browserbase
+// region.
+type RegionalEndpointOption interface {
+	RegionalEndpoint() (region, baseURL string)
+}
+
+type regionalEndpointOption struct {
+	region  string
+	baseURL string
+}
+
+func (o regionalEndpointOption) Apply(*requestconfig.RequestConfig) error {
+	return nil
+}
+
+func (o regionalEndpointOption) RegionalEndpoint() (string, string) {
+	return o.region, o.baseURL
+}
+
+// WithRegionalEndpoint sets the API endpoint for sessions started in a
+// Browserbase region, e.g. "eu-central-1". Later calls for those sessions are
+// sent to the same endpoint. Sessions in regions without an endpoint, other
+// than the default us-west-2, are run through a local mode driver.
+func WithRegionalEndpoint(region, baseURL string) RequestOption {
+	return regionalEndpointOption{region: strings.ToLower(region), baseURL: baseURL}
+}
+
+// BrowserbaseAPIURLOption represents the base URL of the Browserbase API.
+type BrowserbaseAPIURLOption interface {
+	BrowserbaseAPIURL() string
+}
+
+type browserbaseAPIURLOption struct {
+	baseURL string
+}
+
+func (o browserbaseAPIURLOption) Apply(*requestconfig.RequestConfig) error {
+	return nil
+}
+
+func (o browserbaseAPIURLOption) BrowserbaseAPIURL() string {
+	return o.baseURL
+}
+
+// WithBrowserbaseAPIURL sets the base URL of the Browserbase API, which
+// serves calls that go to Browserbase directly rather than through Stagehand,
+// such as those of the contexts service. Defaults to
+// https://api.browserbase.com/.
+func WithBrowserbaseAPIURL(baseURL string) RequestOption {
+	return browserbaseAPIURLOption{baseURL: baseURL}
+}
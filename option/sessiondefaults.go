@@ -0,0 +1,78 @@
+// Custom code. Not generated by Stainless.
+package option
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// WithDefaultModel returns a RequestOption that sets the model of sessions
+// started without one.
+func WithDefaultModel(name string) RequestOption {
+	return withSessionStartDefault(func(body []byte) ([]byte, error) {
+		if gjson.GetBytes(body, "modelName").String() != "" {
+			return body, nil
+		}
+		return sjson.SetBytes(body, "modelName", name)
+	})
+}
+
+// WithBrowserbaseSessionDefaults returns a RequestOption that fills in the
+// Browserbase session parameters of started sessions, such as "region" or
+// "keepAlive", that the call does not set itself. Nested objects are merged
+// field by field.
+func WithBrowserbaseSessionDefaults(params map[string]any) RequestOption {
+	return withSessionStartDefault(func(body []byte) ([]byte, error) {
+		return mergeDefaults(body, "browserbaseSessionCreateParams", params)
+	})
+}
+
+// withSessionStartDefault applies fill to the body of session start requests
+// once all other options have been applied.
+func withSessionStartDefault(fill func([]byte) ([]byte, error)) RequestOption {
+	return requestconfig.RequestOptionFunc(func(r *requestconfig.RequestConfig) error {
+		if r.Request.Method != http.MethodPost || !strings.HasSuffix(strings.TrimRight(r.Request.URL.Path, "/"), "sessions/start") {
+			return nil
+		}
+		r.PostApply = append(r.PostApply, func(r *requestconfig.RequestConfig) error {
+			buffer, ok := r.Body.(*bytes.Buffer)
+			if !ok {
+				return nil
+			}
+			body, err := fill(buffer.Bytes())
+			if err != nil {
+				return err
+			}
+			r.Body = bytes.NewBuffer(body)
+			return nil
+		})
+		return nil
+	})
+}
+
+func mergeDefaults(body []byte, path string, defaults map[string]any) ([]byte, error) {
+	var err error
+	for key, value := range defaults {
+		field := path + "." + escapeJSONPathKey(key)
+		existing := gjson.GetBytes(body, field)
+		if nested, ok := value.(map[string]any); ok && (!existing.Exists() || existing.IsObject()) {
+			body, err = mergeDefaults(body, field, nested)
+		} else if !existing.Exists() {
+			body, err = sjson.SetBytes(body, field, value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+func escapeJSONPathKey(key string) string {
+	r := strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`, "|", `\|`, "#", `\#`, "@", `\@`)
+	return r.Replace(key)
+}
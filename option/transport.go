@@ -0,0 +1,229 @@
+// Custom code. Not generated by Stainless.
+package option
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+)
+
+// TransportSettings describe the HTTP transport a client builds for API
+// calls, streamed responses, local mode driver downloads and local mode health
+// checks. Zero values keep the defaults of [http.DefaultTransport].
+type TransportSettings struct {
+	// ProxyURL is the proxy every request goes through, such as
+	// "http://proxy.corp:3128". By default the HTTP_PROXY, HTTPS_PROXY and
+	// NO_PROXY environment variables are used. Requests to loopback
+	// addresses, such as those to a local mode driver, never use the proxy.
+	ProxyURL string
+	// CABundleFile is a PEM file of certificate authorities trusted in
+	// addition to the system roots.
+	CABundleFile string
+	// ClientCertFile and ClientKeyFile are the PEM certificate and key
+	// presented to servers that require mutual TLS.
+	ClientCertFile string
+	ClientKeyFile  string
+	// DialTimeout bounds establishing a TCP connection.
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout bounds the TLS handshake.
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout bounds waiting for the response headers once the
+	// request was sent. Streamed response bodies are not affected.
+	ResponseHeaderTimeout time.Duration
+}
+
+// TransportOption represents a setting of the HTTP transport built by the
+// client. Transport options only take effect when passed to
+// stagehand.NewClient, and are ignored when [WithHTTPClient] is also given.
+type TransportOption interface {
+	ConfigureTransport(*TransportSettings)
+}
+
+type transportOption func(*TransportSettings)
+
+func (o transportOption) Apply(*requestconfig.RequestConfig) error {
+	return nil
+}
+
+func (o transportOption) ConfigureTransport(s *TransportSettings) {
+	o(s)
+}
+
+// invalidOption is an option whose settings were rejected when it was built.
+// It fails every request it is applied to, and is reported by [Validate].
+type invalidOption struct {
+	err error
+}
+
+func (o invalidOption) Apply(*requestconfig.RequestConfig) error {
+	return o.err
+}
+
+// Validate returns the errors of options whose settings were rejected when
+// they were built, such as a malformed proxy URL or an unreadable CA bundle.
+// Such options fail every request they are applied to, so checking them up
+// front reports the problem once.
+func Validate(opts ...RequestOption) error {
+	var errs []error
+	for _, opt := range opts {
+		if invalid, ok := opt.(invalidOption); ok {
+			errs = append(errs, invalid.err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithProxy sends every request through the proxy at proxyURL, such as
+// "http://proxy.corp:3128". Schemes http, https and socks5 are supported.
+func WithProxy(proxyURL string) RequestOption {
+	if _, err := parseProxyURL(proxyURL); err != nil {
+		return invalidOption{err}
+	}
+	return transportOption(func(s *TransportSettings) { s.ProxyURL = proxyURL })
+}
+
+// WithCABundle trusts the certificate authorities in the PEM file at path in
+// addition to the system roots. The file is checked when the option is built.
+func WithCABundle(path string) RequestOption {
+	if _, err := loadCABundle(path); err != nil {
+		return invalidOption{err}
+	}
+	return transportOption(func(s *TransportSettings) { s.CABundleFile = path })
+}
+
+// WithClientCertificate presents the PEM certificate and key in certFile and
+// keyFile to servers that require mutual TLS. The files are checked when the
+// option is built.
+func WithClientCertificate(certFile, keyFile string) RequestOption {
+	if _, err := loadClientCertificate(certFile, keyFile); err != nil {
+		return invalidOption{err}
+	}
+	return transportOption(func(s *TransportSettings) {
+		s.ClientCertFile = certFile
+		s.ClientKeyFile = keyFile
+	})
+}
+
+// WithDialTimeout bounds establishing a TCP connection.
+func WithDialTimeout(d time.Duration) RequestOption {
+	return transportOption(func(s *TransportSettings) { s.DialTimeout = d })
+}
+
+// WithTLSHandshakeTimeout bounds the TLS handshake.
+func WithTLSHandshakeTimeout(d time.Duration) RequestOption {
+	return transportOption(func(s *TransportSettings) { s.TLSHandshakeTimeout = d })
+}
+
+// WithResponseHeaderTimeout bounds waiting for the response headers. Unlike
+// [WithRequestTimeout], it does not cut off streamed responses.
+func WithResponseHeaderTimeout(d time.Duration) RequestOption {
+	return transportOption(func(s *TransportSettings) { s.ResponseHeaderTimeout = d })
+}
+
+// NewTransport returns a transport configured by s, based on a clone of
+// [http.DefaultTransport].
+func NewTransport(s TransportSettings) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if s.ProxyURL != "" {
+		proxy, err := parseProxyURL(s.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			if isLoopback(req.URL.Hostname()) {
+				return nil, nil
+			}
+			return proxy, nil
+		}
+	}
+
+	if s.CABundleFile != "" || s.ClientCertFile != "" || s.ClientKeyFile != "" {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if s.CABundleFile != "" {
+			pool, err := loadCABundle(s.CABundleFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if s.ClientCertFile != "" || s.ClientKeyFile != "" {
+			cert, err := loadClientCertificate(s.ClientCertFile, s.ClientKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		t.TLSClientConfig = tlsConfig
+	}
+
+	if s.DialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: s.DialTimeout, KeepAlive: 30 * time.Second}
+		t.DialContext = dialer.DialContext
+	}
+	if s.TLSHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = s.TLSHandshakeTimeout
+	}
+	if s.ResponseHeaderTimeout > 0 {
+		t.ResponseHeaderTimeout = s.ResponseHeaderTimeout
+	}
+	return t, nil
+}
+
+func parseProxyURL(proxyURL string) (*url.URL, error) {
+	proxy, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("option: invalid proxy URL: %w", err)
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("option: unsupported proxy scheme %q", proxy.Scheme)
+	}
+	return proxy, nil
+}
+
+// loadCABundle returns the system roots with the certificate authorities in
+// the PEM file at path added.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("option: failed to read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("option: no certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
+
+func loadClientCertificate(certFile, keyFile string) (tls.Certificate, error) {
+	if certFile == "" || keyFile == "" {
+		return tls.Certificate{}, errors.New("option: client certificate requires both a certificate and a key file")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("option: failed to load client certificate: %w", err)
+	}
+	return cert, nil
+}
+
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,192 @@
+// Custom code. Not generated by Stainless.
+package stagehand
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+	"github.com/browserbase/stagehand-go/v3/option"
+	"github.com/tidwall/gjson"
+)
+
+// defaultRegion is the Browserbase region served by the default API endpoint.
+const defaultRegion = "us-west-2"
+
+// sessionBackend is where a session started through a regionRouter lives.
+type sessionBackend struct {
+	// baseURL is the regional endpoint, or empty for the local driver.
+	baseURL string
+}
+
+// regionSessionTTL is how long a session's backend is remembered after its
+// last call. It exceeds the longest a Browserbase session can run, so only
+// sessions that ended without an end call are forgotten.
+const regionSessionTTL = 6 * time.Hour
+
+// routedSession is a session a regionRouter sends to a backend.
+type routedSession struct {
+	backend  sessionBackend
+	lastUsed time.Time
+}
+
+// regionRouter is a request option that sends sessions started in a
+// Browserbase region to an endpoint that can serve it, and sends later calls
+// for each session to the same place. Regions without a configured endpoint,
+// other than the default one, are served by a local driver that is started on
+// first use. A session is forgotten when it ends, when its backend no longer
+// knows it, or after regionSessionTTL without calls.
+type regionRouter struct {
+	endpoints map[string]string
+	newLocal  func() *localServerOption
+
+	mu       sync.Mutex
+	local    *localServerOption
+	sessions map[string]*routedSession
+}
+
+func newRegionRouter(opts []option.RequestOption, transport http.RoundTripper) *regionRouter {
+	endpoints := map[string]string{}
+	for _, opt := range opts {
+		if endpointOpt, ok := opt.(option.RegionalEndpointOption); ok {
+			region, baseURL := endpointOpt.RegionalEndpoint()
+			endpoints[region] = baseURL
+		}
+	}
+	return &regionRouter{
+		endpoints: endpoints,
+		newLocal: func() *localServerOption {
+			return newLocalServerOption(localServerScopeFromOptions(opts), localDriverVersionFromOptions(opts), transport)
+		},
+		sessions: map[string]*routedSession{},
+	}
+}
+
+func (r *regionRouter) Apply(cfg *requestconfig.RequestConfig) error {
+	if cfg == nil || cfg.Request == nil {
+		return nil
+	}
+	path := strings.Trim(cfg.Request.URL.Path, "/")
+	if path == "v1/sessions/start" {
+		if cfg.Request.Method == http.MethodPost {
+			// Defer until the hooks of all options have run, so the region
+			// in the body is final.
+			cfg.PostApply = append(cfg.PostApply, func(cfg *requestconfig.RequestConfig) error {
+				cfg.PostApply = append(cfg.PostApply, r.routeStart)
+				return nil
+			})
+		}
+		return nil
+	}
+
+	rest, ok := strings.CutPrefix(path, "v1/sessions/")
+	if !ok {
+		return nil
+	}
+	id, op, _ := strings.Cut(rest, "/")
+	r.mu.Lock()
+	session, ok := r.sessions[id]
+	if ok {
+		session.lastUsed = time.Now()
+	}
+	r.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	backend := session.backend
+	cfg.Middlewares = append(cfg.Middlewares, func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+		res, err := next(req)
+		if err == nil && res != nil && (res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone ||
+			(op == "end" && res.StatusCode < 300)) {
+			r.mu.Lock()
+			if r.sessions[id] == session {
+				delete(r.sessions, id)
+			}
+			r.mu.Unlock()
+		}
+		return res, err
+	})
+	cfg.PostApply = append(cfg.PostApply, func(cfg *requestconfig.RequestConfig) error {
+		return r.routeTo(cfg, backend)
+	})
+	return nil
+}
+
+// routeStart picks the backend for a session from its Browserbase region.
+func (r *regionRouter) routeStart(cfg *requestconfig.RequestConfig) error {
+	buffer, ok := cfg.Body.(*bytes.Buffer)
+	if !ok {
+		return nil
+	}
+	region := strings.ToLower(gjson.GetBytes(buffer.Bytes(), "browserbaseSessionCreateParams.region").String())
+	if region == "" {
+		return nil
+	}
+
+	var backend sessionBackend
+	if baseURL, ok := r.endpoints[region]; ok {
+		backend.baseURL = baseURL
+	} else if region == defaultRegion {
+		return nil
+	}
+
+	cfg.Middlewares = append(cfg.Middlewares, func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+		res, err := next(req)
+		r.recordSession(res, backend)
+		return res, err
+	})
+	return r.routeTo(cfg, backend)
+}
+
+func (r *regionRouter) routeTo(cfg *requestconfig.RequestConfig, backend sessionBackend) error {
+	if backend.baseURL != "" {
+		return option.WithBaseURL(backend.baseURL).Apply(cfg)
+	}
+	r.mu.Lock()
+	if r.local == nil {
+		r.local = r.newLocal()
+	}
+	local := r.local
+	r.mu.Unlock()
+	return local.Apply(cfg)
+}
+
+// recordSession remembers the backend of the session started by res.
+func (r *regionRouter) recordSession(res *http.Response, backend sessionBackend) {
+	if res == nil || res.Body == nil || res.StatusCode >= 300 {
+		return
+	}
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	if id := gjson.GetBytes(body, "data.sessionId").String(); id != "" {
+		now := time.Now()
+		r.mu.Lock()
+		for id, session := range r.sessions {
+			if now.Sub(session.lastUsed) > regionSessionTTL {
+				delete(r.sessions, id)
+			}
+		}
+		r.sessions[id] = &routedSession{backend: backend, lastUsed: now}
+		r.mu.Unlock()
+	}
+}
+
+// Close stops the local driver started for regional sessions, if any.
+func (r *regionRouter) Close() error {
+	r.mu.Lock()
+	local := r.local
+	r.local = nil
+	r.mu.Unlock()
+	if local == nil {
+		return nil
+	}
+	return local.Close()
+}
@@ -0,0 +1,138 @@
+// Custom tests. Not generated by Stainless.
+package stagehand_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+func TestRegionalRouting(t *testing.T) {
+	requests := []string{}
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://default.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithRegionalEndpoint("eu-central-1", "http://eu.test"),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					requests = append(requests, req.URL.Host+req.URL.Path)
+					if strings.HasSuffix(req.URL.Path, "/start") {
+						id := "default-session"
+						if req.URL.Host == "eu.test" {
+							id = "eu-session"
+						}
+						return jsonResponse(http.StatusOK, `{"success":true,"data":{"available":true,"sessionId":"`+id+`"}}`), nil
+					}
+					return jsonResponse(http.StatusOK, `{"success":true,"data":{}}`), nil
+				},
+			},
+		}),
+	)
+	ctx := context.Background()
+
+	_, err := client.Sessions.Start(ctx, stagehand.SessionStartParams{
+		ModelName: "openai/gpt-5.4-mini",
+		BrowserbaseSessionCreateParams: stagehand.SessionStartParamsBrowserbaseSessionCreateParams{
+			Region: "eu-central-1",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	_, _ = client.Sessions.Start(ctx, stagehand.SessionStartParams{
+		ModelName: "openai/gpt-5.4-mini",
+		BrowserbaseSessionCreateParams: stagehand.SessionStartParamsBrowserbaseSessionCreateParams{
+			Region: "us-west-2",
+		},
+	})
+	_, _ = client.Sessions.Navigate(ctx, "eu-session", stagehand.SessionNavigateParams{URL: "https://example.com"})
+	_, _ = client.Sessions.Navigate(ctx, "default-session", stagehand.SessionNavigateParams{URL: "https://example.com"})
+	_, _ = client.Sessions.End(ctx, "eu-session", stagehand.SessionEndParams{})
+	_, _ = client.Sessions.End(ctx, "eu-session", stagehand.SessionEndParams{})
+
+	want := []string{
+		"eu.test/v1/sessions/start",
+		"default.test/v1/sessions/start",
+		"eu.test/v1/sessions/eu-session/navigate",
+		"default.test/v1/sessions/default-session/navigate",
+		"eu.test/v1/sessions/eu-session/end",
+		"default.test/v1/sessions/eu-session/end",
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("expected requests %v, got %v", want, requests)
+	}
+}
+
+func TestRegionalRoutingForgetsUnknownSessions(t *testing.T) {
+	requests := []string{}
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://default.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(0),
+		option.WithRegionalEndpoint("eu-central-1", "http://eu.test"),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					requests = append(requests, req.URL.Host+req.URL.Path)
+					if strings.HasSuffix(req.URL.Path, "/start") {
+						return jsonResponse(http.StatusOK, `{"success":true,"data":{"available":true,"sessionId":"eu-session"}}`), nil
+					}
+					// The session timed out on the regional backend.
+					return jsonResponse(http.StatusGone, `{"success":false,"message":"session expired"}`), nil
+				},
+			},
+		}),
+	)
+	ctx := context.Background()
+
+	_, err := client.Sessions.Start(ctx, stagehand.SessionStartParams{
+		ModelName: "openai/gpt-5.4-mini",
+		BrowserbaseSessionCreateParams: stagehand.SessionStartParamsBrowserbaseSessionCreateParams{
+			Region: "eu-central-1",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	_, _ = client.Sessions.Navigate(ctx, "eu-session", stagehand.SessionNavigateParams{URL: "https://example.com"})
+	_, _ = client.Sessions.Navigate(ctx, "eu-session", stagehand.SessionNavigateParams{URL: "https://example.com"})
+
+	want := []string{
+		"eu.test/v1/sessions/start",
+		"eu.test/v1/sessions/eu-session/navigate",
+		"default.test/v1/sessions/eu-session/navigate",
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("expected requests %v, got %v", want, requests)
+	}
+}
+
+func TestRegionalRoutingFallsBackToLocal(t *testing.T) {
+	t.Setenv("MODEL_API_KEY", "")
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://default.test"),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					t.Fatalf("unexpected request to %s", req.URL)
+					return nil, nil
+				},
+			},
+		}),
+	)
+	_, err := client.Sessions.Start(context.Background(), stagehand.SessionStartParams{
+		ModelName: "openai/gpt-5.4-mini",
+		BrowserbaseSessionCreateParams: stagehand.SessionStartParamsBrowserbaseSessionCreateParams{
+			Region: "ap-southeast-1",
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "local mode") {
+		t.Fatalf("expected the session to be routed to local mode, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+// Custom tests. Not generated by Stainless.
+package stagehand_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	type call struct {
+		status  int
+		attempt int
+	}
+	calls := []call{}
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(5),
+		option.WithRetryPolicy(option.RetryPolicyFunc(func(req *http.Request, res *http.Response, err error, attempt int) (time.Duration, bool) {
+			calls = append(calls, call{status: res.StatusCode, attempt: attempt})
+			return 0, res.StatusCode == http.StatusBadGateway
+		})),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					if len(calls) < 2 {
+						return jsonResponse(http.StatusBadGateway, `{}`), nil
+					}
+					return jsonResponse(http.StatusBadRequest, `{}`), nil
+				},
+			},
+		}),
+	)
+
+	_, err := client.Sessions.Start(context.Background(), stagehand.SessionStartParams{ModelName: "openai/gpt-5.4-mini"})
+	if err == nil {
+		t.Fatalf("expected the final 400 to be returned")
+	}
+	want := []call{{http.StatusBadGateway, 0}, {http.StatusBadGateway, 1}, {http.StatusBadRequest, 2}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("expected policy calls %v, got %v", want, calls)
+	}
+}
+
+func TestNoRetryNonIdempotentPolicy(t *testing.T) {
+	attempts := map[string]int{}
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithRetryPolicy(option.NoRetryNonIdempotentPolicy(option.ExponentialRetryPolicy(time.Millisecond, time.Millisecond, 0))),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					parts := strings.Split(req.URL.Path, "/")
+					attempts[parts[len(parts)-1]]++
+					return jsonResponse(http.StatusServiceUnavailable, `{}`), nil
+				},
+			},
+		}),
+	)
+
+	ctx := context.Background()
+	_, _ = client.Sessions.Act(ctx, "session", stagehand.SessionActParams{
+		Input: stagehand.SessionActParamsInputUnion{OfString: stagehand.String("click the button")},
+	})
+	_, _ = client.Sessions.Observe(ctx, "session", stagehand.SessionObserveParams{})
+
+	if want := map[string]int{"act": 1, "observe": 3}; !reflect.DeepEqual(attempts, want) {
+		t.Fatalf("expected attempts %v, got %v", want, attempts)
+	}
+}
+
+func TestRetryPolicies_ClampRetryAfter(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://stagehand.test/v1/sessions/session", nil)
+	res := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": {"3600"}}}
+	for name, tc := range map[string]struct {
+		policy option.RetryPolicy
+		want   time.Duration
+	}{
+		"default":                  {option.DefaultRetryPolicy(), time.Minute},
+		"exponential":              {option.ExponentialRetryPolicy(time.Second, 10*time.Second, 0), 10 * time.Second},
+		"exponential without max":  {option.ExponentialRetryPolicy(time.Second, 0, 0), time.Minute},
+		"decorrelated jitter":      {option.DecorrelatedJitterRetryPolicy(time.Second, 20*time.Second), 20 * time.Second},
+		"decorrelated without max": {option.DecorrelatedJitterRetryPolicy(time.Second, 0), time.Minute},
+	} {
+		delay, retry := tc.policy.Retry(req, res, nil, 0)
+		if !retry || delay != tc.want {
+			t.Errorf("%s: expected a retry after %v, got %v (retry %v)", name, tc.want, delay, retry)
+		}
+	}
+}
@@ -0,0 +1,500 @@
+// Custom code. Not generated by Stainless.
+package stagehand
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3/packages/param"
+)
+
+// BrowserType is where a session's browser runs, for
+// [SessionStartParamsBrowser.Type].
+type BrowserType string
+
+const (
+	BrowserTypeLocal       BrowserType = "local"
+	BrowserTypeBrowserbase BrowserType = "browserbase"
+)
+
+// Region is a Browserbase region, for
+// [SessionStartParamsBrowserbaseSessionCreateParams.Region].
+type Region string
+
+const (
+	RegionUSWest2      Region = "us-west-2"
+	RegionUSEast1      Region = "us-east-1"
+	RegionEUCentral1   Region = "eu-central-1"
+	RegionAPSoutheast1 Region = "ap-southeast-1"
+)
+
+// FingerprintBrowser is a browser Browserbase fingerprints can imitate.
+type FingerprintBrowser string
+
+const (
+	FingerprintBrowserChrome  FingerprintBrowser = "chrome"
+	FingerprintBrowserEdge    FingerprintBrowser = "edge"
+	FingerprintBrowserFirefox FingerprintBrowser = "firefox"
+	FingerprintBrowserSafari  FingerprintBrowser = "safari"
+)
+
+// FingerprintDevice is a kind of device Browserbase fingerprints can imitate.
+type FingerprintDevice string
+
+const (
+	FingerprintDeviceDesktop FingerprintDevice = "desktop"
+	FingerprintDeviceMobile  FingerprintDevice = "mobile"
+)
+
+// FingerprintOperatingSystem is an operating system Browserbase fingerprints
+// can imitate.
+type FingerprintOperatingSystem string
+
+const (
+	FingerprintOperatingSystemAndroid FingerprintOperatingSystem = "android"
+	FingerprintOperatingSystemIOS     FingerprintOperatingSystem = "ios"
+	FingerprintOperatingSystemLinux   FingerprintOperatingSystem = "linux"
+	FingerprintOperatingSystemMacOS   FingerprintOperatingSystem = "macos"
+	FingerprintOperatingSystemWindows FingerprintOperatingSystem = "windows"
+)
+
+// FingerprintHTTPVersion is an HTTP version Browserbase fingerprints can
+// imitate.
+type FingerprintHTTPVersion string
+
+const (
+	FingerprintHTTPVersion1 FingerprintHTTPVersion = "1"
+	FingerprintHTTPVersion2 FingerprintHTTPVersion = "2"
+)
+
+// AgentMode is the tool mode of an agent, for
+// [SessionExecuteParamsAgentConfig.Mode].
+type AgentMode string
+
+const (
+	AgentModeDOM    AgentMode = "dom"
+	AgentModeHybrid AgentMode = "hybrid"
+	AgentModeCua    AgentMode = "cua"
+)
+
+// Browserbase limits the duration of sessions to between a minute and six
+// hours.
+const (
+	minBrowserbaseTimeout = 60
+	maxBrowserbaseTimeout = 6 * 60 * 60
+)
+
+// ValidationError reports an invalid field of request parameters. Validate
+// methods return all of them, joined with [errors.Join].
+type ValidationError struct {
+	// Field is the JSON path of the field, such as "browser.type".
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("stagehand: invalid %s: %s", e.Field, e.Message)
+}
+
+// validator collects validation errors.
+type validator struct {
+	errs []error
+}
+
+func (v *validator) fail(field, format string, args ...any) {
+	v.errs = append(v.errs, &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) oneOf(field, value string, allowed ...string) {
+	if value != "" && !slices.Contains(allowed, value) {
+		v.fail(field, "%q is not one of %s", value, strings.Join(allowed, ", "))
+	}
+}
+
+func (v *validator) eachOneOf(field string, values []string, allowed ...string) {
+	for i, value := range values {
+		v.oneOf(fmt.Sprintf("%s.%d", field, i), value, allowed...)
+	}
+}
+
+func (v *validator) atLeast(field string, value param.Opt[float64], min float64) {
+	if value.Valid() && value.Value < min {
+		v.fail(field, "must be at least %v, got %v", min, value.Value)
+	}
+}
+
+func (v *validator) between(field string, value param.Opt[float64], min, max float64) {
+	if value.Valid() && (value.Value < min || value.Value > max) {
+		v.fail(field, "must be between %v and %v, got %v", min, max, value.Value)
+	}
+}
+
+func (v *validator) err() error {
+	return errors.Join(v.errs...)
+}
+
+// Validate checks the parameters before they are sent: enumerated values,
+// fields that cannot be used together and numeric ranges. The model name is
+// not required, since client options such as [option.WithDefaultModel] may
+// fill it in.
+func (r SessionStartParams) Validate() error {
+	v := &validator{}
+	v.oneOf("verbose", fmt.Sprint(r.Verbose), "0", "1", "2")
+	v.atLeast("domSettleTimeoutMs", r.DomSettleTimeoutMs, 0)
+	v.atLeast("actTimeoutMs", r.ActTimeoutMs, 0)
+
+	b := r.Browser
+	v.oneOf("browser.type", b.Type, string(BrowserTypeLocal), string(BrowserTypeBrowserbase))
+	if b.CdpURL.Valid() {
+		validateCdpURL(v, "browser.cdpUrl", b.CdpURL.Value)
+		if !param.IsOmitted(b.LaunchOptions) {
+			v.fail("browser.launchOptions", "cannot be used with browser.cdpUrl, which connects to a running browser")
+		}
+	}
+	local := b.Type != string(BrowserTypeBrowserbase)
+	if !local {
+		if b.CdpURL.Valid() {
+			v.fail("browser.cdpUrl", "only applies to local browsers")
+		}
+		if !param.IsOmitted(b.LaunchOptions) {
+			v.fail("browser.launchOptions", "only applies to local browsers")
+		}
+	}
+	validateLaunchOptions(v, b.LaunchOptions)
+
+	if b.Type == string(BrowserTypeLocal) {
+		if !param.IsOmitted(r.BrowserbaseSessionCreateParams) {
+			v.fail("browserbaseSessionCreateParams", "only applies to Browserbase browsers")
+		}
+		if r.BrowserbaseSessionID.Valid() {
+			v.fail("browserbaseSessionID", "only applies to Browserbase browsers")
+		}
+	}
+	if r.BrowserbaseSessionID.Valid() && !param.IsOmitted(r.BrowserbaseSessionCreateParams) {
+		v.fail("browserbaseSessionCreateParams", "cannot be used with browserbaseSessionID, which resumes an existing session")
+	}
+	validateBrowserbaseParams(v, r.BrowserbaseSessionCreateParams)
+	return v.err()
+}
+
+func validateCdpURL(v *validator, field, raw string) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		v.fail(field, "%v", err)
+		return
+	}
+	switch u.Scheme {
+	case "ws", "wss", "http", "https":
+	default:
+		v.fail(field, "must be a ws, wss, http or https URL, got %q", raw)
+	}
+}
+
+func validateLaunchOptions(v *validator, o SessionStartParamsBrowserLaunchOptions) {
+	const field = "browser.launchOptions"
+	if o.CdpURL.Valid() {
+		validateCdpURL(v, field+".cdpUrl", o.CdpURL.Value)
+	}
+	v.between(field+".port", o.Port, 1, 65535)
+	v.atLeast(field+".connectTimeoutMs", o.ConnectTimeoutMs, 0)
+	if o.DeviceScaleFactor.Valid() && o.DeviceScaleFactor.Value <= 0 {
+		v.fail(field+".deviceScaleFactor", "must be positive, got %v", o.DeviceScaleFactor.Value)
+	}
+	if !param.IsOmitted(o.Viewport) && (o.Viewport.Width <= 0 || o.Viewport.Height <= 0) {
+		v.fail(field+".viewport", "width and height must be positive, got %vx%v", o.Viewport.Width, o.Viewport.Height)
+	}
+	if !param.IsOmitted(o.Proxy) && o.Proxy.Server == "" {
+		v.fail(field+".proxy.server", "is required")
+	}
+	if o.PreserveUserDataDir.Valid() && o.PreserveUserDataDir.Value && !o.UserDataDir.Valid() {
+		v.fail(field+".preserveUserDataDir", "requires userDataDir")
+	}
+}
+
+func validateBrowserbaseParams(v *validator, p SessionStartParamsBrowserbaseSessionCreateParams) {
+	const field = "browserbaseSessionCreateParams"
+	v.oneOf(field+".region", p.Region, string(RegionUSWest2), string(RegionUSEast1), string(RegionEUCentral1), string(RegionAPSoutheast1))
+	v.between(field+".timeout", p.Timeout, minBrowserbaseTimeout, maxBrowserbaseTimeout)
+
+	s := p.BrowserSettings
+	if !param.IsOmitted(s.Context) && s.Context.ID == "" {
+		v.fail(field+".browserSettings.context.id", "is required")
+	}
+	if s.Viewport.Width.Valid() != s.Viewport.Height.Valid() {
+		v.fail(field+".browserSettings.viewport", "needs both width and height")
+	}
+	v.atLeast(field+".browserSettings.viewport.width", s.Viewport.Width, 1)
+	v.atLeast(field+".browserSettings.viewport.height", s.Viewport.Height, 1)
+
+	f := s.Fingerprint
+	const fp = field + ".browserSettings.fingerprint"
+	v.eachOneOf(fp+".browsers", f.Browsers, "chrome", "edge", "firefox", "safari")
+	v.eachOneOf(fp+".devices", f.Devices, "desktop", "mobile")
+	v.eachOneOf(fp+".operatingSystems", f.OperatingSystems, "android", "ios", "linux", "macos", "windows")
+	v.oneOf(fp+".httpVersion", f.HTTPVersion, "1", "2")
+	if f.Screen.MinWidth.Valid() && f.Screen.MaxWidth.Valid() && f.Screen.MinWidth.Value > f.Screen.MaxWidth.Value {
+		v.fail(fp+".screen", "minWidth %v is above maxWidth %v", f.Screen.MinWidth.Value, f.Screen.MaxWidth.Value)
+	}
+	if f.Screen.MinHeight.Valid() && f.Screen.MaxHeight.Valid() && f.Screen.MinHeight.Value > f.Screen.MaxHeight.Value {
+		v.fail(fp+".screen", "minHeight %v is above maxHeight %v", f.Screen.MinHeight.Value, f.Screen.MaxHeight.Value)
+	}
+
+	for i, proxy := range p.Proxies.OfProxyConfigList {
+		item := fmt.Sprintf("%s.proxies.%d", field, i)
+		switch {
+		case proxy.OfBrowserbase != nil && proxy.OfExternal != nil:
+			v.fail(item, "must be either a Browserbase or an external proxy")
+		case proxy.OfExternal != nil && proxy.OfExternal.Server == "":
+			v.fail(item+".server", "is required")
+		case proxy.OfBrowserbase != nil && !param.IsOmitted(proxy.OfBrowserbase.Geolocation) && proxy.OfBrowserbase.Geolocation.Country == "":
+			v.fail(item+".geolocation.country", "is required")
+		}
+	}
+}
+
+// Validate checks the parameters before they are sent: enumerated values,
+// fields that cannot be used together and numeric ranges.
+func (r SessionExecuteParams) Validate() error {
+	v := &validator{}
+	c := r.AgentConfig
+	v.oneOf("agentConfig.mode", c.Mode, string(AgentModeDOM), string(AgentModeHybrid), string(AgentModeCua))
+	v.oneOf("agentConfig.provider", c.Provider, "openai", "anthropic", "google", "microsoft", "bedrock")
+	if c.Cua.Valid() && c.Mode != "" {
+		v.fail("agentConfig.cua", "cannot be used with agentConfig.mode, which replaces it")
+	}
+	if r.ExecuteOptions.Instruction == "" {
+		v.fail("executeOptions.instruction", "is required")
+	}
+	v.atLeast("executeOptions.maxSteps", r.ExecuteOptions.MaxSteps, 1)
+	v.atLeast("executeOptions.toolTimeout", r.ExecuteOptions.ToolTimeout, 0)
+	return v.err()
+}
+
+// SessionStartBuilder builds [SessionStartParams] for a local or a
+// Browserbase browser. Settings that do not apply to the builder's kind of
+// browser are reported by [SessionStartBuilder.Build].
+type SessionStartBuilder struct {
+	params SessionStartParams
+}
+
+// NewLocalSessionStart starts building the parameters of a session with a
+// browser launched on this machine, or connected to with
+// [SessionStartBuilder.CdpURL].
+func NewLocalSessionStart(model string) *SessionStartBuilder {
+	b := &SessionStartBuilder{}
+	b.params.ModelName = model
+	b.params.Browser.Type = string(BrowserTypeLocal)
+	return b
+}
+
+// NewBrowserbaseSessionStart starts building the parameters of a session with
+// a Browserbase browser.
+func NewBrowserbaseSessionStart(model string) *SessionStartBuilder {
+	b := &SessionStartBuilder{}
+	b.params.ModelName = model
+	b.params.Browser.Type = string(BrowserTypeBrowserbase)
+	return b
+}
+
+// Build validates and returns the parameters.
+func (b *SessionStartBuilder) Build() (SessionStartParams, error) {
+	return b.params, b.params.Validate()
+}
+
+// SystemPrompt sets the system prompt of AI operations.
+func (b *SessionStartBuilder) SystemPrompt(prompt string) *SessionStartBuilder {
+	b.params.SystemPrompt = String(prompt)
+	return b
+}
+
+// SelfHeal enables or disables self-healing of failed actions.
+func (b *SessionStartBuilder) SelfHeal(enabled bool) *SessionStartBuilder {
+	b.params.SelfHeal = Bool(enabled)
+	return b
+}
+
+// DomSettleTimeout sets how long to wait for the DOM to settle.
+func (b *SessionStartBuilder) DomSettleTimeout(d time.Duration) *SessionStartBuilder {
+	b.params.DomSettleTimeoutMs = Float(float64(d.Milliseconds()))
+	return b
+}
+
+// Verbose sets the logging verbosity: 0 (quiet), 1 (normal) or 2 (debug).
+func (b *SessionStartBuilder) Verbose(level int) *SessionStartBuilder {
+	b.params.Verbose = float64(level)
+	return b
+}
+
+// CdpURL connects a local session to a running browser instead of launching
+// one.
+func (b *SessionStartBuilder) CdpURL(cdpURL string) *SessionStartBuilder {
+	b.params.Browser.CdpURL = String(cdpURL)
+	return b
+}
+
+// Headless launches the local browser with or without a window.
+func (b *SessionStartBuilder) Headless(headless bool) *SessionStartBuilder {
+	b.params.Browser.LaunchOptions.Headless = Bool(headless)
+	return b
+}
+
+// ExecutablePath launches the local browser from a given executable.
+func (b *SessionStartBuilder) ExecutablePath(path string) *SessionStartBuilder {
+	b.params.Browser.LaunchOptions.ExecutablePath = String(path)
+	return b
+}
+
+// UserDataDir launches the local browser with a given profile directory,
+// kept after the session if preserve is set.
+func (b *SessionStartBuilder) UserDataDir(dir string, preserve bool) *SessionStartBuilder {
+	b.params.Browser.LaunchOptions.UserDataDir = String(dir)
+	b.params.Browser.LaunchOptions.PreserveUserDataDir = Bool(preserve)
+	return b
+}
+
+// Args adds command-line arguments of the local browser.
+func (b *SessionStartBuilder) Args(args ...string) *SessionStartBuilder {
+	b.params.Browser.LaunchOptions.Args = append(b.params.Browser.LaunchOptions.Args, args...)
+	return b
+}
+
+// LocalProxy routes the local browser's traffic through a proxy server.
+func (b *SessionStartBuilder) LocalProxy(server string) *SessionStartBuilder {
+	b.params.Browser.LaunchOptions.Proxy.Server = server
+	return b
+}
+
+// AcceptDownloads makes the local browser accept downloads into dir.
+func (b *SessionStartBuilder) AcceptDownloads(dir string) *SessionStartBuilder {
+	b.params.Browser.LaunchOptions.AcceptDownloads = Bool(true)
+	b.params.Browser.LaunchOptions.DownloadsPath = String(dir)
+	return b
+}
+
+// Viewport sets the viewport size, of the local browser's window or of the
+// Browserbase browser.
+func (b *SessionStartBuilder) Viewport(width, height int) *SessionStartBuilder {
+	if b.params.Browser.Type == string(BrowserTypeBrowserbase) {
+		b.params.BrowserbaseSessionCreateParams.BrowserSettings.Viewport.Width = Float(float64(width))
+		b.params.BrowserbaseSessionCreateParams.BrowserSettings.Viewport.Height = Float(float64(height))
+	} else {
+		b.params.Browser.LaunchOptions.Viewport.Width = float64(width)
+		b.params.Browser.LaunchOptions.Viewport.Height = float64(height)
+	}
+	return b
+}
+
+// Device emulates a device preset, such as one from [LookupDevice] or
+// [Devices]. See [Device.Apply].
+func (b *SessionStartBuilder) Device(d Device) *SessionStartBuilder {
+	d.Apply(&b.params)
+	return b
+}
+
+// Region runs the Browserbase browser in a region.
+func (b *SessionStartBuilder) Region(region Region) *SessionStartBuilder {
+	b.params.BrowserbaseSessionCreateParams.Region = string(region)
+	return b
+}
+
+// ProjectID creates the Browserbase session in a project other than the
+// client's.
+func (b *SessionStartBuilder) ProjectID(id string) *SessionStartBuilder {
+	b.params.BrowserbaseSessionCreateParams.ProjectID = String(id)
+	return b
+}
+
+// KeepAlive keeps the Browserbase session running after it disconnects.
+func (b *SessionStartBuilder) KeepAlive(keepAlive bool) *SessionStartBuilder {
+	b.params.BrowserbaseSessionCreateParams.KeepAlive = Bool(keepAlive)
+	return b
+}
+
+// Timeout ends the Browserbase session after d, which is rounded down to
+// whole seconds.
+func (b *SessionStartBuilder) Timeout(d time.Duration) *SessionStartBuilder {
+	b.params.BrowserbaseSessionCreateParams.Timeout = Float(float64(int64(d / time.Second)))
+	return b
+}
+
+// Context uses a Browserbase context, saving changes to it at the end of the
+// session if persist is set.
+func (b *SessionStartBuilder) Context(id string, persist bool) *SessionStartBuilder {
+	b.params.BrowserbaseSessionCreateParams.BrowserSettings.Context = SessionStartParamsBrowserbaseSessionCreateParamsBrowserSettingsContext{
+		ID:      id,
+		Persist: Bool(persist),
+	}
+	return b
+}
+
+// Fingerprint sets what the Browserbase browser's fingerprint imitates. Empty
+// arguments are left unset.
+func (b *SessionStartBuilder) Fingerprint(browsers []FingerprintBrowser, devices []FingerprintDevice, operatingSystems []FingerprintOperatingSystem) *SessionStartBuilder {
+	f := &b.params.BrowserbaseSessionCreateParams.BrowserSettings.Fingerprint
+	for _, browser := range browsers {
+		f.Browsers = append(f.Browsers, string(browser))
+	}
+	for _, device := range devices {
+		f.Devices = append(f.Devices, string(device))
+	}
+	for _, system := range operatingSystems {
+		f.OperatingSystems = append(f.OperatingSystems, string(system))
+	}
+	return b
+}
+
+// FingerprintHTTPVersion sets the HTTP version of the Browserbase browser's
+// fingerprint.
+func (b *SessionStartBuilder) FingerprintHTTPVersion(version FingerprintHTTPVersion) *SessionStartBuilder {
+	b.params.BrowserbaseSessionCreateParams.BrowserSettings.Fingerprint.HTTPVersion = string(version)
+	return b
+}
+
+// FingerprintLocales sets the locales of the Browserbase browser's
+// fingerprint, such as "en-US".
+func (b *SessionStartBuilder) FingerprintLocales(locales ...string) *SessionStartBuilder {
+	f := &b.params.BrowserbaseSessionCreateParams.BrowserSettings.Fingerprint
+	f.Locales = append(f.Locales, locales...)
+	return b
+}
+
+// Proxies routes the Browserbase browser's traffic through Browserbase's
+// proxies.
+func (b *SessionStartBuilder) Proxies(enabled bool) *SessionStartBuilder {
+	b.params.BrowserbaseSessionCreateParams.Proxies = SessionStartParamsBrowserbaseSessionCreateParamsProxiesUnion{OfBool: Bool(enabled)}
+	return b
+}
+
+// SolveCaptchas makes the Browserbase browser solve captchas.
+func (b *SessionStartBuilder) SolveCaptchas(enabled bool) *SessionStartBuilder {
+	b.params.BrowserbaseSessionCreateParams.BrowserSettings.SolveCaptchas = Bool(enabled)
+	return b
+}
+
+// BlockAds makes the Browserbase browser block ads.
+func (b *SessionStartBuilder) BlockAds(enabled bool) *SessionStartBuilder {
+	b.params.BrowserbaseSessionCreateParams.BrowserSettings.BlockAds = Bool(enabled)
+	return b
+}
+
+// AdvancedStealth enables Browserbase's advanced stealth mode.
+func (b *SessionStartBuilder) AdvancedStealth(enabled bool) *SessionStartBuilder {
+	b.params.BrowserbaseSessionCreateParams.BrowserSettings.AdvancedStealth = Bool(enabled)
+	return b
+}
+
+// RecordSession enables or disables the Browserbase session recording.
+func (b *SessionStartBuilder) RecordSession(enabled bool) *SessionStartBuilder {
+	b.params.BrowserbaseSessionCreateParams.BrowserSettings.RecordSession = Bool(enabled)
+	return b
+}
+
+// UserMetadata attaches metadata to the Browserbase session.
+func (b *SessionStartBuilder) UserMetadata(metadata map[string]any) *SessionStartBuilder {
+	b.params.BrowserbaseSessionCreateParams.UserMetadata = metadata
+	return b
+}
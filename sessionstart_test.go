@@ -0,0 +1,112 @@
+// Custom tests. Not generated by Stainless.
+package stagehand_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/packages/param"
+)
+
+func TestSessionStartBuilder(t *testing.T) {
+	params, err := stagehand.NewBrowserbaseSessionStart("openai/gpt-5.4-mini").
+		Region(stagehand.RegionEUCentral1).
+		Timeout(30*time.Minute).
+		Context("ctx-1", true).
+		Fingerprint([]stagehand.FingerprintBrowser{stagehand.FingerprintBrowserChrome}, []stagehand.FingerprintDevice{stagehand.FingerprintDeviceMobile}, nil).
+		Viewport(390, 844).
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	data, _ := json.Marshal(params)
+	want := `{"modelName":"openai/gpt-5.4-mini","browser":{"type":"browserbase"},"browserbaseSessionCreateParams":{"timeout":1800,` +
+		`"browserSettings":{"context":{"id":"ctx-1","persist":true},"fingerprint":{"browsers":["chrome"],"devices":["mobile"]},"viewport":{"height":844,"width":390}},"region":"eu-central-1"}}`
+	if string(data) != want {
+		t.Fatalf("expected %s, got %s", want, data)
+	}
+
+	params, err = stagehand.NewLocalSessionStart("openai/gpt-5.4-mini").Headless(true).Viewport(1280, 720).Args("--lang=de").Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if lo := params.Browser.LaunchOptions; !lo.Headless.Value || lo.Viewport.Width != 1280 || lo.Args[0] != "--lang=de" {
+		t.Fatalf("unexpected launch options %+v", lo)
+	}
+
+	// Settings for the other kind of browser are reported.
+	_, err = stagehand.NewLocalSessionStart("openai/gpt-5.4-mini").Region(stagehand.RegionUSEast1).Build()
+	var validationErr *stagehand.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "browserbaseSessionCreateParams" {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+}
+
+func TestSessionStartParams_Validate(t *testing.T) {
+	params := stagehand.SessionStartParams{
+		ModelName: "openai/gpt-5.4-mini",
+		Verbose:   3,
+		Browser: stagehand.SessionStartParamsBrowser{
+			Type:   "Local",
+			CdpURL: stagehand.String("ftp://localhost:9222"),
+			LaunchOptions: stagehand.SessionStartParamsBrowserLaunchOptions{
+				Port: stagehand.Float(70000),
+			},
+		},
+		BrowserbaseSessionCreateParams: stagehand.SessionStartParamsBrowserbaseSessionCreateParams{
+			Region:  "eu-west-1",
+			Timeout: stagehand.Float(10),
+			BrowserSettings: stagehand.SessionStartParamsBrowserbaseSessionCreateParamsBrowserSettings{
+				Fingerprint: stagehand.SessionStartParamsBrowserbaseSessionCreateParamsBrowserSettingsFingerprint{
+					Browsers:    []string{"chrome", "opera"},
+					HTTPVersion: "3",
+				},
+			},
+		},
+	}
+	err := params.Validate()
+	if err == nil {
+		t.Fatalf("expected validation errors")
+	}
+	for _, field := range []string{
+		"verbose", "browser.type", "browser.cdpUrl", "browser.launchOptions:", "browser.launchOptions.port",
+		"browserbaseSessionCreateParams.region", "browserbaseSessionCreateParams.timeout",
+		"browserbaseSessionCreateParams.browserSettings.fingerprint.browsers.1",
+		"browserbaseSessionCreateParams.browserSettings.fingerprint.httpVersion",
+	} {
+		if !strings.Contains(err.Error(), "invalid "+field) {
+			t.Errorf("expected an error for %s, got:\n%v", field, err)
+		}
+	}
+
+	valid := stagehand.SessionStartParams{ModelName: "openai/gpt-5.4-mini", Browser: stagehand.SessionStartParamsBrowser{Type: "local"}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected valid parameters, got %v", err)
+	}
+}
+
+func TestSessionExecuteParams_Validate(t *testing.T) {
+	params := stagehand.SessionExecuteParams{
+		AgentConfig: stagehand.SessionExecuteParamsAgentConfig{
+			Cua:  stagehand.Bool(true),
+			Mode: string(stagehand.AgentModeHybrid),
+		},
+		ExecuteOptions: stagehand.SessionExecuteParamsExecuteOptions{
+			Instruction: "Find the pricing page",
+			MaxSteps:    stagehand.Float(0),
+		},
+	}
+	err := params.Validate()
+	if err == nil || !strings.Contains(err.Error(), "invalid agentConfig.cua") || !strings.Contains(err.Error(), "invalid executeOptions.maxSteps") {
+		t.Fatalf("expected errors for cua and maxSteps, got %v", err)
+	}
+	params.AgentConfig.Cua = param.Opt[bool]{}
+	params.ExecuteOptions.MaxSteps = stagehand.Float(10)
+	if err := params.Validate(); err != nil {
+		t.Fatalf("expected valid parameters, got %v", err)
+	}
+}
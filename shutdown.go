@@ -0,0 +1,204 @@
+// Custom code. Not generated by Stainless.
+package stagehand
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// shutdownConcurrency bounds how many sessions are ended at once during
+	// [Client.Shutdown].
+	shutdownConcurrency = 8
+	// defaultCloseTimeout bounds how long [Client.Close] spends ending sessions.
+	defaultCloseTimeout = 10 * time.Second
+)
+
+// sessionTracker is a request option that records the sessions started through
+// a client so they can be ended when the client shuts down.
+type sessionTracker struct {
+	mu  sync.Mutex
+	ids map[string]struct{}
+}
+
+func newSessionTracker() *sessionTracker {
+	return &sessionTracker{ids: map[string]struct{}{}}
+}
+
+func (t *sessionTracker) Apply(cfg *requestconfig.RequestConfig) error {
+	if cfg == nil || cfg.Request == nil || cfg.Request.Method != http.MethodPost {
+		return nil
+	}
+
+	path := strings.Trim(cfg.Request.URL.Path, "/")
+	switch {
+	case path == "v1/sessions/start":
+		cfg.Middlewares = append(cfg.Middlewares, t.trackStart)
+	case strings.HasPrefix(path, "v1/sessions/") && strings.HasSuffix(path, "/end"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "v1/sessions/"), "/end")
+		cfg.Middlewares = append(cfg.Middlewares, func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+			res, err := next(req)
+			if err == nil && res != nil && (res.StatusCode < 300 || res.StatusCode == http.StatusNotFound) {
+				t.remove(id)
+			}
+			return res, err
+		})
+	}
+	return nil
+}
+
+func (t *sessionTracker) trackStart(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	res, err := next(req)
+	// A middleware may fail after the session started, returning the start
+	// response with its error; the session is tracked all the same.
+	if res == nil || res.Body == nil || res.StatusCode >= 300 {
+		return res, err
+	}
+
+	body, readErr := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil && err == nil {
+		err = readErr
+	}
+
+	if id := gjson.GetBytes(body, "data.sessionId").String(); id != "" {
+		t.mu.Lock()
+		t.ids[id] = struct{}{}
+		t.mu.Unlock()
+	}
+	return res, err
+}
+
+func (t *sessionTracker) remove(id string) {
+	t.mu.Lock()
+	delete(t.ids, id)
+	t.mu.Unlock()
+}
+
+// open returns the IDs of sessions that were started and not yet ended.
+func (t *sessionTracker) open() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ids := make([]string, 0, len(t.ids))
+	for id := range t.ids {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// OpenSessions returns the IDs of sessions started through this client that
+// have not been ended yet.
+func (c Client) OpenSessions() []string {
+	for _, opt := range c.Options {
+		if tracker, ok := opt.(*sessionTracker); ok {
+			return tracker.open()
+		}
+	}
+	return nil
+}
+
+// Shutdown ends every session started through this client that is still open,
+// then shuts down any local mode processes associated with the client.
+//
+// Sessions are ended concurrently, bounded by the deadline of ctx. Errors from
+// ending sessions and from stopping local mode are joined into the returned
+// error.
+func (c Client) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	ids := c.OpenSessions()
+	if len(ids) > 0 {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, shutdownConcurrency)
+		for _, id := range ids {
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("end session %s: %w", id, ctx.Err()))
+					mu.Unlock()
+					return
+				}
+				if _, err := c.Sessions.End(ctx, id, SessionEndParams{}); err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("end session %s: %w", id, err))
+					mu.Unlock()
+				}
+			}(id)
+		}
+		wg.Wait()
+	}
+
+	for _, opt := range c.Options {
+		if closer, ok := opt.(interface{ Close() error }); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ShutdownOnSignal calls [Client.Shutdown] when the process receives one of the
+// given signals, or SIGINT and SIGTERM if none are given. Shutdown is bounded by
+// timeout.
+//
+// The returned context is cancelled once shutdown has finished, with
+// [context.Cause] reporting any error returned by Shutdown, so command line
+// tools can wait on it before exiting. Calling stop stops listening for
+// signals and cancels the context without shutting the client down.
+func (c Client) ShutdownOnSignal(timeout time.Duration, signals ...os.Signal) (done context.Context, stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+
+	done, cancel := context.WithCancelCause(context.Background())
+	stopped := make(chan struct{})
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(stopped)
+		})
+	}
+
+	go func() {
+		select {
+		case <-ch:
+			signal.Stop(ch)
+			ctx, cancelTimeout := context.WithTimeout(context.Background(), timeout)
+			err := c.Shutdown(ctx)
+			cancelTimeout()
+			cancel(err)
+		case <-stopped:
+			cancel(nil)
+		}
+	}()
+
+	return done, stop
+}
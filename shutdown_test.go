@@ -0,0 +1,110 @@
+// Custom tests. Not generated by Stainless.
+package stagehand_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+	}
+}
+
+func TestShutdownEndsOpenSessions(t *testing.T) {
+	var mu sync.Mutex
+	started := 0
+	ended := []string{}
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(0),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					switch {
+					case strings.HasSuffix(req.URL.Path, "/sessions/start"):
+						started++
+						return jsonResponse(http.StatusOK, fmt.Sprintf(`{"success":true,"data":{"available":true,"sessionId":"session-%d"}}`, started)), nil
+					case strings.HasSuffix(req.URL.Path, "/session-3/end"):
+						return jsonResponse(http.StatusInternalServerError, `{}`), nil
+					case strings.HasSuffix(req.URL.Path, "/end"):
+						ended = append(ended, strings.Split(req.URL.Path, "/")[3])
+						return jsonResponse(http.StatusOK, `{"success":true}`), nil
+					}
+					return jsonResponse(http.StatusNotFound, `{}`), nil
+				},
+			},
+		}),
+	)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		res, err := client.Sessions.Start(ctx, stagehand.SessionStartParams{ModelName: "openai/gpt-5.4-mini"})
+		if err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		if res.Data.SessionID != fmt.Sprintf("session-%d", i+1) {
+			t.Fatalf("unexpected session ID %q", res.Data.SessionID)
+		}
+	}
+	if _, err := client.Sessions.End(ctx, "session-1", stagehand.SessionEndParams{}); err != nil {
+		t.Fatalf("End: %v", err)
+	}
+	if got, want := client.OpenSessions(), []string{"session-2", "session-3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected open sessions %v, got %v", want, got)
+	}
+
+	err := client.Shutdown(ctx)
+	if err == nil || !strings.Contains(err.Error(), "end session session-3") {
+		t.Fatalf("expected shutdown to report the failed session, got %v", err)
+	}
+	if want := []string{"session-1", "session-2"}; !reflect.DeepEqual(ended, want) {
+		t.Fatalf("expected ended sessions %v, got %v", want, ended)
+	}
+	if got, want := client.OpenSessions(), []string{"session-3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected open sessions %v, got %v", want, got)
+	}
+}
+
+func TestShutdownRespectsContext(t *testing.T) {
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					if strings.HasSuffix(req.URL.Path, "/sessions/start") {
+						return jsonResponse(http.StatusOK, `{"success":true,"data":{"available":true,"sessionId":"slow"}}`), nil
+					}
+					<-req.Context().Done()
+					return nil, req.Context().Err()
+				},
+			},
+		}),
+	)
+	if _, err := client.Sessions.Start(context.Background(), stagehand.SessionStartParams{ModelName: "openai/gpt-5.4-mini"}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.Shutdown(ctx); err == nil {
+		t.Fatalf("expected shutdown to fail when its context is done")
+	}
+}
@@ -0,0 +1,209 @@
+// Custom code. Not generated by Stainless.
+package stagehand
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+	"github.com/browserbase/stagehand-go/v3/option"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// TenantContextsConfig configures [TenantContexts].
+type TenantContextsConfig struct {
+	// File, if set, is a JSON file that keeps the context of each tenant
+	// across runs. Without it, contexts are only remembered by the process.
+	File string
+	// ProjectID is the project contexts are created in. Defaults to the
+	// client's Browserbase project ID.
+	ProjectID string
+}
+
+// TenantContexts keeps one persistent Browserbase context per tenant, such
+// as a customer, so that each tenant's login state carries over between its
+// sessions. A tenant's context is created the first time one of its sessions
+// is started, and reused afterwards.
+type TenantContexts struct {
+	store ContextStore
+	cfg   TenantContextsConfig
+
+	mu      sync.Mutex
+	tenants map[string]*tenantContext
+	loadErr error
+}
+
+type tenantContext struct {
+	mu sync.Mutex
+	id string
+}
+
+// NewTenantContexts returns TenantContexts that create contexts in store,
+// usually the client's Contexts service, or a [MemoryContextStore] in tests.
+func NewTenantContexts(store ContextStore, cfg TenantContextsConfig) *TenantContexts {
+	t := &TenantContexts{store: store, cfg: cfg, tenants: map[string]*tenantContext{}}
+	if cfg.File != "" {
+		t.loadErr = t.load()
+	}
+	return t
+}
+
+func (t *TenantContexts) load() error {
+	data, err := os.ReadFile(t.cfg.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var ids map[string]string
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return fmt.Errorf("stagehand: invalid tenant contexts file %s: %w", t.cfg.File, err)
+	}
+	for tenant, id := range ids {
+		t.tenants[tenant] = &tenantContext{id: id}
+	}
+	return nil
+}
+
+// save writes the known contexts to the file. The caller holds t.mu.
+func (t *TenantContexts) save() error {
+	if t.cfg.File == "" {
+		return nil
+	}
+	ids := map[string]string{}
+	for tenant, c := range t.tenants {
+		if c.id != "" {
+			ids[tenant] = c.id
+		}
+	}
+	data, err := json.MarshalIndent(ids, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.cfg.File), 0o700); err != nil {
+		return err
+	}
+	tmpPath := t.cfg.File + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, t.cfg.File)
+}
+
+// ContextID returns the ID of the tenant's context, creating the context if
+// the tenant has none yet.
+func (t *TenantContexts) ContextID(ctx context.Context, tenant string) (string, error) {
+	if tenant == "" {
+		return "", errors.New("stagehand: missing tenant")
+	}
+	t.mu.Lock()
+	if t.loadErr != nil {
+		t.mu.Unlock()
+		return "", t.loadErr
+	}
+	c, ok := t.tenants[tenant]
+	if !ok {
+		c = &tenantContext{}
+		t.tenants[tenant] = c
+	}
+	t.mu.Unlock()
+
+	// Concurrent first sessions of a tenant share the context created by
+	// whichever gets here first.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.id != "" {
+		return c.id, nil
+	}
+	created, err := t.store.New(ctx, ContextNewParams{ProjectID: t.cfg.ProjectID})
+	if err != nil {
+		return "", fmt.Errorf("stagehand: failed to create a context for tenant %s: %w", tenant, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c.id = created.ID
+	return c.id, t.save()
+}
+
+// Lookup returns the ID of the tenant's context without creating one.
+func (t *TenantContexts) Lookup(tenant string) (string, bool) {
+	t.mu.Lock()
+	c, ok := t.tenants[tenant]
+	t.mu.Unlock()
+	if !ok {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.id, c.id != ""
+}
+
+// Delete deletes the tenant's context, if it has one, so its next session
+// starts from a fresh context.
+func (t *TenantContexts) Delete(ctx context.Context, tenant string) error {
+	t.mu.Lock()
+	c, ok := t.tenants[tenant]
+	t.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.id == "" {
+		return nil
+	}
+	if err := t.store.Delete(ctx, c.id); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c.id = ""
+	delete(t.tenants, tenant)
+	return t.save()
+}
+
+// ForTenant returns a RequestOption that starts sessions in the tenant's
+// context, persisting changes to it when the session ends. It only affects
+// start calls, and leaves a context set by the call itself alone.
+//
+//	client.Sessions.Start(ctx, params, tenants.ForTenant("acme"))
+func (t *TenantContexts) ForTenant(tenant string) option.RequestOption {
+	return requestconfig.RequestOptionFunc(func(r *requestconfig.RequestConfig) error {
+		if r.Request.Method != http.MethodPost || strings.Trim(r.Request.URL.Path, "/") != "v1/sessions/start" {
+			return nil
+		}
+		r.PostApply = append(r.PostApply, func(r *requestconfig.RequestConfig) error {
+			buffer, ok := r.Body.(*bytes.Buffer)
+			if !ok || gjson.GetBytes(buffer.Bytes(), "browserbaseSessionCreateParams.browserSettings.context.id").Exists() {
+				return nil
+			}
+			ctx := r.Context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			id, err := t.ContextID(ctx, tenant)
+			if err != nil {
+				return err
+			}
+			body, err := sjson.SetBytes(buffer.Bytes(), "browserbaseSessionCreateParams.browserSettings.context", map[string]any{"id": id, "persist": true})
+			if err != nil {
+				return err
+			}
+			r.Body = bytes.NewBuffer(body)
+			return nil
+		})
+		return nil
+	})
+}
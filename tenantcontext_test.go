@@ -0,0 +1,94 @@
+// Custom tests. Not generated by Stainless.
+package stagehand_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+	"github.com/tidwall/gjson"
+)
+
+func TestTenantContexts(t *testing.T) {
+	store := &stagehand.MemoryContextStore{}
+	file := filepath.Join(t.TempDir(), "tenants.json")
+	tenants := stagehand.NewTenantContexts(store, stagehand.TenantContextsConfig{File: file})
+
+	var mu sync.Mutex
+	var contexts []string
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://stagehand.test"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithHTTPClient(&http.Client{
+			Transport: &closureTransport{
+				fn: func(req *http.Request) (*http.Response, error) {
+					body, _ := io.ReadAll(req.Body)
+					mu.Lock()
+					contexts = append(contexts, gjson.GetBytes(body, "browserbaseSessionCreateParams.browserSettings.context").Raw)
+					mu.Unlock()
+					return jsonResponse(http.StatusOK, `{"success":true,"data":{"available":true,"sessionId":"session"}}`), nil
+				},
+			},
+		}),
+	)
+	ctx := context.Background()
+	params := stagehand.SessionStartParams{ModelName: "openai/gpt-5.4-mini"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Sessions.Start(ctx, params, tenants.ForTenant("acme")); err != nil {
+				t.Errorf("start: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := client.Sessions.Start(ctx, params, tenants.ForTenant("globex")); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if store.Len() != 2 {
+		t.Fatalf("expected one context per tenant, got %d", store.Len())
+	}
+	acme, _ := tenants.Lookup("acme")
+	for _, c := range contexts[:5] {
+		if c != `{"id":"`+acme+`","persist":true}` {
+			t.Fatalf("expected the sessions of acme to use its context, got %s", c)
+		}
+	}
+	if globex, _ := tenants.Lookup("globex"); !strings.Contains(contexts[5], globex) || globex == acme {
+		t.Fatalf("expected globex to have its own context, got %s", contexts[5])
+	}
+
+	// A context set by the call itself is kept.
+	explicit := params
+	explicit.BrowserbaseSessionCreateParams.BrowserSettings.Context.ID = "mine"
+	if _, err := client.Sessions.Start(ctx, explicit, tenants.ForTenant("acme")); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if contexts[6] != `{"id":"mine"}` {
+		t.Fatalf("expected the explicit context, got %s", contexts[6])
+	}
+
+	// Contexts are remembered across runs through the file.
+	reloaded := stagehand.NewTenantContexts(store, stagehand.TenantContextsConfig{File: file})
+	if id, err := reloaded.ContextID(ctx, "acme"); err != nil || id != acme {
+		t.Fatalf("expected the context of acme to be reused, got %q, %v", id, err)
+	}
+	if err := reloaded.Delete(ctx, "acme"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := reloaded.Lookup("acme"); ok || store.Len() != 1 {
+		t.Fatalf("expected the context of acme to be deleted")
+	}
+	if id, _ := reloaded.ContextID(ctx, "acme"); id == acme {
+		t.Fatalf("expected a new context for acme")
+	}
+}
@@ -0,0 +1,43 @@
+// Custom code. Not generated by Stainless.
+package stagehand
+
+import (
+	"net/http"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+// transportFromOptions builds the transport configured by the transport
+// options among opts, such as [option.WithProxy]. It returns nil if there are
+// none.
+func transportFromOptions(opts []option.RequestOption) (http.RoundTripper, error) {
+	var settings option.TransportSettings
+	found := false
+	for _, opt := range opts {
+		if transportOpt, ok := opt.(option.TransportOption); ok {
+			transportOpt.ConfigureTransport(&settings)
+			found = true
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	return option.NewTransport(settings)
+}
+
+// withTransport returns the options with an HTTP client using transport in
+// front, so that an explicit [option.WithHTTPClient] still takes precedence.
+// An invalid transport configuration fails every request; use
+// [NewClientFromConfig] to get the error when the client is built.
+func withTransport(opts []option.RequestOption, transport http.RoundTripper, err error) []option.RequestOption {
+	if err != nil {
+		return append(opts, requestconfig.RequestOptionFunc(func(*requestconfig.RequestConfig) error {
+			return err
+		}))
+	}
+	if transport == nil {
+		return opts
+	}
+	return append([]option.RequestOption{option.WithHTTPClient(&http.Client{Transport: transport})}, opts...)
+}
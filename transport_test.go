@@ -0,0 +1,182 @@
+// Custom tests. Not generated by Stainless.
+package stagehand_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/browserbase/stagehand-go/v3"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+const startResponse = `{"success":true,"data":{"available":true,"sessionId":"session"}}`
+
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newClientCertificate writes a self-signed client certificate and its key,
+// returning their paths and the certificate.
+func newClientCertificate(t *testing.T) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "stagehand-test-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "client.pem", "CERTIFICATE", der), writePEM(t, "client-key.pem", "EC PRIVATE KEY", keyDER), cert
+}
+
+func TestTransportCABundleAndClientCertificate(t *testing.T) {
+	certFile, keyFile, clientCert := newClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	var peer string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer = r.TLS.PeerCertificates[0].Subject.CommonName
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(startResponse))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", srv.Certificate().Raw)
+
+	client := stagehand.NewClient(
+		option.WithBaseURL(srv.URL),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(0),
+		option.WithCABundle(caFile),
+		option.WithClientCertificate(certFile, keyFile),
+		option.WithTLSHandshakeTimeout(5*time.Second),
+	)
+	if _, err := client.Sessions.Start(context.Background(), stagehand.SessionStartParams{}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if peer != "stagehand-test-client" {
+		t.Fatalf("expected the client certificate to be presented, got %q", peer)
+	}
+
+	client = stagehand.NewClient(
+		option.WithBaseURL(srv.URL),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(0),
+		option.WithCABundle(caFile),
+	)
+	if _, err := client.Sessions.Start(context.Background(), stagehand.SessionStartParams{}); err == nil {
+		t.Fatalf("expected the server to reject a client without a certificate")
+	}
+}
+
+func TestTransportProxy(t *testing.T) {
+	var target string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target = r.URL.String()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(startResponse))
+	}))
+	defer proxy.Close()
+
+	client := stagehand.NewClient(
+		option.WithBaseURL("http://api.stagehand.test/"),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(0),
+		option.WithProxy(proxy.URL),
+	)
+	if _, err := client.Sessions.Start(context.Background(), stagehand.SessionStartParams{}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if target != "http://api.stagehand.test/v1/sessions/start" {
+		t.Fatalf("expected the request to go through the proxy, got %q", target)
+	}
+}
+
+func TestTransportProxySkipsLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(startResponse))
+	}))
+	defer srv.Close()
+
+	client := stagehand.NewClient(
+		option.WithBaseURL(srv.URL),
+		option.WithModelAPIKey("My Model API Key"),
+		option.WithMaxRetries(0),
+		// Nothing listens here, so the request only succeeds if it bypasses
+		// the proxy.
+		option.WithProxy("http://127.0.0.1:1"),
+	)
+	if _, err := client.Sessions.Start(context.Background(), stagehand.SessionStartParams{}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+}
+
+func TestTransportInvalidSettings(t *testing.T) {
+	for name, opt := range map[string]option.RequestOption{
+		"missing CA bundle":  option.WithCABundle(filepath.Join(t.TempDir(), "missing.pem")),
+		"unsupported proxy":  option.WithProxy("ftp://proxy.test"),
+		"certificate no key": option.WithClientCertificate("client.pem", ""),
+		"invalid server":     option.WithServer("cloud"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Settings are checked when the option is built.
+			if err := option.Validate(opt); err == nil || !strings.Contains(err.Error(), "option:") {
+				t.Fatalf("expected the option to be invalid, got %v", err)
+			}
+			if _, err := stagehand.NewClientFromConfig(opt); err == nil || !strings.Contains(err.Error(), "option:") {
+				t.Fatalf("expected NewClientFromConfig to fail, got %v", err)
+			}
+
+			client := stagehand.NewClient(
+				option.WithBaseURL("http://api.stagehand.test/"),
+				option.WithModelAPIKey("My Model API Key"),
+				opt,
+			)
+			_, err := client.Sessions.Start(context.Background(), stagehand.SessionStartParams{})
+			if err == nil || !strings.Contains(err.Error(), "option:") {
+				t.Fatalf("expected a transport configuration error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// Custom code. Not generated by Stainless.
+package stagehand
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"slices"
+
+	"github.com/browserbase/stagehand-go/v3/internal/requestconfig"
+	"github.com/browserbase/stagehand-go/v3/option"
+)
+
+// browserbaseUploadDir is where Browserbase puts the files uploaded to a
+// session, on the browser's machine.
+const browserbaseUploadDir = "/tmp/.uploads"
+
+// UploadService transfers files to the browsers of Browserbase sessions, so
+// pages can be given them, for example through a file input.
+type UploadService struct {
+	Options []option.RequestOption
+	baseURL string
+}
+
+// NewUploadService generates a new service that applies the given options to
+// each request. The Browserbase API URL is taken from
+// [option.WithBrowserbaseAPIURL], if given.
+func NewUploadService(opts ...option.RequestOption) (r UploadService) {
+	r = UploadService{Options: opts, baseURL: browserbaseAPIURLFromOptions(opts)}
+	return
+}
+
+// New uploads a file to the browser of a Browserbase session and returns its
+// path on the browser's machine.
+func (r *UploadService) New(ctx context.Context, sessionID string, name string, content io.Reader, opts ...option.RequestOption) (string, error) {
+	if sessionID == "" {
+		return "", errors.New("missing required sessionID parameter")
+	}
+	name = path.Base(name)
+	if name == "." || name == "/" {
+		return "", errors.New("missing required name parameter")
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, content); err != nil {
+		return "", err
+	}
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	opts = slices.Concat(r.Options, opts, []option.RequestOption{
+		option.WithHeader("Content-Type", form.FormDataContentType()),
+		browserbaseRequest{},
+	})
+	url := fmt.Sprintf("%sv1/sessions/%s/uploads", r.baseURL, sessionID)
+	if err := requestconfig.ExecuteNewRequest(ctx, http.MethodPost, url, body.Bytes(), nil, opts...); err != nil {
+		return "", err
+	}
+	return browserbaseUploadDir + "/" + name, nil
+}